@@ -1,18 +1,23 @@
 #!/bin/bash
 
 # This script performs cleanup by:
-# 1. Removing all docker containers (node0-node4)
+# 1. Removing all node containers and stopping the local network if there is one
 # 2. Recursively deleting the ./data directory while preserving any *_key.txt files
 # 3. Restoring the preserved *_key.txt files to a fresh ./data directory
 
 set -euo pipefail
 
-for i in {0..100}; do
-  docker stop "node${i}" 2>/dev/null || true
-  docker rm "node${i}" 2>/dev/null || true
-done
+# Removes every container labeled by the tool plus the nodes of the workspace
+if [ -x ./etnacli ]; then
+  ETNACLI=./etnacli
+else
+  ETNACLI="go run ."
+fi
+$ETNACLI nodes down || true
 echo "- Removed all containers"
 
+$ETNACLI local-network stop || true
+
 mkdir -p data_backup
 if mv data/*_key.txt data_backup/ 2>/dev/null; then
   echo "- Moved all *_key.txt files to data_backup"
@@ -20,7 +25,7 @@ else
   echo "- No *_key.txt files to move"
 fi
 
-sudo rm -rf data/*.txt data/*.json data/chains/ ./data/add_validator_*
+sudo rm -rf data/*.txt data/*.json data/chains/ data/tmpnet/ ./data/add_validator_*
 echo "- Removed data directory's *.txt and *.json files keeping node keys and data"
 
 mkdir -p data
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/ava-labs/avalanchego/genesis"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/tests/fixture/tmpnet"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/spf13/cobra"
+)
+
+// LocalFundingAmount is what transfer-coins sends from ewoq on a local network
+var LocalFundingAmount = 1000 * units.Avax
+
+const localNetworkTimeout = 5 * time.Minute
+
+var localNetworkNodeCount int
+
+func init() {
+	rootCmd.AddCommand(localNetworkCmd)
+	localNetworkCmd.AddCommand(localNetworkStartCmd)
+	localNetworkCmd.AddCommand(localNetworkStopCmd)
+	localNetworkCmd.AddCommand(localNetworkStatusCmd)
+
+	localNetworkStartCmd.Flags().IntVar(&localNetworkNodeCount, "nodes", tmpnet.DefaultNodeCount, "Number of primary network validators")
+}
+
+var localNetworkCmd = &cobra.Command{
+	Use:   "local-network",
+	Short: "Run the whole flow against a throwaway local primary network instead of Fuji",
+	Long: `Run the whole flow against a throwaway local primary network instead of Fuji.
+local-network start bootstraps a tmpnet network in data/tmpnet with the ewoq key
+pre-funded and switches the workspace to it (data/network.json), every other
+command then talks to it instead of Fuji. It needs a local avalanchego binary
+($AVALANCHEGO_PATH or PATH). ./cleanup.sh stops it and switches back to Fuji.`,
+}
+
+var localNetworkStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Bootstrap the local network or restart a stopped one",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🏠 Starting local network")
+
+		// tmpnet panics on a network without nodes
+		if localNetworkNodeCount < 1 {
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("--nodes must be at least 1, got %d", localNetworkNodeCount))
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), localNetworkTimeout)
+		defer cancel()
+
+		selection, err := LoadNetworkSelection()
+		if err != nil {
+			return err
+		}
+
+		if selection.IsLocal() {
+			if _, err := GetRPCURL(); err == nil {
+				log.Printf("✅ Local network at %s is already running\n", selection.TmpnetDir)
+				return printLocalNetwork(ctx, selection)
+			}
+			if err := tmpnet.RestartNetwork(ctx, os.Stdout, selection.TmpnetDir); err != nil {
+				return fmt.Errorf("failed to restart local network: %w", err)
+			}
+			log.Printf("✅ Restarted local network at %s\n", selection.TmpnetDir)
+			return printLocalNetwork(ctx, selection)
+		}
+
+		subnetExists, err := helpers.FileExists(helpers.SubnetIdPath)
+		if err != nil {
+			return err
+		}
+		if subnetExists {
+			return fmt.Errorf("the workspace already has a subnet on %s, run ./cleanup.sh first", selection.Name)
+		}
+
+		avalanchegoPath := os.Getenv("AVALANCHEGO_PATH")
+		if avalanchegoPath == "" {
+			avalanchegoPath = "avalanchego"
+		}
+		avalanchegoPath, err = exec.LookPath(avalanchegoPath)
+		if err != nil {
+			return fmt.Errorf("avalanchego binary not found, set AVALANCHEGO_PATH: %w", err)
+		}
+
+		network := &tmpnet.Network{
+			Owner:         "etna-devnet",
+			Nodes:         tmpnet.NewNodesOrPanic(localNetworkNodeCount),
+			PreFundedKeys: []*secp256k1.PrivateKey{genesis.EWOQKey},
+		}
+		if err := network.EnsureDefaultConfig(os.Stdout, avalanchegoPath, ""); err != nil {
+			return fmt.Errorf("failed to configure local network: %w", err)
+		}
+		network.Genesis, err = tmpnet.NewTestGenesis(localNetworkID, network.Nodes, network.PreFundedKeys)
+		if err != nil {
+			return fmt.Errorf("failed to create local network genesis: %w", err)
+		}
+
+		rootDir, err := filepath.Abs(helpers.TmpnetRootDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", helpers.TmpnetRootDir, err)
+		}
+		if err := network.Create(rootDir); err != nil {
+			return fmt.Errorf("failed to create local network: %w", err)
+		}
+		// Saved before bootstrapping so that cleanup.sh can stop a network that never became healthy
+		selection = NetworkSelection{Name: LocalNetwork, TmpnetDir: network.Dir}
+		if err := SaveNetworkSelection(selection); err != nil {
+			return fmt.Errorf("failed to save network selection: %w", err)
+		}
+		if err := network.Bootstrap(ctx, os.Stdout); err != nil {
+			return fmt.Errorf("failed to bootstrap local network: %w", err)
+		}
+
+		log.Printf("✅ Local network bootstrapped at %s\n", network.Dir)
+		return printLocalNetwork(ctx, selection)
+	},
+}
+
+var localNetworkStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the local network nodes, state stays on disk",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🏠 Stopping local network")
+
+		selection, err := LoadNetworkSelection()
+		if err != nil {
+			return err
+		}
+		if !selection.IsLocal() {
+			log.Printf("Workspace is on %s, nothing to stop\n", selection.Name)
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), localNetworkTimeout)
+		defer cancel()
+		if err := tmpnet.StopNetwork(ctx, selection.TmpnetDir); err != nil {
+			return fmt.Errorf("failed to stop local network: %w", err)
+		}
+		log.Printf("✅ Stopped local network at %s\n", selection.TmpnetDir)
+		SetResult("dir", selection.TmpnetDir)
+		return nil
+	},
+}
+
+var localNetworkStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print the network the workspace uses and the local network nodes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selection, err := LoadNetworkSelection()
+		if err != nil {
+			return err
+		}
+		if !selection.IsLocal() {
+			fmt.Printf("Network: %s\n", selection.Name)
+			SetResult("network", selection.Name)
+			return nil
+		}
+
+		ctx, cancel := RPCContext(cmd.Context())
+		defer cancel()
+		return printLocalNetwork(ctx, selection)
+	},
+}
+
+func printLocalNetwork(ctx context.Context, selection NetworkSelection) error {
+	network, err := selection.ReadTmpnet()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Network: %s (ID %d)\n", selection.Name, network.GetNetworkID())
+	fmt.Printf("Dir: %s\n", network.Dir)
+	fmt.Printf("Funded key: ewoq, P-chain address %s\n", genesis.EWOQKey.Address())
+	SetResult("network", selection.Name)
+	SetResult("networkID", network.GetNetworkID())
+	SetResult("dir", network.Dir)
+	SetResult("fundedPChainAddress", genesis.EWOQKey.Address().String())
+	for _, node := range network.Nodes {
+		state := "stopped"
+		if node.URI != "" {
+			state = "unhealthy"
+			if healthy, err := node.IsHealthy(ctx); err == nil && healthy {
+				state = "healthy"
+			}
+		}
+		fmt.Printf("%s: %s\n", node.NodeID, state)
+		nodeResult := map[string]any{"nodeID": node.NodeID.String(), "state": state}
+		if node.URI != "" {
+			fmt.Printf("  URI: %s\n", node.URI)
+			fmt.Printf("  Staking address: %s\n", node.StakingAddress)
+			nodeResult["uri"] = node.URI
+			nodeResult["stakingAddress"] = node.StakingAddress.String()
+		}
+		AppendResult("nodes", nodeResult)
+	}
+	return nil
+}
+
+// FundFromEwoq sends amount from the pre-funded ewoq key to addr on the P-chain
+func FundFromEwoq(ctx context.Context, addr ids.ShortID, amount uint64) error {
+	rpcURL, err := GetRPCURL()
+	if err != nil {
+		return fmt.Errorf("failed to get RPC URL: %w", err)
+	}
+
+	kc := secp256k1fx.NewKeychain(genesis.EWOQKey)
+	wallet, err := primary.MakeWallet(ctx, &primary.WalletConfig{
+		URI:          rpcURL,
+		AVAXKeychain: kc,
+		EthKeychain:  kc,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize ewoq wallet: %w", err)
+	}
+
+	pWallet := wallet.P()
+	tx, err := pWallet.IssueBaseTx([]*avax.TransferableOutput{{
+		Asset: avax.Asset{ID: pWallet.Builder().Context().AVAXAssetID},
+		Out: &secp256k1fx.TransferOutput{
+			Amt: amount,
+			OutputOwners: secp256k1fx.OutputOwners{
+				Threshold: 1,
+				Addrs:     []ids.ShortID{addr},
+			},
+		},
+	}}, common.WithContext(ctx), common.WithAssumeDecided())
+	if err != nil {
+		return fmt.Errorf("failed to transfer from ewoq: %w", err)
+	}
+	if err := AwaitPChainTx(ctx, tx.ID(), "transfer from ewoq"); err != nil {
+		return err
+	}
+	log.Printf("✅ Transferred %s AVAX from ewoq to %s in %s\n", GetBalanceString(new(big.Int).SetUint64(amount), 9), addr, tx.ID())
+	SetResult("fundTxID", tx.ID().String())
+	return nil
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/ava-labs/avalanchego/staking"
 	"github.com/ava-labs/avalanchego/utils/crypto/bls"
 	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/coreth/plugin/evm"
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
 	"github.com/spf13/cobra"
 )
 
@@ -31,7 +33,22 @@ var GenerateKeysCmd = &cobra.Command{
 			helpers.SaveSecp256k1PrivateKey(helpers.ValidatorManagerOwnerKeyPath, ownerKey)
 		}
 
-		return GenerateCredsIfNotExists(helpers.Node0KeysFolder)
+		if err := GenerateCredsIfNotExists(helpers.Node0KeysFolder); err != nil {
+			return err
+		}
+
+		ownerKey, err := helpers.LoadSecp256k1PrivateKey(helpers.ValidatorManagerOwnerKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to load validator manager owner key: %w", err)
+		}
+		SetResult("pChainAddress", ownerKey.Address().String())
+		SetResult("cChainAddress", evm.PublicKeyToEthAddress(ownerKey.PublicKey()).Hex())
+		nodeID, _, err := l1.NodeInfoFromCreds(helpers.Node0KeysFolder)
+		if err != nil {
+			return fmt.Errorf("failed to get node info from creds: %w", err)
+		}
+		SetResult("nodeID", nodeID.String())
+		return nil
 	},
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
@@ -13,6 +14,7 @@ import (
 	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
 
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
 
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/constants"
@@ -35,33 +37,84 @@ var TransferCoinsCmd = &cobra.Command{
 
 		key, err := helpers.LoadSecp256k1PrivateKey(helpers.ValidatorManagerOwnerKeyPath)
 		if err != nil {
-			log.Fatalf("failed to load validator manager owner key: %s\n", err)
+			return fmt.Errorf("failed to load validator manager owner key: %w", err)
 		}
 
 		pChainAddr := key.Address()
 		cChainAddr := evm.PublicKeyToEthAddress(key.PublicKey())
+		SetResult("pChainAddress", pChainAddr.String())
+		SetResult("cChainAddress", cChainAddr.Hex())
 
-		pChainBalance, err := CheckPChainBalance(context.Background(), pChainAddr)
+		ctx := cmd.Context()
+		pChainBalance, err := CheckPChainBalance(ctx, pChainAddr)
 		if err != nil {
+			if IsInterrupted(err) {
+				return err
+			}
 			log.Printf("Failed to check P-chain balance: %s\n", err)
 		} else {
 			log.Printf("P-chain balance: %s AVAX\n", GetBalanceString(pChainBalance, 9))
 			if pChainBalance.Cmp(big.NewInt(int64(MIN_BALANCE))) >= 0 {
 				log.Printf("P-chain balance sufficient")
+				SetResult("pChainBalance", GetBalanceString(pChainBalance, 9))
 				return nil
 			}
 		}
 
 		log.Printf("P-chain balance insufficient on address %s: %s < %s\n", pChainAddr.String(), GetBalanceString(pChainBalance, 9), MIN_BALANCE_STRING)
 
+		network, err := LoadNetworkSelection()
+		if err != nil {
+			return fmt.Errorf("failed to load network: %w", err)
+		}
+		if network.IsLocal() {
+			// The local network has no faucet, the pre-funded ewoq key pays instead
+			return FundFromEwoq(ctx, pChainAddr, LocalFundingAmount)
+		}
+
+		workspace, err := GetWorkspace()
+		if err != nil {
+			return err
+		}
+		wallet, err := workspace.Wallet(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Get P-chain and C-chain wallets
+		pWallet := wallet.P()
+		cWallet := wallet.C()
+
+		// Setup owner configuration
+		owner := secp256k1fx.OutputOwners{
+			Threshold: 1,
+			Addrs: []ids.ShortID{
+				pChainAddr,
+			},
+		}
+
+		// A run that was interrupted after the export left the funds in
+		// shared memory, they only need the import
+		cChainID := cWallet.Builder().Context().BlockchainID
+		importable, err := pWallet.Builder().GetImportableBalance(cChainID, common.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to get importable balance: %w", err)
+		}
+		if importable[pWallet.Builder().Context().AVAXAssetID] >= MIN_BALANCE {
+			log.Printf("Found %s AVAX exported by an earlier run, importing it\n", GetBalanceString(new(big.Int).SetUint64(importable[pWallet.Builder().Context().AVAXAssetID]), 9))
+			return importToPChain(ctx, workspace, pWallet, cChainID, &owner, pChainAddr)
+		}
+
 		cChainClient, err := ethclient.Dial(config.RPC_URL + "/ext/bc/C/rpc")
 		if err != nil {
-			log.Fatalf("failed to connect to c-chain: %s\n", err)
+			return fmt.Errorf("failed to connect to c-chain: %w", err)
 		}
 
-		cChainBalance, err := cChainClient.BalanceAt(context.Background(), cChainAddr, nil)
+		rpcCtx, cancel := RPCContext(ctx)
+		cChainBalance, err := cChainClient.BalanceAt(rpcCtx, cChainAddr, nil)
+		cancel()
 		if err != nil {
-			log.Fatalf("failed to get balance: %s\n", err)
+			return fmt.Errorf("failed to get balance: %w", err)
 		}
 		// The P chain balance is in nDEVAX (10-9), but the C-chain balance is in WEI (10-18)
 		// So we need to convert it to the same unit
@@ -73,36 +126,13 @@ var TransferCoinsCmd = &cobra.Command{
 			log.Printf("Balance %s is less than minimum balance: %s\n", GetBalanceString(cChainBalance, 9), MIN_BALANCE_STRING)
 			log.Printf("Please visit https://test.core.app/tools/testnet-faucet/?subnet=c&token=c \n")
 			log.Printf("Use this address to request funds: %s\n", cChainAddr.Hex())
-			return fmt.Errorf("transfer to your Fuji C-chain address %s balance to at least %s AVAX", cChainAddr.Hex(), MIN_BALANCE_STRING)
+			return fmt.Errorf("%w on C-chain address %s, transfer at least %s AVAX to it", ErrInsufficientBalance, cChainAddr.Hex(), MIN_BALANCE_STRING)
 		} else {
 			log.Printf("C-chain balance sufficient: current %s, required %s\n", GetBalanceString(cChainBalance, 9), MIN_BALANCE_STRING)
 		}
 
 		log.Printf("Transferring balance from C-chain to P-chain\n")
 
-		// Create keychain and wallet
-		kc := secp256k1fx.NewKeychain(key)
-		wallet, err := primary.MakeWallet(context.Background(), &primary.WalletConfig{
-			URI:          config.RPC_URL,
-			AVAXKeychain: kc,
-			EthKeychain:  kc,
-		})
-		if err != nil {
-			log.Fatalf("failed to initialize wallet: %s\n", err)
-		}
-
-		// Get P-chain and C-chain wallets
-		pWallet := wallet.P()
-		cWallet := wallet.C()
-
-		// Setup owner configuration
-		owner := secp256k1fx.OutputOwners{
-			Threshold: 1,
-			Addrs: []ids.ShortID{
-				pChainAddr,
-			},
-		}
-
 		log.Println("constants.PlatformChainID", constants.PlatformChainID)
 
 		// Export from C-chain
@@ -112,31 +142,44 @@ var TransferCoinsCmd = &cobra.Command{
 				Amt:          cChainBalance.Uint64() - 100*units.MilliAvax,
 				OutputOwners: owner,
 			}},
+			common.WithContext(ctx),
 		)
 		if err != nil {
-			log.Fatalf("failed to issue export transaction: %s\n", err)
+			workspace.InvalidateWallet()
+			return fmt.Errorf("failed to issue export transaction: %w", err)
 		}
 		log.Printf("✅ Issued export %s\n", exportTx.ID())
+		SetResult("exportTxID", exportTx.ID().String())
 
-		// Import to P-chain
-		importTx, err := pWallet.IssueImportTx(cWallet.Builder().Context().BlockchainID, &owner)
-		if err != nil {
-			log.Fatalf("failed to issue import transaction: %s\n", err)
-		}
-		log.Printf("✅ Issued import %s\n", importTx.ID())
+		return importToPChain(ctx, workspace, pWallet, cChainID, &owner, pChainAddr)
+	},
+}
 
-		// Check P-chain balance again after import
-		pChainBalance, err = CheckPChainBalance(context.Background(), pChainAddr)
-		if err != nil {
-			log.Fatalf("failed to get P-chain balance: %s\n", err)
-		}
-		if pChainBalance.Cmp(big.NewInt(int64(MIN_BALANCE))) < 0 {
-			log.Fatalf("❌ Final P-chain balance %s is less than minimum required %s\n", GetBalanceString(pChainBalance, 9), MIN_BALANCE_STRING)
-		}
-		log.Printf("✅ Final P-chain balance: %s (greater than minimum %s)\n", GetBalanceString(pChainBalance, 9), MIN_BALANCE_STRING)
+// importToPChain imports everything exported from the C-chain and checks the
+// P-chain balance afterwards
+func importToPChain(ctx context.Context, workspace *l1.Workspace, pWallet wallet.Wallet, cChainID ids.ID, owner *secp256k1fx.OutputOwners, pChainAddr ids.ShortID) error {
+	importTx, err := pWallet.IssueImportTx(cChainID, owner, common.WithContext(ctx), common.WithAssumeDecided())
+	if err != nil {
+		workspace.InvalidateWallet()
+		return fmt.Errorf("failed to issue import transaction: %w", err)
+	}
+	log.Printf("✅ Issued import %s\n", importTx.ID())
+	SetResult("importTxID", importTx.ID().String())
+	if err := workspace.AwaitTx(ctx, importTx.ID(), "import"); err != nil {
+		return err
+	}
 
-		return nil
-	},
+	// Check P-chain balance again after import
+	pChainBalance, err := CheckPChainBalance(ctx, pChainAddr)
+	if err != nil {
+		return fmt.Errorf("failed to get P-chain balance: %w", err)
+	}
+	if pChainBalance.Cmp(big.NewInt(int64(MIN_BALANCE))) < 0 {
+		return fmt.Errorf("%w on P-chain address %s after the import: %s < %s", ErrInsufficientBalance, pChainAddr, GetBalanceString(pChainBalance, 9), MIN_BALANCE_STRING)
+	}
+	log.Printf("✅ Final P-chain balance: %s (greater than minimum %s)\n", GetBalanceString(pChainBalance, 9), MIN_BALANCE_STRING)
+	SetResult("pChainBalance", GetBalanceString(pChainBalance, 9))
+	return nil
 }
 
 var MIN_BALANCE = 3*units.Avax + 100*units.MilliAvax
@@ -149,11 +192,31 @@ func GetBalanceString(balance *big.Int, decimals int) string {
 	return fmt.Sprintf("%d.%0*d", quotient, decimals, remainder)
 }
 
+// ParseBalanceString is the inverse of GetBalanceString, it turns "1.5" into
+// 1500000000 for 9 decimals
+func ParseBalanceString(balance string, decimals int) (*big.Int, error) {
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(balance), ".")
+	if len(fraction) > decimals {
+		return nil, fmt.Errorf("balance %s has more than %d decimals", balance, decimals)
+	}
+	digits := whole + fraction
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return nil, fmt.Errorf("invalid balance %s", balance)
+	}
+	value, _ := new(big.Int).SetString(digits+strings.Repeat("0", decimals-len(fraction)), 10)
+	return value, nil
+}
+
 func CheckPChainBalance(ctx context.Context, addr ids.ShortID) (*big.Int, error) {
 	addresses := set.Of(addr)
 
+	rpcURL, err := GetRPCURL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RPC URL: %w", err)
+	}
+
 	fetchStartTime := time.Now()
-	state, err := primary.FetchState(ctx, config.RPC_URL, addresses)
+	state, err := primary.FetchState(ctx, rpcURL, addresses)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch state: %w", err)
 	}
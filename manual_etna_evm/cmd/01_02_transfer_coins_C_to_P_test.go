@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseBalanceString(t *testing.T) {
+	tests := []struct {
+		balance  string
+		decimals int
+		want     string
+		wantErr  bool
+	}{
+		{"1.5", 9, "1500000000", false},
+		{"1", 9, "1000000000", false},
+		{"0.000000001", 9, "1", false},
+		{".5", 9, "500000000", false},
+		{"2.", 9, "2000000000", false},
+		{" 3.1 ", 9, "3100000000", false},
+		{"0", 9, "0", false},
+		{"1000000", 18, "1000000000000000000000000", false},
+		{"0.123456789012345678", 18, "123456789012345678", false},
+		{"1.0000000001", 9, "", true},
+		{"-1", 9, "", true},
+		{"+1", 9, "", true},
+		{"1.-5", 9, "", true},
+		{"1.2.3", 9, "", true},
+		{"1e9", 9, "", true},
+		{"", 9, "", true},
+		{".", 9, "", true},
+		{"abc", 9, "", true},
+	}
+	for _, test := range tests {
+		got, err := ParseBalanceString(test.balance, test.decimals)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseBalanceString(%q, %d) = %s, want an error", test.balance, test.decimals, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseBalanceString(%q, %d) failed: %s", test.balance, test.decimals, err)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("ParseBalanceString(%q, %d) = %s, want %s", test.balance, test.decimals, got, test.want)
+		}
+	}
+}
+
+func TestParseBalanceStringRoundTrip(t *testing.T) {
+	for _, value := range []int64{0, 1, 999999999, 1000000000, 3100000000, 123456789012} {
+		formatted := GetBalanceString(big.NewInt(value), 9)
+		parsed, err := ParseBalanceString(formatted, 9)
+		if err != nil {
+			t.Errorf("ParseBalanceString(%q) failed: %s", formatted, err)
+			continue
+		}
+		if parsed.Int64() != value {
+			t.Errorf("ParseBalanceString(GetBalanceString(%d)) = %s", value, parsed)
+		}
+	}
+}
@@ -4,15 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"time"
 
-	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
 	"github.com/spf13/cobra"
-
-	"github.com/ava-labs/avalanchego/ids"
-	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
-	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
 )
 
 func init() {
@@ -32,52 +27,43 @@ var CreateSubnetCmd = &cobra.Command{
 		}
 		if exists {
 			log.Println("Subnet already exists, exiting")
+			subnetID, err := helpers.LoadId(helpers.SubnetIdPath)
+			if err != nil {
+				return fmt.Errorf("failed to load subnet ID: %w", err)
+			}
+			SetResult("subnetID", subnetID.String())
 			return nil
 		}
 
-		// If we get here, we need to create a new subnet
-		key, err := helpers.LoadSecp256k1PrivateKey(helpers.ValidatorManagerOwnerKeyPath)
+		workspace, err := GetWorkspace()
 		if err != nil {
-			return fmt.Errorf("failed to load validator manager owner key: %w", err)
+			return err
 		}
-
-		kc := secp256k1fx.NewKeychain(key)
-		subnetOwner := key.Address()
-
-		ctx := context.Background()
-
-		// MakeWallet fetches the available UTXOs owned by [kc] on the network that
-		// [uri] is hosting.
-		walletSyncStartTime := time.Now()
-		wallet, err := primary.MakeWallet(ctx, &primary.WalletConfig{
-			URI:          config.RPC_URL,
-			AVAXKeychain: kc,
-			EthKeychain:  kc,
+		ctx := cmd.Context()
+		subnetID, err := ResumePendingTx(ctx, helpers.SubnetIdPath, func(ctx context.Context, txID ids.ID) error {
+			return workspace.AwaitTx(ctx, txID, "create subnet")
 		})
 		if err != nil {
-			log.Fatalf("❌ Failed to initialize wallet: %s\n", err)
+			return err
 		}
-		log.Printf("Synced wallet in %s\n", time.Since(walletSyncStartTime))
-
-		// Pull out useful constants to use when issuing transactions.
-		owner := &secp256k1fx.OutputOwners{
-			Locktime:  0,
-			Threshold: 1,
-			Addrs:     []ids.ShortID{subnetOwner},
+		if subnetID != ids.Empty {
+			SetResult("subnetID", subnetID.String())
+			SetResult("txID", subnetID.String())
+			return nil
 		}
 
-		createSubnetStartTime := time.Now()
-		createSubnetTx, err := wallet.P().IssueCreateSubnetTx(owner)
+		subnetID, err = workspace.CreateSubnet(ctx)
 		if err != nil {
-			log.Fatalf("❌ Failed to issue create subnet transaction: %s\n", err)
+			return SavePendingTx(helpers.SubnetIdPath, subnetID, err)
 		}
-		log.Printf("✅ Created new subnet %s in %s\n", createSubnetTx.ID(), time.Since(createSubnetStartTime))
 
 		// Save the subnet ID to file
-		err = helpers.SaveId(helpers.SubnetIdPath, createSubnetTx.ID())
+		err = helpers.SaveId(helpers.SubnetIdPath, subnetID)
 		if err != nil {
 			return fmt.Errorf("failed to save subnet ID: %w", err)
 		}
+		SetResult("subnetID", subnetID.String())
+		SetResult("txID", subnetID.String())
 		return nil
 	},
 }
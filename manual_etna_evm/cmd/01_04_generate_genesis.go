@@ -24,6 +24,11 @@ import (
 	"github.com/ava-labs/subnet-evm/core"
 	"github.com/ava-labs/subnet-evm/core/types"
 	"github.com/ava-labs/subnet-evm/params"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/deployerallowlist"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/feemanager"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/nativeminter"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/txallowlist"
+	"github.com/ava-labs/subnet-evm/utils"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -31,6 +36,17 @@ var (
 	defaultPoAOwnerBalance = new(big.Int).Mul(vm.OneAvax, big.NewInt(10)) // 10 Native Tokens
 )
 
+const (
+	feeManagerPrecompile        = "fee-manager"
+	nativeMinterPrecompile      = "native-minter"
+	deployerAllowListPrecompile = "deployer-allow-list"
+	txAllowListPrecompile       = "tx-allow-list"
+)
+
+var (
+	genesisPrecompiles []string
+)
+
 //go:embed proxy_compiled/deployed_proxy_admin_bytecode.txt
 var proxyAdminBytecodeHexString string
 
@@ -39,6 +55,8 @@ var transparentProxyBytecodeHexString string
 
 func init() {
 	rootCmd.AddCommand(GenerateGenesisCmd)
+	GenerateGenesisCmd.Flags().StringSliceVar(&genesisPrecompiles, "precompiles", []string{},
+		fmt.Sprintf("Precompiles to enable with the owner as admin (%s, %s, %s, %s), none by default", feeManagerPrecompile, nativeMinterPrecompile, deployerAllowListPrecompile, txAllowListPrecompile))
 }
 
 var GenerateGenesisCmd = &cobra.Command{
@@ -145,6 +163,24 @@ var GenerateGenesisCmd = &cobra.Command{
 			"requirePrimaryNetworkSigners": true,
 		}
 
+		// Enable the requested precompiles with the owner as the only admin
+		admins := []common.Address{ethAddr}
+		activation := utils.NewUint64(uint64(now))
+		for _, precompile := range genesisPrecompiles {
+			switch precompile {
+			case feeManagerPrecompile:
+				configMap[feemanager.ConfigKey] = feemanager.NewConfig(activation, admins, nil, nil, nil)
+			case nativeMinterPrecompile:
+				configMap[nativeminter.ConfigKey] = nativeminter.NewConfig(activation, admins, nil, nil, nil)
+			case deployerAllowListPrecompile:
+				configMap[deployerallowlist.ConfigKey] = deployerallowlist.NewConfig(activation, admins, nil, nil)
+			case txAllowListPrecompile:
+				configMap[txallowlist.ConfigKey] = txallowlist.NewConfig(activation, admins, nil, nil)
+			default:
+				return fmt.Errorf("unknown precompile: %s", precompile)
+			}
+		}
+
 		prettyJSON, err := json.MarshalIndent(genesisMap, "", "  ")
 		if err != nil {
 			return fmt.Errorf("failed to marshal genesis: %s\n", err)
@@ -156,6 +192,11 @@ var GenerateGenesisCmd = &cobra.Command{
 		}
 
 		log.Printf("Successfully wrote genesis to data/L1-genesis.json\n")
+		SetResult("genesisPath", helpers.L1GenesisPath)
+		SetResult("evmChainID", config.L1_CHAIN_ID)
+		SetResult("ownerAddress", ethAddr.Hex())
+		SetResult("validatorManagerAddress", config.ProxyContractAddress)
+		SetResult("precompiles", genesisPrecompiles)
 
 		return nil
 	},
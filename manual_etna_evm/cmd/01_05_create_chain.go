@@ -4,16 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"time"
 
-	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
 	"github.com/spf13/cobra"
 
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/constants"
-	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
-	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
 )
 
 func init() {
@@ -33,15 +29,14 @@ var CreateChainCmd = &cobra.Command{
 		}
 		if exists {
 			log.Println("Chain already exists, exiting")
+			chainID, err := helpers.LoadId(helpers.ChainIdPath)
+			if err != nil {
+				return fmt.Errorf("failed to load chain ID: %w", err)
+			}
+			SetResult("chainID", chainID.String())
 			return nil
 		}
 
-		key, err := helpers.LoadSecp256k1PrivateKey(helpers.ValidatorManagerOwnerKeyPath)
-		if err != nil {
-			return fmt.Errorf("failed to load validator manager owner key: %w", err)
-		}
-		kc := secp256k1fx.NewKeychain(key)
-
 		subnetID, err := helpers.LoadId(helpers.SubnetIdPath)
 		if err != nil {
 			return fmt.Errorf("failed to load subnet ID: %w", err)
@@ -54,43 +49,37 @@ var CreateChainCmd = &cobra.Command{
 			return fmt.Errorf("failed to load genesis: %w", err)
 		}
 
-		ctx := context.Background()
-
-		// MakeWallet fetches the available UTXOs owned by [kc] on the network that
-		// [uri] is hosting and registers [subnetID].
-		walletSyncStartTime := time.Now()
-		wallet, err := primary.MakeWallet(ctx, &primary.WalletConfig{
-			URI:          config.RPC_URL,
-			AVAXKeychain: kc,
-			EthKeychain:  kc,
-			SubnetIDs:    []ids.ID{subnetID},
+		workspace, err := GetWorkspace()
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+		chainID, err := ResumePendingTx(ctx, helpers.ChainIdPath, func(ctx context.Context, txID ids.ID) error {
+			return workspace.AwaitTx(ctx, txID, "create chain")
 		})
 		if err != nil {
-			return fmt.Errorf("failed to initialize wallet: %w", err)
+			return err
+		}
+		if chainID != ids.Empty {
+			SetResult("subnetID", subnetID.String())
+			SetResult("chainID", chainID.String())
+			SetResult("txID", chainID.String())
+			return nil
 		}
-		log.Printf("synced wallet in %s\n", time.Since(walletSyncStartTime))
-
-		// Get the P-chain wallet
-		pWallet := wallet.P()
 
-		createChainStartTime := time.Now()
-		createChainTx, err := pWallet.IssueCreateChainTx(
-			subnetID,
-			[]byte(genesisString),
-			constants.SubnetEVMID,
-			nil,
-			"My L1",
-		)
+		chainID, err = workspace.CreateChain(ctx, subnetID, []byte(genesisString), "My L1")
 		if err != nil {
-			return fmt.Errorf("failed to issue create chain transaction: %w", err)
+			return SavePendingTx(helpers.ChainIdPath, chainID, err)
 		}
-		log.Printf("Created new chain %s in %s\n", createChainTx.ID(), time.Since(createChainStartTime))
 
 		// Save the chain ID to file
-		err = helpers.SaveId(helpers.ChainIdPath, createChainTx.ID())
+		err = helpers.SaveId(helpers.ChainIdPath, chainID)
 		if err != nil {
 			return fmt.Errorf("failed to save chain ID: %w", err)
 		}
+		SetResult("subnetID", subnetID.String())
+		SetResult("chainID", chainID.String())
+		SetResult("txID", chainID.String())
 
 		log.Println("Saved chain ID to file")
 		return nil
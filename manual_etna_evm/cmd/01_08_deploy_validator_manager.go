@@ -1,10 +1,8 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
@@ -45,7 +43,8 @@ var deployValidatorManagerCmd = &cobra.Command{
 			return fmt.Errorf("failed to load private key: %w", err)
 		}
 
-		ethClient, evmChainId, err := GetLocalEthClient("9650")
+		ctx := cmd.Context()
+		ethClient, evmChainId, err := GetLocalEthClient(ctx, "9650")
 		if err != nil {
 			return fmt.Errorf("failed to connect to client: %w", err)
 		}
@@ -53,13 +52,16 @@ var deployValidatorManagerCmd = &cobra.Command{
 		myEthAddr := evm.PublicKeyToEthAddress(privKey.PublicKey())
 		expectedContractAddress := MustDeriveContractAddress(myEthAddr, 1)
 
-		deployedBytecode, err := ethClient.CodeAt(context.Background(), expectedContractAddress, nil)
+		rpcCtx, cancel := RPCContext(ctx)
+		deployedBytecode, err := ethClient.CodeAt(rpcCtx, expectedContractAddress, nil)
+		cancel()
 		if err != nil {
 			return fmt.Errorf("failed to get deployed bytecode: %w", err)
 		}
 
 		if len(deployedBytecode) > 0 {
 			log.Printf("Validator manager already deployed at: %s\n", expectedContractAddress)
+			SetResult("implementationAddress", expectedContractAddress.Hex())
 			return nil
 		}
 
@@ -69,6 +71,7 @@ var deployValidatorManagerCmd = &cobra.Command{
 		}
 		opts.GasLimit = 8000000
 		opts.GasPrice = nil
+		opts.Context = ctx
 
 		var newContractAddress common.Address
 		var tx *types.Transaction
@@ -105,10 +108,12 @@ var deployValidatorManagerCmd = &cobra.Command{
 			return fmt.Errorf("expected contract address %s, got %s", expectedContractAddress, newContractAddress)
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		// An interrupted deployment is picked up through the code at the
+		// expected address once the tx is mined
+		txCtx, cancel := TxContext(ctx)
 		defer cancel()
 
-		_, err = bind.WaitMined(ctx, ethClient, tx)
+		_, err = bind.WaitMined(txCtx, ethClient, tx)
 		if err != nil {
 			return fmt.Errorf("failed to wait for transaction confirmation: %w", err)
 		}
@@ -119,6 +124,11 @@ var deployValidatorManagerCmd = &cobra.Command{
 		}
 
 		fmt.Printf("Validator manager deployed at: %s\n", tx.Hash().Hex())
+		SetResult("implementationAddress", newContractAddress.Hex())
+		SetResult("txHash", tx.Hash().Hex())
+		if exampleRewardCalculatorAddress != (common.Address{}) {
+			SetResult("exampleRewardCalculatorAddress", exampleRewardCalculatorAddress.Hex())
+		}
 
 		log.Println("Validator manager deployed")
 		return nil
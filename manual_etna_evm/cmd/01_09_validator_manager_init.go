@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"log"
 	"math/big"
-	"time"
 
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
@@ -44,7 +43,8 @@ var validatorManagerInitCmd = &cobra.Command{
 		}
 
 		managerAddress := common.HexToAddress(config.ProxyContractAddress)
-		ethClient, evmChainId, err := GetLocalEthClient("9650")
+		ctx := cmd.Context()
+		ethClient, evmChainId, err := GetLocalEthClient(ctx, "9650")
 		if err != nil {
 			return fmt.Errorf("failed to connect to client: %w", err)
 		}
@@ -67,17 +67,18 @@ var validatorManagerInitCmd = &cobra.Command{
 		}
 		opts.GasLimit = 8000000
 		opts.GasPrice = nil
+		opts.Context = ctx
 
 		var receipt *types.Receipt
 		var tx *types.Transaction
 
 		if validatorType == config.PoAMode {
-			receipt, tx, err = initializeValidatorManagerPoA(validatorType, managerAddress, ethClient, subnetID, opts, ecdsaKey.PublicKey)
+			receipt, tx, err = initializeValidatorManagerPoA(ctx, validatorType, managerAddress, ethClient, subnetID, opts, ecdsaKey.PublicKey)
 			if err != nil {
 				return fmt.Errorf("failed to initialize validator manager: %w", err)
 			}
 		} else if validatorType == config.PoSNativeMode {
-			receipt, tx, err = initializeValidatorManagerPoSNativeTokenStaking(validatorType, managerAddress, ethClient, subnetID, opts, ecdsaKey.PublicKey)
+			receipt, tx, err = initializeValidatorManagerPoSNativeTokenStaking(ctx, validatorType, managerAddress, ethClient, subnetID, opts, ecdsaKey.PublicKey)
 			if err != nil {
 				return fmt.Errorf("failed to initialize validator manager: %w", err)
 			}
@@ -85,24 +86,32 @@ var validatorManagerInitCmd = &cobra.Command{
 			return fmt.Errorf("invalid validator type: %s", validatorType)
 		}
 
+		SetResult("validatorManagerAddress", managerAddress.Hex())
+		if receipt == nil {
+			// Already initialized, the Initialized event was printed instead
+			return nil
+		}
+
 		PrintLogs(receipt.Logs)
 
 		fmt.Printf("Validator manager initialized at: %s\n", tx.Hash().Hex())
+		SetResult("txHash", tx.Hash().Hex())
 
 		return nil
 	},
 }
 
-func initializeValidatorManagerPoA(validatorManagerType string, managerAddress common.Address, ethClient ethclient.Client, subnetID ids.ID, opts *bind.TransactOpts, ecdsaPubKey ecdsa.PublicKey) (*types.Receipt, *types.Transaction, error) {
-	logs, err := ethClient.FilterLogs(context.Background(), interfaces.FilterQuery{
+func initializeValidatorManagerPoA(ctx context.Context, validatorManagerType string, managerAddress common.Address, ethClient ethclient.Client, subnetID ids.ID, opts *bind.TransactOpts, ecdsaPubKey ecdsa.PublicKey) (*types.Receipt, *types.Transaction, error) {
+	rpcCtx, cancel := RPCContext(ctx)
+	logs, err := ethClient.FilterLogs(rpcCtx, interfaces.FilterQuery{
 		Addresses: []common.Address{managerAddress},
 	})
+	cancel()
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get contract logs: %w", err)
 	}
 
-	// Replace sleep with transaction wait
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	ctx, cancel = TxContext(ctx)
 	defer cancel()
 
 	contract, err := poavalidatormanager.NewPoAValidatorManager(managerAddress, ethClient)
@@ -135,16 +144,17 @@ func initializeValidatorManagerPoA(validatorManagerType string, managerAddress c
 	return receipt, tx, nil
 }
 
-func initializeValidatorManagerPoSNativeTokenStaking(validatorManagerType string, managerAddress common.Address, ethClient ethclient.Client, subnetID ids.ID, opts *bind.TransactOpts, ecdsaPubKey ecdsa.PublicKey) (*types.Receipt, *types.Transaction, error) {
-	logs, err := ethClient.FilterLogs(context.Background(), interfaces.FilterQuery{
+func initializeValidatorManagerPoSNativeTokenStaking(ctx context.Context, validatorManagerType string, managerAddress common.Address, ethClient ethclient.Client, subnetID ids.ID, opts *bind.TransactOpts, ecdsaPubKey ecdsa.PublicKey) (*types.Receipt, *types.Transaction, error) {
+	rpcCtx, cancel := RPCContext(ctx)
+	logs, err := ethClient.FilterLogs(rpcCtx, interfaces.FilterQuery{
 		Addresses: []common.Address{managerAddress},
 	})
+	cancel()
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get contract logs: %w", err)
 	}
 
-	// Replace sleep with transaction wait
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	ctx, cancel = TxContext(ctx)
 	defer cancel()
 
 	contract, err := nativetokenstakingmanager.NewNativeTokenStakingManager(managerAddress, ethClient)
@@ -2,25 +2,71 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto/bls"
+	"github.com/ava-labs/avalanchego/utils/formatting/address"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/spf13/cobra"
 )
 
+var (
+	validatorsHeight   string
+	validatorsStatuses []string
+)
+
 func init() {
 	rootCmd.AddCommand(printPChainInfoCmd)
+	printPChainInfoCmd.Flags().StringVar(&validatorsHeight, "height", "proposed", "P-chain height of the validator set, or proposed")
+	printPChainInfoCmd.Flags().StringSliceVar(&validatorsStatuses, "status", nil, "Only list validations with these contract statuses: unknown, pending-added, active, pending-removed, completed or invalidated")
 }
 
 var printPChainInfoCmd = &cobra.Command{
 	Use:   "validators",
 	Short: "Print validators",
+	Long: `Print the P-chain validator set of the L1 with the P-chain and contract state of
+every validation, and whether the node is one of the local nodes and connected to node0.
+At the proposed height it also lists validations of the contract that are not in the set.
+With --height the set is the one at that P-chain height, the states are the current ones.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		PrintHeader("🧱 Printing validators")
 
-		if err := printPChainState(); err != nil {
+		height := l1.ProposedHeight
+		if validatorsHeight != "proposed" {
+			var err error
+			height, err = strconv.ParseUint(validatorsHeight, 10, 64)
+			if err != nil {
+				return WithErrorCode(ErrCodeUsage, fmt.Errorf("failed to parse height %q: %w", validatorsHeight, err))
+			}
+		}
+		statuses := make(map[l1.ValidationStatus]bool)
+		for _, name := range validatorsStatuses {
+			status, err := l1.ParseValidationStatus(name)
+			if err != nil {
+				return WithErrorCode(ErrCodeUsage, err)
+			}
+			statuses[status] = true
+		}
+
+		if err := printValidators(cmd.Context(), height, statuses); err != nil {
+			return fmt.Errorf("failed to print validators: %w", err)
+		}
+		if err := printSubnetInfo(cmd.Context()); err != nil {
 			return fmt.Errorf("failed to print P-Chain state: %w", err)
 		}
 
@@ -35,7 +81,7 @@ type JSONRPCResponse struct {
 	ID      int         `json:"id"`
 }
 
-func makeJSONRPCRequest(client *http.Client, url string, payload map[string]interface{}) (*JSONRPCResponse, error) {
+func makeJSONRPCRequest(ctx context.Context, client *http.Client, url string, payload map[string]interface{}) (*JSONRPCResponse, error) {
 	// Convert payload to JSON
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -43,7 +89,9 @@ func makeJSONRPCRequest(client *http.Client, url string, payload map[string]inte
 	}
 
 	// Create request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payloadBytes))
+	ctx, cancel := RPCContext(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -79,7 +127,7 @@ type ValidatorsResponse struct {
 	Validators map[string]ValidatorInfo
 }
 
-func callPChainValidatorsAt(fujiPChainURL string, subnetID string) (*ValidatorsResponse, error) {
+func callPChainValidatorsAt(ctx context.Context, pChainURL string, subnetID string) (*ValidatorsResponse, error) {
 	client := &http.Client{}
 	validatorsPayload := map[string]interface{}{
 		"jsonrpc": "2.0",
@@ -91,7 +139,7 @@ func callPChainValidatorsAt(fujiPChainURL string, subnetID string) (*ValidatorsR
 		"id": 1,
 	}
 
-	resp, err := makeJSONRPCRequest(client, fujiPChainURL, validatorsPayload)
+	resp, err := makeJSONRPCRequest(ctx, client, pChainURL, validatorsPayload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -121,7 +169,153 @@ func callPChainValidatorsAt(fujiPChainURL string, subnetID string) (*ValidatorsR
 	return validatorsResp, nil
 }
 
-func printPChainState() error {
+// printValidators prints a table of the validators whose contract status is
+// one of statuses, all of them if it is empty
+func printValidators(ctx context.Context, height uint64, statuses map[l1.ValidationStatus]bool) error {
+	manager, err := GetValidatorManager()
+	if err != nil {
+		return err
+	}
+	states, err := manager.ValidatorStates(ctx, height)
+	if err != nil {
+		return err
+	}
+	localNodes, err := localNodesByID(ctx)
+	if err != nil {
+		return err
+	}
+	hrp := constants.GetHRP(manager.L1.Workspace.Network.ID)
+
+	if height == l1.ProposedHeight {
+		SetResult("height", "proposed")
+	} else {
+		SetResult("height", height)
+	}
+	SetResult("validators", []any{})
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "NODE ID\tLOCAL\tCONNECTED\tSTATUS\tWEIGHT\tBALANCE\tSTARTED\tENDED\tNONCE\tVALIDATION ID\tREMAINING BALANCE OWNER")
+	for _, state := range states {
+		status := l1.ValidationUnknown
+		if state.Validation != nil {
+			status = state.Validation.Status
+		}
+		if len(statuses) > 0 && !statuses[status] {
+			continue
+		}
+		result := map[string]any{
+			"nodeID":    state.NodeID.String(),
+			"inSet":     state.InSet,
+			"connected": state.Connected,
+			"status":    status.String(),
+		}
+		row := validatorRow{nodeID: state.NodeID.String(), local: "-", connected: "no", status: status.String(), weight: "-", balance: "-", started: "-", ended: "-", nonce: "-", validationID: "-", owner: "-"}
+		if state.Connected {
+			row.connected = "yes"
+		}
+
+		if node, ok := localNodes[state.NodeID]; ok {
+			row.local = node.name
+			if node.state != "" {
+				row.local += " (" + node.state + ")"
+			}
+			result["local"] = map[string]any{"name": node.name, "state": node.state, "uri": node.uri}
+		}
+		if state.InSet {
+			row.weight = strconv.FormatUint(state.SetWeight, 10)
+			result["weight"] = state.SetWeight
+			if state.PublicKey != nil {
+				result["publicKey"] = hexutil.Encode(bls.PublicKeyToCompressedBytes(state.PublicKey))
+			}
+		}
+		if state.ValidationID != ids.Empty {
+			row.validationID = state.ValidationID.String()
+			result["validationID"] = state.ValidationID.String()
+		}
+		if validator := state.L1Validator; validator != nil {
+			owners, err := formatPChainOwners(hrp, validator.RemainingBalanceOwner)
+			if err != nil {
+				return err
+			}
+			row.balance = fmt.Sprintf("%.4f", float64(validator.Balance)/float64(units.Avax))
+			row.owner = strings.Join(owners, ",")
+			result["balance"] = validator.Balance
+			result["remainingBalanceOwner"] = map[string]any{"threshold": validator.RemainingBalanceOwner.Threshold, "addresses": owners}
+			result["minNonce"] = validator.MinNonce
+		}
+		if validation := state.Validation; validation != nil {
+			row.nonce = strconv.FormatUint(validation.MessageNonce, 10)
+			result["nonce"] = validation.MessageNonce
+			result["contractWeight"] = validation.Weight
+			if validation.StartedAt != 0 {
+				row.started = time.Unix(int64(validation.StartedAt), 0).UTC().Format(time.RFC3339)
+				result["startedAt"] = row.started
+			}
+			if validation.EndedAt != 0 {
+				row.ended = time.Unix(int64(validation.EndedAt), 0).UTC().Format(time.RFC3339)
+				result["endedAt"] = row.ended
+			}
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", row.nodeID, row.local, row.connected, row.status, row.weight, row.balance, row.started, row.ended, row.nonce, row.validationID, row.owner)
+		AppendResult("validators", result)
+	}
+	return table.Flush()
+}
+
+// validatorRow is the columns of a validator in the validators table
+type validatorRow struct {
+	nodeID, local, connected, status, weight, balance, started, ended, nonce, validationID, owner string
+}
+
+// localNode is a managed node with the state its runner reports
+type localNode struct {
+	name  string
+	uri   string
+	state string
+}
+
+// localNodesByID is the managed nodes of the data folder by node ID. Their
+// state is left empty if the node runner is not available.
+func localNodesByID(ctx context.Context) (map[ids.NodeID]localNode, error) {
+	nodes, err := GetManagedNodes()
+	if err != nil {
+		return nil, err
+	}
+	runner, err := GetNodeRunner(ctx, "")
+	if err != nil {
+		log.Printf("⚠️ Node states unknown: %s\n", err)
+	}
+	byID := make(map[ids.NodeID]localNode, len(nodes))
+	for _, node := range nodes {
+		nodeID, _, err := l1.NodeInfoFromCreds(node.CredsFolder)
+		if err != nil {
+			continue
+		}
+		local := localNode{name: node.Name, uri: node.URI()}
+		if runner != nil {
+			if state, err := runner.Status(ctx, node.Name); err == nil {
+				local.state = state.State
+			}
+		}
+		byID[nodeID] = local
+	}
+	return byID, nil
+}
+
+// formatPChainOwners is the P-chain addresses of owners
+func formatPChainOwners(hrp string, owners *secp256k1fx.OutputOwners) ([]string, error) {
+	addresses := make([]string, 0, len(owners.Addrs))
+	for _, addr := range owners.Addrs {
+		formatted, err := address.Format("P", hrp, addr[:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to format address: %w", err)
+		}
+		addresses = append(addresses, formatted)
+	}
+	return addresses, nil
+}
+
+func printSubnetInfo(ctx context.Context) error {
 	subnetID, err := helpers.LoadId(helpers.SubnetIdPath)
 	if err != nil {
 		return fmt.Errorf("failed to load subnet ID: %w", err)
@@ -129,13 +323,11 @@ func printPChainState() error {
 
 	// Make HTTP requests
 	client := &http.Client{}
-	fujiPChainURL := "https://api.avax-test.network/ext/P"
-
-	// Get validators
-	validatorsResp, err := callPChainValidatorsAt(fujiPChainURL, subnetID.String())
+	rpcURL, err := GetRPCURL()
 	if err != nil {
-		return fmt.Errorf("failed to get validators: %w", err)
+		return fmt.Errorf("failed to get RPC URL: %w", err)
 	}
+	pChainURL := rpcURL + "/ext/P"
 
 	// Get subnet info
 	subnetPayload := map[string]interface{}{
@@ -147,22 +339,12 @@ func printPChainState() error {
 		"id": 1,
 	}
 
-	subnetResp, err := makeJSONRPCRequest(client, fujiPChainURL, subnetPayload)
+	subnetResp, err := makeJSONRPCRequest(ctx, client, pChainURL, subnetPayload)
 	if err != nil {
 		return fmt.Errorf("failed to get subnet info: %w", err)
 	}
 
-	// Print results
-	fmt.Println("P-Chain State:")
-	fmt.Println("------------------------")
-
-	fmt.Println("\nValidators:")
-	for nodeID, details := range validatorsResp.Validators {
-		fmt.Printf("NodeID: %s\n", nodeID)
-		fmt.Printf("  Public Key: %s\n", details.PublicKey)
-		fmt.Printf("  Weight: %s\n", details.Weight)
-	}
-
+	SetResult("subnetID", subnetID.String())
 	fmt.Println("\nSubnet Info:")
 	subnetInfo := subnetResp.Result.(map[string]interface{})
 	fmt.Printf("Is Permissioned: %v\n", subnetInfo["isPermissioned"])
@@ -171,6 +353,7 @@ func printPChainState() error {
 	fmt.Printf("Manager Chain ID: %s\n", subnetInfo["managerChainID"])
 	fmt.Printf("Manager Address: %s\n", subnetInfo["managerAddress"])
 	fmt.Printf("IsPermissioned: %v\n", subnetInfo["isPermissioned"])
+	SetResult("subnet", subnetInfo)
 
 	return nil
 }
@@ -2,24 +2,70 @@ package cmd
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math/big"
+	"os"
+	"time"
 
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
-	poavalidatormanager "github.com/ava-labs/icm-contracts/abi-bindings/go/validator-manager/PoAValidatorManager"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/ethclient"
 	"github.com/ava-labs/subnet-evm/interfaces"
-	"github.com/ethereum/go-ethereum"
+	subnetEvmWarp "github.com/ava-labs/subnet-evm/precompile/contracts/warp"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cobra"
 )
 
+var (
+	logsFromBlock uint64
+	logsToBlock   uint64
+	logsEvents    []string
+	logsAddresses []string
+	logsCSV       bool
+
+	logsFollow       bool
+	logsFromBlockSet bool
+	logsPollInterval time.Duration
+	logsExec         string
+	logsWebhook      string
+)
+
+// logsBlockRange bounds the blocks of one eth_getLogs call, public RPC
+// endpoints reject larger ranges
+const logsBlockRange = 2048
+
 func init() {
 	rootCmd.AddCommand(printContractLogsCmd)
+	printContractLogsCmd.Flags().Uint64Var(&logsFromBlock, "from-block", 0, "First block to read logs from")
+	printContractLogsCmd.Flags().Uint64Var(&logsToBlock, "to-block", 0, "Last block to read logs from, 0 for the latest")
+	printContractLogsCmd.Flags().StringSliceVar(&logsEvents, "event", nil, "Only print these events, e.g. ValidationPeriodCreated,Upgraded")
+	printContractLogsCmd.Flags().StringSliceVar(&logsAddresses, "address", []string{config.ProxyContractAddress, config.ProxyAdminContractAddress, subnetEvmWarp.ContractAddress.Hex()}, "Contracts to read logs of")
+	printContractLogsCmd.Flags().BoolVar(&logsCSV, "csv", false, "Print the logs as CSV, the fields as a JSON object per row")
+	printContractLogsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing new logs as they arrive, resuming after the last one printed")
+	printContractLogsCmd.Flags().DurationVar(&logsPollInterval, "poll-interval", 2*time.Second, "Time between two polls when the websocket endpoint is not available")
+	printContractLogsCmd.Flags().StringVar(&logsExec, "exec", "", "With --follow, run this shell command for every log with the log as JSON on stdin")
+	printContractLogsCmd.Flags().StringVar(&logsWebhook, "webhook", "", "With --follow, POST every log as JSON to this URL")
 }
 
 var printContractLogsCmd = &cobra.Command{
-	Use:   "logs",
+	Use:   "logs [port]",
 	Short: "Print contract logs",
+	Long: `Print the logs of the validator manager proxy, its proxy admin and the warp precompile,
+decoded with the ABIs of every validator manager flavour (PoA, native and ERC20 token staking),
+the transparent proxy, the proxy admin and the warp precompile. Logs of other events are printed
+raw.
+
+With --follow the command keeps running and prints new logs as they arrive, through a log
+subscription on the websocket endpoint of the node or by polling if it has none. The last log
+handled is kept in data/logs_follow_cursor.json: after a node restart or a new run it resumes
+right after it, --from-block overrides it. --exec and --webhook get every log as a JSON object, a
+log is only marked handled once they accepted it.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var port string
@@ -29,9 +75,15 @@ var printContractLogsCmd = &cobra.Command{
 			port = "9650"
 		}
 
-		PrintHeader(fmt.Sprintf("🧱 Printing contract logs from localhost:%s", port))
+		logsFromBlockSet = cmd.Flags().Changed("from-block")
+		if (logsExec != "" || logsWebhook != "") && !logsFollow {
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("--exec and --webhook need --follow"))
+		}
+		if !logsCSV {
+			PrintHeader(fmt.Sprintf("🧱 Printing contract logs from localhost:%s", port))
+		}
 
-		if err := printEVMContractLogs(port); err != nil {
+		if err := printEVMContractLogs(cmd.Context(), port); err != nil {
 			return fmt.Errorf("failed to print EVM contract logs: %w", err)
 		}
 
@@ -39,104 +91,154 @@ var printContractLogsCmd = &cobra.Command{
 	},
 }
 
-func printEVMContractLogs(port string) error {
-	managerAddress := common.HexToAddress(config.ProxyContractAddress)
-
-	ethClient, _, err := GetLocalEthClient(port)
+func printEVMContractLogs(ctx context.Context, port string) error {
+	registry, err := l1.DefaultEventRegistry()
 	if err != nil {
-		return fmt.Errorf("failed to connect to client: %s\n", err)
+		return err
 	}
-
-	contract, err := poavalidatormanager.NewPoAValidatorManager(managerAddress, ethClient)
-	if err != nil {
-		return fmt.Errorf("failed to deploy contract: %s\n", err)
+	query := interfaces.FilterQuery{}
+	for _, address := range logsAddresses {
+		if !common.IsHexAddress(address) {
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("invalid address %q", address))
+		}
+		query.Addresses = append(query.Addresses, common.HexToAddress(address))
+	}
+	if len(logsEvents) > 0 {
+		topics, err := registry.Topics(logsEvents)
+		if err != nil {
+			return WithErrorCode(ErrCodeUsage, err)
+		}
+		query.Topics = [][]common.Hash{topics}
 	}
 
-	// Get all logs
-	query := ethereum.FilterQuery{
-		Addresses: []common.Address{managerAddress},
+	var csvWriter *csv.Writer
+	if logsCSV {
+		csvWriter = csv.NewWriter(os.Stdout)
+		if err := csvWriter.Write([]string{"blockNumber", "txHash", "logIndex", "address", "event", "fields"}); err != nil {
+			return err
+		}
+	}
+	if logsFollow {
+		return followContractLogs(ctx, port, registry, query, csvWriter)
 	}
 
-	logs, err := ethClient.FilterLogs(context.Background(), (interfaces.FilterQuery)(query))
+	ethClient, _, err := GetLocalEthClient(ctx, port)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("failed to connect to client: %w", err)
+	}
+	logs, err := filterLogsInRange(ctx, ethClient, query, logsFromBlock, logsToBlock)
+	if err != nil {
+		return err
 	}
 
-	// Print all logs
+	SetResult("logs", []any{})
 	for _, vLog := range logs {
-		fmt.Println("------------------------")
-		fmt.Printf("Log TxHash: %s\n", vLog.TxHash.Hex())
-
-		// Try parsing each event type
-		if event, err := contract.PoAValidatorManagerFilterer.ParseInitialValidatorCreated(vLog); err == nil {
-			fmt.Printf("InitialValidatorCreated:\n")
-			fmt.Printf("  ValidationID: %x\n", event.ValidationID)
-			fmt.Printf("  NodeID: %x\n", event.NodeID)
-			fmt.Printf("  Weight: %d\n", event.Weight)
-			continue
+		result, err := printContractLog(registry, vLog, csvWriter)
+		if err != nil {
+			return err
 		}
+		AppendResult("logs", result)
+	}
+	if csvWriter != nil {
+		csvWriter.Flush()
+		return csvWriter.Error()
+	}
+	return nil
+}
 
-		if event, err := contract.PoAValidatorManagerFilterer.ParseValidationPeriodCreated(vLog); err == nil {
-			fmt.Printf("ValidationPeriodCreated:\n")
-			fmt.Printf("  ValidationID: %x\n", event.ValidationID)
-			fmt.Printf("  NodeID: %x\n", event.NodeID)
-			fmt.Printf("  RegisterValidationMessageID: %x\n", event.RegisterValidationMessageID)
-			fmt.Printf("  Weight: %d\n", event.Weight)
-			fmt.Printf("  RegistrationExpiry: %d\n", event.RegistrationExpiry)
-			continue
+// printContractLog decodes the log and prints it as text, or as a row of
+// csvWriter if it is set. It returns the log as a result object.
+func printContractLog(registry *l1.EventRegistry, vLog types.Log, csvWriter *csv.Writer) (map[string]any, error) {
+	decoded, err := registry.Decode(vLog)
+	if err != nil && !errors.Is(err, l1.ErrUnknownEvent) {
+		return nil, err
+	}
+	event, fields := "unknown", map[string]any{
+		"topics": vLog.Topics,
+		"data":   fmt.Sprintf("%x", vLog.Data),
+	}
+	if err == nil {
+		event, fields = decoded.Event, make(map[string]any, len(decoded.Fields))
+		for _, field := range decoded.Fields {
+			fields[field.Name] = field.JSONValue()
 		}
+	}
+	result := contractLogResult(vLog, event, fields)
 
-		// Add these new event parsers
-		if event, err := contract.PoAValidatorManagerFilterer.ParseValidatorWeightUpdate(vLog); err == nil {
-			fmt.Printf("ValidatorWeightUpdate:\n")
-			fmt.Printf("  ValidationID: %x\n", event.ValidationID)
-			fmt.Printf("  Nonce: %d\n", event.Nonce)
-			fmt.Printf("  Weight: %d\n", event.Weight)
-			fmt.Printf("  SetWeightMessageID: %x\n", event.SetWeightMessageID)
-			continue
+	if csvWriter != nil {
+		fieldsJSON, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
 		}
-
-		if event, err := contract.PoAValidatorManagerFilterer.ParseValidationPeriodRegistered(vLog); err == nil {
-			fmt.Printf("ValidationPeriodRegistered:\n")
-			fmt.Printf("  ValidationID: %x\n", event.ValidationID)
-			fmt.Printf("  Weight: %d\n", event.Weight)
-			fmt.Printf("  Timestamp: %d\n", event.Timestamp)
-			continue
+		if err := csvWriter.Write([]string{
+			fmt.Sprint(vLog.BlockNumber), vLog.TxHash.Hex(), fmt.Sprint(vLog.Index), vLog.Address.Hex(), event, string(fieldsJSON),
+		}); err != nil {
+			return nil, err
 		}
+		return result, nil
+	}
 
-		if event, err := contract.PoAValidatorManagerFilterer.ParseValidationPeriodEnded(vLog); err == nil {
-			fmt.Printf("ValidationPeriodEnded:\n")
-			fmt.Printf("  ValidationID: %x\n", event.ValidationID)
-			fmt.Printf("  Status: %d\n", event.Status)
-			continue
-		}
+	fmt.Println("------------------------")
+	fmt.Printf("Block %d, log TxHash: %s\n", vLog.BlockNumber, vLog.TxHash.Hex())
+	if err != nil {
+		log.Printf("❗ Failed to parse log: %s\n", err)
+		fmt.Printf("  Address: %s\n", vLog.Address.Hex())
+		fmt.Printf("  Topics: %v\n", vLog.Topics)
+		fmt.Printf("  Data: %x\n", vLog.Data)
+		return result, nil
+	}
+	fmt.Printf("%s (%s):\n", decoded.Event, vLog.Address.Hex())
+	for _, field := range decoded.Fields {
+		fmt.Printf("  %s: %s\n", field.Name, field)
+	}
+	return result, nil
+}
 
-		if event, err := contract.PoAValidatorManagerFilterer.ParseValidatorRemovalInitialized(vLog); err == nil {
-			fmt.Printf("ValidatorRemovalInitialized:\n")
-			fmt.Printf("  ValidationID: %x\n", event.ValidationID)
-			fmt.Printf("  SetWeightMessageID: %x\n", event.SetWeightMessageID)
-			fmt.Printf("  Weight: %d\n", event.Weight)
-			fmt.Printf("  EndTime: %d\n", event.EndTime)
-			continue
+// filterLogsInRange reads the logs of the query from the blocks from to to,
+// both included, to 0 for the latest. Ranges are read in logsBlockRange
+// chunks.
+func filterLogsInRange(ctx context.Context, client ethclient.Client, query interfaces.FilterQuery, from uint64, to uint64) ([]types.Log, error) {
+	if to == 0 {
+		rpcCtx, cancel := RPCContext(ctx)
+		latest, err := client.BlockNumber(rpcCtx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest block: %w", err)
 		}
+		to = latest
+	}
+	if from > to {
+		return nil, WithErrorCode(ErrCodeUsage, fmt.Errorf("--from-block %d is after --to-block %d", from, to))
+	}
 
-		if event, err := contract.PoAValidatorManagerFilterer.ParseInitialized(vLog); err == nil {
-			fmt.Printf("Initialized:\n")
-			fmt.Printf("  Version: %d\n", event.Version)
-			continue
+	var logs []types.Log
+	for start := from; start <= to; start += logsBlockRange {
+		end := min(start+logsBlockRange-1, to)
+		query.FromBlock = new(big.Int).SetUint64(start)
+		query.ToBlock = new(big.Int).SetUint64(end)
+		rpcCtx, cancel := RPCContext(ctx)
+		chunk, err := client.FilterLogs(rpcCtx, query)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter logs of blocks %d to %d: %w", start, end, err)
 		}
-
-		if event, err := contract.PoAValidatorManagerFilterer.ParseOwnershipTransferred(vLog); err == nil {
-			fmt.Printf("OwnershipTransferred:\n")
-			fmt.Printf("  Previous Owner: %s\n", event.PreviousOwner.Hex())
-			fmt.Printf("  New Owner: %s\n", event.NewOwner.Hex())
-			continue
+		logs = append(logs, chunk...)
+		if end == to {
+			break
 		}
+	}
+	return logs, nil
+}
 
-		log.Printf("❗ Failed to parse log: unknown event type\n")
-		fmt.Printf("  Address: %s\n", vLog.Address.Hex())
-		fmt.Printf("  Topics: %v\n", vLog.Topics)
-		fmt.Printf("  Data: %x\n", vLog.Data)
+// contractLogResult is one log of the result object, fields are the decoded
+// event arguments
+func contractLogResult(vLog types.Log, event string, fields map[string]any) map[string]any {
+	return map[string]any{
+		"txHash":      vLog.TxHash.Hex(),
+		"blockNumber": vLog.BlockNumber,
+		"logIndex":    vLog.Index,
+		"address":     vLog.Address.Hex(),
+		"event":       event,
+		"fields":      fields,
 	}
-	return nil
 }
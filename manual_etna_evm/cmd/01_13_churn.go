@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var churnWeight uint64
+
+func init() {
+	rootCmd.AddCommand(churnCmd)
+	churnCmd.Flags().Uint64Var(&churnWeight, "weight", 0, "Also report when a change of this weight fits")
+}
+
+var churnCmd = &cobra.Command{
+	Use:   "churn",
+	Short: "Print how much validator weight can change in the current churn period",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("⚖️ Printing churn period")
+
+		manager, err := GetValidatorManager()
+		if err != nil {
+			return err
+		}
+		churn, err := manager.Churn(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to read churn period: %w", err)
+		}
+
+		fmt.Printf("Period: %ds, maximum churn: %d%%\n", churn.PeriodSeconds, churn.MaximumPercentage)
+		fmt.Printf("Total weight: %d\n", churn.TotalWeight)
+		SetResult("periodSeconds", churn.PeriodSeconds)
+		SetResult("maximumChurnPercentage", churn.MaximumPercentage)
+		SetResult("totalWeight", churn.TotalWeight)
+		SetResult("maxChange", churn.MaxChange())
+		SetResult("room", churn.Room())
+		if churn.NewPeriod() {
+			fmt.Printf("The next change starts a new period, up to %d can change now\n", churn.Room())
+		} else {
+			resetsAt := churn.ResetsAt()
+			fmt.Printf("Changed %d of the initial weight %d, %d can change now\n", churn.ChurnAmount, churn.InitialWeight, churn.Room())
+			fmt.Printf("The period resets at %s, in %s\n", resetsAt.Format(time.RFC3339), time.Duration(uint64(resetsAt.Unix())-churn.Now)*time.Second)
+			SetResult("initialWeight", churn.InitialWeight)
+			SetResult("churnAmount", churn.ChurnAmount)
+			SetResult("resetsAt", resetsAt.Format(time.RFC3339))
+		}
+		fmt.Printf("A single change is at most %d\n", churn.MaxChange())
+
+		if churnWeight == 0 {
+			return nil
+		}
+		SetResult("weight", churnWeight)
+		wait, err := churn.Wait(churnWeight)
+		if err != nil {
+			return err
+		}
+		SetResult("wait", wait.String())
+		if wait == 0 {
+			fmt.Printf("✅ A change of %d fits now\n", churnWeight)
+		} else {
+			fmt.Printf("⏳ A change of %d fits in %s\n", churnWeight, wait)
+		}
+		return nil
+	},
+}
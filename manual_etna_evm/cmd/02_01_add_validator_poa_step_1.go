@@ -1,32 +1,21 @@
 package cmd
 
 import (
-	"encoding/hex"
+	"context"
 	"fmt"
 	"log"
-	"math/big"
-	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
 	"github.com/spf13/cobra"
 
-	"github.com/ava-labs/avalanche-cli/cmd/blockchaincmd"
 	"github.com/ava-labs/avalanche-cli/pkg/constants"
-	"github.com/ava-labs/avalanche-cli/pkg/contract"
-	"github.com/ava-labs/avalanche-cli/pkg/models"
-	"github.com/ava-labs/avalanche-cli/pkg/utils"
-	"github.com/ava-labs/avalanche-cli/sdk/interchain"
-	validatorManagerSDK "github.com/ava-labs/avalanche-cli/sdk/validatormanager"
-	"github.com/ava-labs/avalanchego/api/info"
 	"github.com/ava-labs/avalanchego/ids"
-	"github.com/ava-labs/avalanchego/utils/logging"
-	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
-	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
-	warpPayload "github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
-	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/avalanchego/utils/units"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -43,65 +32,82 @@ var AddPoaValidatorCmd = &cobra.Command{
 			return fmt.Errorf("failed to generate add validator folder: %w", err)
 		}
 
-		err = GenerateCredsIfNotExists(credsFolder)
+		manager, err := GetValidatorManager()
 		if err != nil {
-			return fmt.Errorf("failed to generate creds: %w", err)
+			return err
 		}
 
-		log.Printf("New creds folder: %s\n", credsFolder)
-
-		warpMessage, validationID, expiry, err := InitValidatorRegistration(credsFolder)
+		// A resumed node may be registered already, only a new one is
+		// checked before its keys are made
+		resumed, err := helpers.FileExists(credsFolder + "staker.crt")
 		if err != nil {
-			return fmt.Errorf("failed to initialize validator registration: %w", err)
+			return fmt.Errorf("failed to check if creds exist: %w", err)
 		}
-
-		log.Printf("Validator registration initialized: %x\n", warpMessage.Bytes())
-		log.Printf("Validation ID: %s\n", validationID)
-		log.Printf("Expiry: %d\n", expiry)
-
-		pChainRegistrationCompleted := false
-		for i := 0; i < 5; i++ {
-			log.Printf("Attempting to register L1 validator on P-chain (attempt %d/5)...", i+1)
-			err = RegisterL1ValidatorOnPChain(warpMessage, credsFolder)
-			if err != nil {
-				log.Printf("Attempt %d failed: %s", i+1, err)
-				if i < 4 {
-					log.Printf("Waiting 10 seconds before retrying...")
-					time.Sleep(10 * time.Second)
-					continue
-				}
-				return fmt.Errorf("all attempts to register L1 validator failed: %w", err)
+		if !resumed {
+			if err := preflightChurn(cmd.Context(), manager, constants.NonBootstrapValidatorWeight, 1); err != nil {
+				return err
 			}
-			pChainRegistrationCompleted = true
-			log.Printf("Successfully registered L1 validator on P-chain")
-			break
 		}
 
-		if !pChainRegistrationCompleted {
-			return fmt.Errorf("failed to register L1 validator on P-chain")
+		err = GenerateCredsIfNotExists(credsFolder)
+		if err != nil {
+			return fmt.Errorf("failed to generate creds: %w", err)
+		}
+
+		log.Printf("Creds folder: %s\n", credsFolder)
+		SetResult("node", fmt.Sprintf("node%d", nodeIndex))
+		SetResult("credsFolder", credsFolder)
+
+		validator, err := l1.ValidatorFromCreds(credsFolder)
+		if err != nil {
+			return fmt.Errorf("failed to get node info from creds: %w", err)
 		}
+		validator.Weight = constants.NonBootstrapValidatorWeight
+		validator.Balance = 1 * units.Avax
+		SetResult("nodeID", validator.NodeID.String())
 
-		err = AddValidatorCompleteRegistration(validationID)
+		// Saved before the contract sees it, a run that gets interrupted
+		// is resumed with the same expiry and thereby the same validation
+		expiry, err := loadRegistrationExpiry(credsFolder)
 		if err != nil {
-			return fmt.Errorf("failed to complete validator registration: %w", err)
+			return err
 		}
 
-		validatorCMD, err := GetValidatorCMD(credsFolder, nodeIndex)
+		// Blocks until the P-chain accepted the registration and the
+		// validator is in the set the completion signature is checked against
+		registration, err := manager.AddValidatorWithExpiry(cmd.Context(), validator, expiry)
+		if registration != nil {
+			log.Printf("Validation ID: %s\n", registration.ValidationID)
+			log.Printf("Expiry: %d\n", registration.Expiry)
+			if registration.Expiry != expiry {
+				if err := saveRegistrationExpiry(credsFolder, registration.Expiry); err != nil {
+					return err
+				}
+			}
+			for key, value := range registrationResult(registration) {
+				SetResult(key, value)
+			}
+		}
 		if err != nil {
-			return fmt.Errorf("failed to get validator cmd: %w", err)
+			return fmt.Errorf("failed to add validator: %w", err)
 		}
 
-		err = helpers.SaveText(fmt.Sprintf("%s/validator.sh", credsFolder), validatorCMD)
+		validatorScript, err := finishAddValidatorFolder(credsFolder, nodeIndex)
 		if err != nil {
-			return fmt.Errorf("failed to save validator cmd: %w", err)
+			return err
 		}
+		SetResult("validatorScript", validatorScript)
 
-		fmt.Println(validatorCMD)
+		fmt.Printf("✅ Validator registered, start it with: go run . nodes up node%d\n", nodeIndex)
+		fmt.Printf("A standalone docker run command was saved to %svalidator.sh\n", credsFolder)
 
 		return nil
 	},
 }
 
+// generateAddValidatorFolder resumes the first registration that did not
+// finish, validator.sh is only written at the end, or picks a new folder.
+// A new folder is only created with the creds, after the churn check.
 func generateAddValidatorFolder() (string, int, error) {
 	for i := 1; i < 100; i++ { //has to start with 1. node0 is already registered
 		folderName := fmt.Sprintf("data/add_validator_%d/", i)
@@ -110,225 +116,134 @@ func generateAddValidatorFolder() (string, int, error) {
 			return "", 0, fmt.Errorf("failed to check if folder exists: %w", err)
 		}
 		if exists {
+			finished, err := helpers.FileExists(folderName + "validator.sh")
+			if err != nil {
+				return "", 0, fmt.Errorf("failed to check if validator.sh exists: %w", err)
+			}
+			if !finished {
+				log.Printf("Resuming the unfinished registration in %s\n", folderName)
+				return folderName, i, nil
+			}
 			continue
 		}
-		err = os.MkdirAll(folderName, 0755)
-		if err != nil {
-			return "", 0, fmt.Errorf("failed to create folder: %w", err)
-		}
 		return folderName, i, nil
 	}
 	return "", 0, fmt.Errorf("failed to generate add validator folder")
 }
 
-func InitValidatorRegistration(credsFolder string) (*warp.Message, ids.ID, uint64, error) {
-	nodeID, proofOfPossession, err := NodeInfoFromCreds(credsFolder)
+// preflightChurn fails before any work is done if count validators of
+// weight can not be added under the churn policy, and logs when they are
+// queued for a later period
+func preflightChurn(ctx context.Context, manager *l1.ValidatorManager, weight uint64, count int) error {
+	churn, wait, err := manager.PreflightChurn(ctx, weight)
 	if err != nil {
-		return nil, ids.Empty, 0, fmt.Errorf("failed to get node info from creds: %w", err)
+		return fmt.Errorf("refusing to add validators: %w", err)
 	}
-
-	chainID, err := helpers.LoadId(helpers.ChainIdPath)
-	if err != nil {
-		return nil, ids.Empty, 0, fmt.Errorf("failed to load chain ID: %w", err)
+	fits := churn.Room() / weight
+	if wait > 0 {
+		fits = 0
 	}
-	evmChainURL := fmt.Sprintf("http://127.0.0.1:9650/ext/bc/%s/rpc", chainID)
-
-	expiry := uint64(time.Now().Add(constants.DefaultValidationIDExpiryDuration).Unix())
-
-	managerKey, err := helpers.LoadSecp256k1PrivateKey(helpers.ValidatorManagerOwnerKeyPath)
-	if err != nil {
-		return nil, ids.Empty, 0, fmt.Errorf("failed to load manager key: %w", err)
+	if uint64(count) <= fits {
+		return nil
 	}
-
-	pChainAddr := managerKey.Address()
-
-	remainingBalanceOwners := warpMessage.PChainOwner{
-		Threshold: 1,
-		Addresses: []ids.ShortID{pChainAddr},
+	if manager.ChurnPolicy == l1.ChurnRefuse {
+		return churnRefusal(churn, weight, count, fits)
 	}
-	disableOwners := remainingBalanceOwners
-
-	managerAddress := common.HexToAddress(config.ProxyContractAddress)
-
-	validatorWeight := uint64(20)
-
-	_, receipt, err := PoAValidatorManagerInitializeValidatorRegistration(
-		evmChainURL,
-		managerAddress,
-		hex.EncodeToString(managerKey.Bytes()),
-		nodeID,
-		proofOfPossession.PublicKey[:],
-		expiry,
-		remainingBalanceOwners,
-		disableOwners,
-		validatorWeight,
-	)
-	if err != nil {
-		if strings.Contains(err.Error(), "node already registered") {
-			log.Printf("reverted with an expected error: %s", err)
-			log.Printf("✅ Node %s was already registered as validator previously\n", nodeID)
-		} else {
-			log.Fatalf("failed to initialize validator registration: %s", err)
-		}
+	if wait > 0 {
+		log.Printf("⏳ The churn period has no room for a weight of %d, queued for the next one in %s\n", weight, wait)
 	} else {
-		log.Printf("✅ Validator registration initialized: %s\n", receipt.TxHash)
+		log.Printf("⏳ %d of %d validators fit the churn period, the rest are queued for the next ones\n", fits, count)
 	}
+	return nil
+}
 
-	log.Println("Validator registration initialized in the contract, collecting signatures...\n")
-
-	network := models.NewFujiNetwork()
-	aggregatorLogLevel := logging.Level(logging.Info)
-	aggregatorQuorumPercentage := uint64(0)
-	aggregatorAllowPrivateIPs := true
-
-	aggregatorExtraPeerEndpoints, err := blockchaincmd.ConvertURIToPeers([]string{"http://127.0.0.1:9650"})
-	if err != nil {
-		return nil, ids.Empty, 0, fmt.Errorf("failed to get extra peers: %w", err)
+// churnRefusal is why a batch of count validators of weight, of which fits
+// fit the period, is refused. A batch above the maximum of a whole period
+// does not fit after the reset either.
+func churnRefusal(churn l1.Churn, weight uint64, count int, fits uint64) error {
+	total := uint64(count) * weight
+	if churn.NewPeriod() || total > churn.MaxChange() {
+		return fmt.Errorf("refusing to add validators, a weight of %d exceeds the per-period maximum of %d, split the batch: %w", total, churn.MaxChange(), ErrChurnLimitExceeded)
 	}
+	return fmt.Errorf("refusing to add validators, %d of %d fit the churn period, it resets at %s: %w", fits, count, churn.ResetsAt().UTC().Format(time.RFC3339), ErrChurnLimitExceeded)
+}
 
-	blsPublicKey := [48]byte(proofOfPossession.PublicKey[:])
-	weight := constants.NonBootstrapValidatorWeight
-
-	subnetID, err := helpers.LoadId(helpers.SubnetIdPath)
-	if err != nil {
-		return nil, ids.Empty, 0, fmt.Errorf("failed to load subnet ID: %w", err)
+// registrationResult is the JSON result of what adding a validator produced
+func registrationResult(registration *l1.Registration) map[string]any {
+	result := map[string]any{
+		"validationID": registration.ValidationID.String(),
+		"expiry":       registration.Expiry,
 	}
-
-	warpMessage, validationID, err := ValidatorManagerGetSubnetValidatorRegistrationMessage(
-		network,
-		aggregatorLogLevel,
-		aggregatorQuorumPercentage,
-		aggregatorAllowPrivateIPs,
-		aggregatorExtraPeerEndpoints,
-		subnetID,
-		chainID,
-		managerAddress,
-		nodeID,
-		blsPublicKey,
-		expiry,
-		remainingBalanceOwners,
-		disableOwners,
-		uint64(weight),
-	)
-	if err != nil {
-		return nil, ids.Empty, 0, fmt.Errorf("failed to get subnet validator registration message: %w", err)
+	if registration.InitializeTxHash != (common.Hash{}) {
+		result["initializeTxHash"] = registration.InitializeTxHash.Hex()
 	}
-
-	return warpMessage, validationID, uint64(expiry), nil
+	if registration.RegisterTxID != ids.Empty {
+		result["registerTxID"] = registration.RegisterTxID.String()
+	}
+	if registration.CompleteTxHash != (common.Hash{}) {
+		result["completeTxHash"] = registration.CompleteTxHash.Hex()
+	}
+	return result
 }
 
-func ValidatorManagerGetSubnetValidatorRegistrationMessage(
-	network models.Network,
-	aggregatorLogLevel logging.Level,
-	aggregatorQuorumPercentage uint64,
-	aggregatorAllowPrivateIPs bool,
-	aggregatorExtraPeerEndpoints []info.Peer,
-	subnetID ids.ID,
-	blockchainID ids.ID,
-	managerAddress common.Address,
-	nodeID ids.NodeID,
-	blsPublicKey [48]byte,
-	expiry uint64,
-	balanceOwners warpMessage.PChainOwner,
-	disableOwners warpMessage.PChainOwner,
-	weight uint64,
-) (*warp.Message, ids.ID, error) {
-	addressedCallPayload, err := warpMessage.NewRegisterL1Validator(
-		subnetID,
-		nodeID,
-		blsPublicKey,
-		expiry,
-		balanceOwners,
-		disableOwners,
-		weight,
-	)
+// finishAddValidatorFolder writes the chain config and validator.sh of a
+// registered node, which marks its folder as done. It returns the script
+// path.
+func finishAddValidatorFolder(credsFolder string, nodeIndex int) (string, error) {
+	chainID, err := helpers.LoadId(helpers.ChainIdPath)
 	if err != nil {
-		return nil, ids.Empty, err
+		return "", fmt.Errorf("failed to load chain ID: %w", err)
 	}
-	validationID := addressedCallPayload.ValidationID()
-	registerSubnetValidatorAddressedCall, err := warpPayload.NewAddressedCall(
-		managerAddress.Bytes(),
-		addressedCallPayload.Bytes(),
-	)
+	err = WriteChainConfig(filepath.Join(credsFolder, "chains"), chainID)
 	if err != nil {
-		return nil, ids.Empty, err
+		return "", fmt.Errorf("failed to write chain config: %w", err)
 	}
-	registerSubnetValidatorUnsignedMessage, err := warp.NewUnsignedMessage(
-		network.ID,
-		blockchainID,
-		registerSubnetValidatorAddressedCall.Bytes(),
-	)
+
+	validatorCMD, err := GetValidatorCMD(credsFolder, nodeIndex)
 	if err != nil {
-		return nil, ids.Empty, err
+		return "", fmt.Errorf("failed to get validator cmd: %w", err)
 	}
-	signatureAggregator, err := interchain.NewSignatureAggregator(
-		network,
-		aggregatorLogLevel,
-		subnetID,
-		aggregatorQuorumPercentage,
-		aggregatorAllowPrivateIPs,
-		aggregatorExtraPeerEndpoints,
-	)
+
+	validatorScript := credsFolder + "validator.sh"
+	err = helpers.SaveText(validatorScript, validatorCMD)
 	if err != nil {
-		return nil, ids.Empty, err
+		return "", fmt.Errorf("failed to save validator cmd: %w", err)
 	}
-	signedMessage, err := signatureAggregator.Sign(registerSubnetValidatorUnsignedMessage, nil)
-	return signedMessage, validationID, err
+	return validatorScript, nil
 }
 
-// step 1 of flow for adding a new validator
-func PoAValidatorManagerInitializeValidatorRegistration(
-	rpcURL string,
-	managerAddress common.Address,
-	managerOwnerPrivateKey string,
-	nodeID ids.NodeID,
-	blsPublicKey []byte,
-	expiry uint64,
-	balanceOwners warpMessage.PChainOwner,
-	disableOwners warpMessage.PChainOwner,
-	weight uint64,
-) (*types.Transaction, *types.Receipt, error) {
-	type PChainOwner struct {
-		Threshold uint32
-		Addresses []common.Address
-	}
-	type ValidatorRegistrationInput struct {
-		NodeID                []byte
-		BlsPublicKey          []byte
-		RegistrationExpiry    uint64
-		RemainingBalanceOwner PChainOwner
-		DisableOwner          PChainOwner
-	}
-	balanceOwnersAux := PChainOwner{
-		Threshold: balanceOwners.Threshold,
-		Addresses: utils.Map(balanceOwners.Addresses, func(addr ids.ShortID) common.Address {
-			return common.BytesToAddress(addr[:])
-		}),
-	}
-	disableOwnersAux := PChainOwner{
-		Threshold: disableOwners.Threshold,
-		Addresses: utils.Map(disableOwners.Addresses, func(addr ids.ShortID) common.Address {
-			return common.BytesToAddress(addr[:])
-		}),
+// loadRegistrationExpiry is the expiry of an earlier attempt to register the
+// node in credsFolder, or a new one
+func loadRegistrationExpiry(credsFolder string) (uint64, error) {
+	expiryPath := filepath.Join(credsFolder, "expiry.txt")
+	exists, err := helpers.FileExists(expiryPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check if %s exists: %w", expiryPath, err)
 	}
-	validatorRegistrationInput := ValidatorRegistrationInput{
-		NodeID:                nodeID[:],
-		BlsPublicKey:          blsPublicKey,
-		RegistrationExpiry:    expiry,
-		RemainingBalanceOwner: balanceOwnersAux,
-		DisableOwner:          disableOwnersAux,
+	if exists {
+		expiryText, err := helpers.LoadText(expiryPath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load registration expiry: %w", err)
+		}
+		expiry, err := strconv.ParseUint(strings.TrimSpace(expiryText), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse %s: %w", expiryPath, err)
+		}
+		return expiry, nil
 	}
 
-	return contract.TxToMethod(
-		rpcURL,
-		managerOwnerPrivateKey,
-		managerAddress,
-		big.NewInt(0),
-		"initialize validator registration",
-		validatorManagerSDK.ErrorSignatureToError,
-		"initializeValidatorRegistration((bytes,bytes,uint64,(uint32,[address]),(uint32,[address])),uint64)",
-		validatorRegistrationInput,
-		weight,
-	)
+	expiry := l1.NewRegistrationExpiry()
+	if err := saveRegistrationExpiry(credsFolder, expiry); err != nil {
+		return 0, err
+	}
+	return expiry, nil
+}
 
+// saveRegistrationExpiry keeps the expiry the node in credsFolder is
+// registered with, e.g. a new one after the last one passed
+func saveRegistrationExpiry(credsFolder string, expiry uint64) error {
+	if err := helpers.SaveText(filepath.Join(credsFolder, "expiry.txt"), strconv.FormatUint(expiry, 10)); err != nil {
+		return fmt.Errorf("failed to save registration expiry: %w", err)
+	}
+	return nil
 }
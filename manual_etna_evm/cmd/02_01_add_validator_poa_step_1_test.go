@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+)
+
+func TestChurnRefusal(t *testing.T) {
+	tests := []struct {
+		name  string
+		churn l1.Churn
+		count int
+		fits  uint64
+		want  string
+	}{
+		{
+			name:  "new period, batch above the maximum",
+			churn: l1.Churn{PeriodSeconds: 3600, MaximumPercentage: 20, TotalWeight: 1000, Now: 5000},
+			count: 3, fits: 2,
+			want: "a weight of 300 exceeds the per-period maximum of 200, split the batch",
+		},
+		{
+			name:  "inside the period, batch fits after the reset",
+			churn: l1.Churn{PeriodSeconds: 3600, MaximumPercentage: 20, StartedAt: 4000, InitialWeight: 1000, TotalWeight: 1100, ChurnAmount: 150, Now: 5000},
+			count: 2, fits: 0,
+			want: "0 of 2 fit the churn period, it resets at 1970-01-01T02:06:40Z",
+		},
+		{
+			name:  "inside the period, batch above the maximum",
+			churn: l1.Churn{PeriodSeconds: 3600, MaximumPercentage: 20, StartedAt: 4000, InitialWeight: 1000, TotalWeight: 1100, ChurnAmount: 150, Now: 5000},
+			count: 3, fits: 0,
+			want: "a weight of 300 exceeds the per-period maximum of 220, split the batch",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := churnRefusal(test.churn, 100, test.count, test.fits)
+			if !errors.Is(err, ErrChurnLimitExceeded) {
+				t.Errorf("error = %v, want ErrChurnLimitExceeded", err)
+			}
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("error = %v, want %q", err, test.want)
+			}
+			if err != nil && strings.Contains(err.Error(), "0001-01-01") {
+				t.Errorf("error = %v, reports the zero time", err)
+			}
+		})
+	}
+}
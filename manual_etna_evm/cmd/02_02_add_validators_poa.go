@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ava-labs/avalanche-cli/pkg/constants"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchKeysFolder string
+	batchCount      int
+	batchParallel   int
+)
+
+func init() {
+	rootCmd.AddCommand(AddPoaValidatorsCmd)
+	AddPoaValidatorsCmd.Flags().StringVar(&batchKeysFolder, "from", keysFolder, "Folder with the creds folders made by generate-new-validator-keys")
+	AddPoaValidatorsCmd.Flags().IntVar(&batchCount, "count", 0, "Number of validators to add, 0 for all folders")
+	AddPoaValidatorsCmd.Flags().IntVar(&batchParallel, "parallel", 5, "Validators in flight at the same time")
+}
+
+// batchNode is a creds folder of the batch and the node folder it is added
+// from
+type batchNode struct {
+	sourceFolder string
+	credsFolder  string
+	nodeIndex    int
+	validator    l1.Validator
+}
+
+var AddPoaValidatorsCmd = &cobra.Command{
+	Use:   "add-poa-validators",
+	Short: "Add many validators to the validator set concurrently",
+	Long: `Add the nodes of the creds folders made by generate-new-validator-keys, e.g.
+add-poa-validators --from keys/ --count 10. Each node gets a data/add_validator_N folder like
+with add-poa-validator. Running the command again resumes the nodes that did not finish.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("👥 Adding validators")
+
+		sourceFolders, err := batchSourceFolders(batchKeysFolder, batchCount)
+		if err != nil {
+			return err
+		}
+		if len(sourceFolders) == 0 {
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("no creds folders in %s, create them with generate-new-validator-keys", batchKeysFolder))
+		}
+
+		// Nothing is written before the churn check, a refused batch leaves
+		// no folders behind for nodes up to start
+		nodes := make(map[ids.NodeID]batchNode, len(sourceFolders))
+		var pendingNodes []batchNode
+		for _, sourceFolder := range sourceFolders {
+			validator, err := l1.ValidatorFromCreds(sourceFolder)
+			if err != nil {
+				return fmt.Errorf("failed to get node info from %s: %w", sourceFolder, err)
+			}
+			validator.Weight = constants.NonBootstrapValidatorWeight
+			validator.Balance = 1 * units.Avax
+
+			node := batchNode{sourceFolder: sourceFolder, validator: validator}
+			credsFolder, nodeIndex, found, err := findAddValidatorFolder(validator.NodeID)
+			if err != nil {
+				return err
+			}
+			if found {
+				node.credsFolder, node.nodeIndex = credsFolder, nodeIndex
+				finished, err := helpers.FileExists(credsFolder + "validator.sh")
+				if err != nil {
+					return fmt.Errorf("failed to check if validator.sh exists: %w", err)
+				}
+				if finished {
+					nodes[validator.NodeID] = node
+					log.Printf("✅ %s was added before as node%d\n", validator.NodeID, nodeIndex)
+					AppendResult("validators", batchNodeResult(node, "done"))
+					continue
+				}
+			}
+			pendingNodes = append(pendingNodes, node)
+		}
+		if len(pendingNodes) == 0 {
+			return nil
+		}
+
+		manager, err := GetValidatorManager()
+		if err != nil {
+			return err
+		}
+
+		if err := preflightChurn(cmd.Context(), manager, constants.NonBootstrapValidatorWeight, len(pendingNodes)); err != nil {
+			return err
+		}
+
+		pending := make([]l1.BatchValidator, 0, len(pendingNodes))
+		for _, node := range pendingNodes {
+			node.credsFolder, node.nodeIndex, err = addValidatorFolderOf(node.validator.NodeID, node.sourceFolder)
+			if err != nil {
+				return err
+			}
+			nodes[node.validator.NodeID] = node
+
+			expiry, err := loadRegistrationExpiry(node.credsFolder)
+			if err != nil {
+				return err
+			}
+			pending = append(pending, l1.BatchValidator{Validator: node.validator, Expiry: expiry})
+		}
+
+		log.Printf("Adding %d validators, %d at a time\n", len(pending), batchParallel)
+		var failed int
+		manager.AddValidators(cmd.Context(), pending, batchParallel, func(result l1.BatchResult) {
+			node := nodes[result.Validator.NodeID]
+			err := result.Err
+			// An expired registration starts over with a new expiry
+			if result.Registration != nil {
+				if saveErr := saveRegistrationExpiry(node.credsFolder, result.Registration.Expiry); saveErr != nil && err == nil {
+					err = saveErr
+				}
+			}
+			var validatorScript string
+			if err == nil {
+				validatorScript, err = finishAddValidatorFolder(node.credsFolder, node.nodeIndex)
+			}
+
+			status := "added"
+			if err != nil {
+				status = "failed"
+				failed++
+				log.Printf("❌ %s (node%d): %s\n", node.validator.NodeID, node.nodeIndex, err)
+			} else {
+				log.Printf("✅ %s added, start it with: go run . nodes up node%d\n", node.validator.NodeID, node.nodeIndex)
+			}
+
+			nodeResult := batchNodeResult(node, status)
+			if result.Registration != nil {
+				for key, value := range registrationResult(result.Registration) {
+					nodeResult[key] = value
+				}
+			}
+			if validatorScript != "" {
+				nodeResult["validatorScript"] = validatorScript
+			}
+			if err != nil {
+				nodeResult["error"] = map[string]string{"message": err.Error(), "code": ErrorCode(err)}
+			}
+			AppendResult("validators", nodeResult)
+		})
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d validators failed, run the command again to resume them", failed, len(pending))
+		}
+		return nil
+	},
+}
+
+func batchNodeResult(node batchNode, status string) map[string]any {
+	return map[string]any{
+		"nodeID":       node.validator.NodeID.String(),
+		"node":         fmt.Sprintf("node%d", node.nodeIndex),
+		"sourceFolder": node.sourceFolder,
+		"credsFolder":  node.credsFolder,
+		"status":       status,
+	}
+}
+
+// batchSourceFolders is the creds folders in folder ordered by their index,
+// at most count of them unless count is zero
+func batchSourceFolders(folder string, count int) ([]string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", folder, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		exists, err := helpers.FileExists(filepath.Join(folder, entry.Name(), "staker.crt"))
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			names = append(names, entry.Name())
+		}
+	}
+
+	// validator_10 comes after validator_9
+	index := func(name string) int {
+		i, err := strconv.Atoi(name[strings.LastIndex(name, "_")+1:])
+		if err != nil {
+			return -1
+		}
+		return i
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if index(a) != index(b) {
+			return index(a) - index(b)
+		}
+		return strings.Compare(a, b)
+	})
+
+	if count > 0 && count < len(names) {
+		names = names[:count]
+	}
+	folders := make([]string, 0, len(names))
+	for _, name := range names {
+		folders = append(folders, filepath.Join(folder, name)+"/")
+	}
+	return folders, nil
+}
+
+// findAddValidatorFolder is the data/add_validator_N folder that has the
+// creds of the node, found is false if there is none yet
+func findAddValidatorFolder(nodeID ids.NodeID) (string, int, bool, error) {
+	for i := 1; i < 100; i++ { // node0 is the bootstrap validator
+		folderName := fmt.Sprintf("data/add_validator_%d/", i)
+		exists, err := helpers.FileExists(folderName)
+		if err != nil {
+			return "", 0, false, fmt.Errorf("failed to check if folder exists: %w", err)
+		}
+		if !exists {
+			continue
+		}
+		folderNodeID, _, err := l1.NodeInfoFromCreds(folderName)
+		if err == nil && folderNodeID == nodeID {
+			return folderName, i, true, nil
+		}
+	}
+	return "", 0, false, nil
+}
+
+// addValidatorFolderOf is the data/add_validator_N folder that has the creds
+// of the node, copied from sourceFolder into a new folder on first use
+func addValidatorFolderOf(nodeID ids.NodeID, sourceFolder string) (string, int, error) {
+	folderName, index, found, err := findAddValidatorFolder(nodeID)
+	if err != nil || found {
+		return folderName, index, err
+	}
+
+	freeIndex := 0
+	for i := 1; i < 100 && freeIndex == 0; i++ {
+		exists, err := helpers.FileExists(fmt.Sprintf("data/add_validator_%d/", i))
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to check if folder exists: %w", err)
+		}
+		if !exists {
+			freeIndex = i
+		}
+	}
+	if freeIndex == 0 {
+		return "", 0, fmt.Errorf("no free add validator folder left for %s", nodeID)
+	}
+
+	folderName = fmt.Sprintf("data/add_validator_%d/", freeIndex)
+	for _, file := range []string{"staker.key", "staker.crt", "signer.key"} {
+		content, err := helpers.LoadBytes(sourceFolder + file)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to load creds of %s: %w", nodeID, err)
+		}
+		if err := helpers.SaveBytes(folderName+file, content); err != nil {
+			return "", 0, fmt.Errorf("failed to copy creds of %s: %w", nodeID, err)
+		}
+	}
+	log.Printf("Copied the creds of %s from %s to %s\n", nodeID, sourceFolder, folderName)
+	return folderName, freeIndex, nil
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanche-cli/pkg/constants"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+)
+
+// chdirTemp runs the test in an empty workspace, the commands use paths
+// relative to it
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestLoadRegistrationExpiry(t *testing.T) {
+	tests := []struct {
+		name    string
+		saved   string
+		want    uint64
+		wantErr bool
+	}{
+		{name: "saved", saved: "1700000000", want: 1700000000},
+		{name: "trailing newline", saved: "1700000000\n", want: 1700000000},
+		{name: "corrupt", saved: "tomorrow", wantErr: true},
+		{name: "negative", saved: "-1", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chdirTemp(t)
+			if err := helpers.SaveText("data/add_validator_1/expiry.txt", test.saved); err != nil {
+				t.Fatal(err)
+			}
+			expiry, err := loadRegistrationExpiry("data/add_validator_1/")
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("loadRegistrationExpiry() = %d, want an error", expiry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadRegistrationExpiry() failed: %s", err)
+			}
+			if expiry != test.want {
+				t.Errorf("loadRegistrationExpiry() = %d, want %d", expiry, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadRegistrationExpiryNew(t *testing.T) {
+	chdirTemp(t)
+	before := uint64(time.Now().Add(constants.DefaultValidationIDExpiryDuration).Unix())
+	expiry, err := loadRegistrationExpiry("data/add_validator_1/")
+	if err != nil {
+		t.Fatalf("loadRegistrationExpiry() failed: %s", err)
+	}
+	if expiry < before || expiry > before+60 {
+		t.Errorf("new expiry %d is not the default window from now, %d", expiry, before)
+	}
+	again, err := loadRegistrationExpiry("data/add_validator_1/")
+	if err != nil {
+		t.Fatalf("loadRegistrationExpiry() failed: %s", err)
+	}
+	if again != expiry {
+		t.Errorf("second load = %d, want the saved %d", again, expiry)
+	}
+}
+
+// TestBatchResume interrupts a batch after the folders were assigned and
+// the expiries saved, the next run has to find the same folder and expiry
+// for every node
+func TestBatchResume(t *testing.T) {
+	chdirTemp(t)
+	sources := []string{"keys/a/", "keys/b/", "keys/c/"}
+	for _, source := range sources {
+		if err := GenerateCredsIfNotExists(source); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A folder of another run that is not one of the batch
+	if err := GenerateCredsIfNotExists("data/add_validator_2/"); err != nil {
+		t.Fatal(err)
+	}
+
+	type assigned struct {
+		folder string
+		index  int
+		expiry uint64
+	}
+	firstRun := map[string]assigned{}
+	for _, source := range sources {
+		nodeID, _, err := l1.NodeInfoFromCreds(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		folder, index, err := addValidatorFolderOf(nodeID, source)
+		if err != nil {
+			t.Fatalf("addValidatorFolderOf(%s) failed: %s", source, err)
+		}
+		folderNodeID, _, err := l1.NodeInfoFromCreds(folder)
+		if err != nil || folderNodeID != nodeID {
+			t.Fatalf("%s holds %s, %v, want the creds of %s", folder, folderNodeID, err, nodeID)
+		}
+		expiry, err := loadRegistrationExpiry(folder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		firstRun[source] = assigned{folder, index, expiry}
+	}
+	wantIndexes := map[string]int{"keys/a/": 1, "keys/b/": 3, "keys/c/": 4}
+	for source, index := range wantIndexes {
+		if got := firstRun[source]; got.index != index || got.folder != fmt.Sprintf("data/add_validator_%d/", index) {
+			t.Errorf("%s got folder %s index %d, want index %d", source, got.folder, got.index, index)
+		}
+	}
+
+	for _, source := range []string{"keys/c/", "keys/a/", "keys/b/"} {
+		nodeID, _, err := l1.NodeInfoFromCreds(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		folder, index, err := addValidatorFolderOf(nodeID, source)
+		if err != nil {
+			t.Fatalf("addValidatorFolderOf(%s) failed on resume: %s", source, err)
+		}
+		expiry, err := loadRegistrationExpiry(folder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := (assigned{folder, index, expiry}), firstRun[source]; got != want {
+			t.Errorf("resume of %s = %+v, want %+v", source, got, want)
+		}
+	}
+}
+
+func TestFindAddValidatorFolder(t *testing.T) {
+	chdirTemp(t)
+	if err := GenerateCredsIfNotExists("keys/a/"); err != nil {
+		t.Fatal(err)
+	}
+	nodeID, _, err := l1.NodeInfoFromCreds("keys/a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Looking up a new node writes nothing, a batch refused by the churn
+	// check leaves no folder behind
+	if _, _, found, err := findAddValidatorFolder(nodeID); err != nil || found {
+		t.Fatalf("findAddValidatorFolder() of a new node = %v, %v, want not found", found, err)
+	}
+	if exists, _ := helpers.FileExists("data/add_validator_1/"); exists {
+		t.Error("findAddValidatorFolder() created a folder")
+	}
+
+	folder, index, err := addValidatorFolderOf(nodeID, "keys/a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, foundIndex, ok, err := findAddValidatorFolder(nodeID)
+	if err != nil || !ok || found != folder || foundIndex != index {
+		t.Errorf("findAddValidatorFolder() = %s, %d, %v, %v, want %s, %d", found, foundIndex, ok, err, folder, index)
+	}
+}
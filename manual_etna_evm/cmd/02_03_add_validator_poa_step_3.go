@@ -1,256 +0,0 @@
-package cmd
-
-import (
-	_ "embed"
-	"encoding/hex"
-	"fmt"
-	"log"
-	"math/big"
-
-	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
-	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
-
-	"github.com/ava-labs/avalanche-cli/cmd/blockchaincmd"
-	"github.com/ava-labs/avalanche-cli/pkg/contract"
-	"github.com/ava-labs/avalanche-cli/pkg/evm"
-	"github.com/ava-labs/avalanche-cli/pkg/models"
-	"github.com/ava-labs/avalanche-cli/pkg/utils"
-	"github.com/ava-labs/avalanche-cli/sdk/interchain"
-	validatorManagerSDK "github.com/ava-labs/avalanche-cli/sdk/validatormanager"
-	"github.com/ava-labs/avalanchego/api/info"
-	"github.com/ava-labs/avalanchego/ids"
-	"github.com/ava-labs/avalanchego/proto/pb/platformvm"
-	avagoconstants "github.com/ava-labs/avalanchego/utils/constants"
-	"github.com/ava-labs/avalanchego/utils/logging"
-	warp "github.com/ava-labs/avalanchego/vms/platformvm/warp"
-	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
-	warpPayload "github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
-	"github.com/ava-labs/subnet-evm/core/types"
-	"github.com/ava-labs/subnet-evm/interfaces"
-	subnetEvmWarp "github.com/ava-labs/subnet-evm/precompile/contracts/warp"
-	goethereumcommon "github.com/ethereum/go-ethereum/common"
-	"google.golang.org/protobuf/proto"
-)
-
-func AddValidatorCompleteRegistration(validationID ids.ID) error {
-	registered := true
-	aggregatorExtraPeerEndpoints, err := blockchaincmd.ConvertURIToPeers([]string{"http://127.0.0.1:9650"})
-	if err != nil {
-		log.Fatalf("failed to get extra peers: %s", err)
-	}
-	aggregatorQuorumPercentage := uint64(0)
-	subnetID, err := helpers.LoadId(helpers.SubnetIdPath)
-	if err != nil {
-		return fmt.Errorf("failed to load subnet id: %w", err)
-	}
-	rpcURL := "http://127.0.0.1:9650"
-
-	network := models.NewFujiNetwork()
-	aggregatorLogLevel := logging.Level(logging.Info)
-	aggregatorAllowPrivateIPs := true
-
-	signedMessage, err := ValidatorManagerGetPChainSubnetValidatorRegistrationWarpMessage(
-		network,
-		rpcURL,
-		aggregatorLogLevel,
-		aggregatorQuorumPercentage,
-		aggregatorAllowPrivateIPs,
-		aggregatorExtraPeerEndpoints,
-		subnetID,
-		validationID,
-		registered,
-	)
-	if err != nil {
-		log.Fatalf("failed to get P-chain subnet validator registration warp message: %s", err)
-	}
-
-	log.Printf("signedMessage: %x\n", signedMessage.Bytes())
-
-	managerAddress := goethereumcommon.HexToAddress(config.ProxyContractAddress)
-
-	privateKey, err := helpers.LoadSecp256k1PrivateKey(helpers.ValidatorManagerOwnerKeyPath)
-	if err != nil {
-		return fmt.Errorf("failed to load manager key: %w", err)
-	}
-
-	chainID, err := helpers.LoadId(helpers.ChainIdPath)
-	if err != nil {
-		return fmt.Errorf("failed to load chain id: %w", err)
-	}
-
-	nodeURL := fmt.Sprintf("http://%s:%s/ext/bc/%s/rpc", "127.0.0.1", "9650", chainID)
-
-	tx, _, err := ValidatorManagerCompleteValidatorRegistration(
-		nodeURL,
-		managerAddress,
-		hex.EncodeToString(privateKey.Bytes()),
-		signedMessage,
-	)
-	if err != nil {
-		err = evm.TransactionError(tx, err, "failure completing validator registration")
-		log.Fatalf("failure completing validator registration: %s", err)
-	}
-
-	return nil
-}
-
-func ValidatorManagerCompleteValidatorRegistration(
-	rpcURL string,
-	managerAddress goethereumcommon.Address,
-	privateKey string, // not need to be owner atm
-	subnetValidatorRegistrationSignedMessage *warp.Message,
-) (*types.Transaction, *types.Receipt, error) {
-	return contract.TxToMethodWithWarpMessage(
-		rpcURL,
-		privateKey,
-		managerAddress,
-		subnetValidatorRegistrationSignedMessage,
-		big.NewInt(0),
-		"complete validator registration",
-		validatorManagerSDK.ErrorSignatureToError,
-		"completeValidatorRegistration(uint32)",
-		uint32(0),
-	)
-}
-
-func ValidatorManagerGetPChainSubnetValidatorRegistrationWarpMessage(network models.Network,
-	rpcURL string,
-	aggregatorLogLevel logging.Level,
-	aggregatorQuorumPercentage uint64,
-	aggregatorAllowPrivateIPs bool,
-	aggregatorExtraPeerEndpoints []info.Peer,
-	subnetID ids.ID,
-	validationID ids.ID,
-	registered bool,
-) (*warp.Message, error) {
-	addressedCallPayload, err := warpMessage.NewL1ValidatorRegistration(validationID, registered)
-	if err != nil {
-		return nil, err
-	}
-	subnetValidatorRegistrationAddressedCall, err := warpPayload.NewAddressedCall(
-		nil,
-		addressedCallPayload.Bytes(),
-	)
-	if err != nil {
-		return nil, err
-	}
-	subnetConversionUnsignedMessage, err := warp.NewUnsignedMessage(
-		network.ID,
-		avagoconstants.PlatformChainID,
-		subnetValidatorRegistrationAddressedCall.Bytes(),
-	)
-	if err != nil {
-		return nil, err
-	}
-	signatureAggregator, err := interchain.NewSignatureAggregator(
-		network,
-		aggregatorLogLevel,
-		subnetID,
-		aggregatorQuorumPercentage,
-		aggregatorAllowPrivateIPs,
-		aggregatorExtraPeerEndpoints,
-	)
-	if err != nil {
-		return nil, err
-	}
-	var justificationBytes []byte
-	if !registered {
-		justificationBytes, err = GetRegistrationJustification(rpcURL, validationID, subnetID)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return signatureAggregator.Sign(subnetConversionUnsignedMessage, justificationBytes)
-}
-
-func GetRegistrationJustification(
-	rpcURL string,
-	validationID ids.ID,
-	subnetID ids.ID,
-) ([]byte, error) {
-	const numBootstrapValidatorsToSearch = 100
-	for validationIndex := uint32(0); validationIndex < numBootstrapValidatorsToSearch; validationIndex++ {
-		bootstrapValidationID := subnetID.Append(validationIndex)
-		if bootstrapValidationID == validationID {
-			justification := platformvm.L1ValidatorRegistrationJustification{
-				Preimage: &platformvm.L1ValidatorRegistrationJustification_ConvertSubnetToL1TxData{
-					ConvertSubnetToL1TxData: &platformvm.SubnetIDIndex{
-						SubnetId: subnetID[:],
-						Index:    validationIndex,
-					},
-				},
-			}
-			return proto.Marshal(&justification)
-		}
-	}
-	msg, err := GetRegistrationMessage(
-		rpcURL,
-		validationID,
-	)
-	if err != nil {
-		return nil, err
-	}
-	parsed, err := warp.ParseUnsignedMessage(msg)
-	if err != nil {
-		return nil, err
-	}
-	payload := parsed.Payload
-	addressedCall, err := warpPayload.ParseAddressedCall(payload)
-	if err != nil {
-		return nil, err
-	}
-	justification := platformvm.L1ValidatorRegistrationJustification{
-		Preimage: &platformvm.L1ValidatorRegistrationJustification_RegisterL1ValidatorMessage{
-			RegisterL1ValidatorMessage: addressedCall.Payload,
-		},
-	}
-	return proto.Marshal(&justification)
-}
-
-func GetRegistrationMessage(
-	rpcURL string,
-	validationID ids.ID,
-) ([]byte, error) {
-	client, err := evm.GetClient(rpcURL)
-	if err != nil {
-		return nil, err
-	}
-	ctx, cancel := utils.GetAPILargeContext()
-	defer cancel()
-	height, err := client.BlockNumber(ctx)
-	if err != nil {
-		return nil, err
-	}
-	for blockNumber := uint64(0); blockNumber <= height; blockNumber++ {
-		ctx, cancel := utils.GetAPILargeContext()
-		defer cancel()
-		block, err := client.BlockByNumber(ctx, big.NewInt(int64(blockNumber)))
-		if err != nil {
-			return nil, err
-		}
-		blockHash := block.Hash()
-		logs, err := client.FilterLogs(ctx, interfaces.FilterQuery{
-			BlockHash: &blockHash,
-			Addresses: []goethereumcommon.Address{subnetEvmWarp.Module.Address},
-		})
-		if err != nil {
-			return nil, err
-		}
-		for _, txLog := range logs {
-			msg, err := subnetEvmWarp.UnpackSendWarpEventDataToMessage(txLog.Data)
-			if err == nil {
-				payload := msg.Payload
-				addressedCall, err := warpPayload.ParseAddressedCall(payload)
-				if err == nil {
-					reg, err := warpMessage.ParseRegisterL1Validator(addressedCall.Payload)
-					if err == nil {
-						if reg.ValidationID() == validationID {
-							return msg.Bytes(), nil
-						}
-					}
-				}
-			}
-		}
-	}
-	return nil, fmt.Errorf("validation id %s not found on warp events", validationID)
-}
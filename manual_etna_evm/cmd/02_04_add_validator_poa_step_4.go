@@ -3,7 +3,9 @@ package cmd
 import (
 	"encoding/base64"
 	"fmt"
+	"path/filepath"
 
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
 	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
 )
 
@@ -26,13 +28,32 @@ func GetValidatorCMD(credsFolder string, nodeIndex int) (string, error) {
 	httpPort := 9650 + (nodeIndex)*2
 	stakingPort := httpPort + 1
 
+	chainConfigDir, err := filepath.Abs(filepath.Join(credsFolder, "chains"))
+	if err != nil {
+		return "", fmt.Errorf("failed to get chain config dir: %w", err)
+	}
+
+	networkConfig, err := LoadNodeNetworkConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load network config: %w", err)
+	}
+	// Network ID, bootstrappers and public IP, plus the genesis of a local network
+	networkArgs := ""
+	if networkConfig.GenesisFile != "" {
+		networkArgs += fmt.Sprintf("  -v %s:%s:ro \\\n", networkConfig.GenesisFile, containerGenesisPath)
+	}
+	for _, setting := range networkConfig.settings(containerGenesisPath) {
+		networkArgs += fmt.Sprintf("  -e %s \\\n", setting.EnvVar())
+	}
+
 	script := fmt.Sprintf(`
 docker rm -f %s || true; \
 docker run -d \
   --name %s \
   --network host \
-  -e AVALANCHEGO_NETWORK_ID=fuji \
-  -e AVALANCHEGO_HTTP_PORT=%d \
+  -v %s:/chains \
+  -e AVALANCHEGO_CHAIN_CONFIG_DIR=/chains \
+%s  -e AVALANCHEGO_HTTP_PORT=%d \
   -e AVALANCHEGO_STAKING_PORT=%d \
   -e AVALANCHEGO_TRACK_SUBNETS=%s \
   -e AVALANCHEGO_HTTP_ALLOWED_HOSTS=* \
@@ -40,11 +61,10 @@ docker run -d \
   -e AVALANCHEGO_STAKING_TLS_CERT_FILE_CONTENT=%s \
   -e AVALANCHEGO_STAKING_TLS_KEY_FILE_CONTENT=%s \
   -e BLS_KEY_BASE64=%s \
-  -e AVALANCHEGO_PUBLIC_IP_RESOLUTION_SERVICE=ifconfigme \
   -e AVALANCHEGO_PARTIAL_SYNC_PRIMARY_NETWORK=true \
-  containerman17/avalanchego-subnetevm:v1.12.0_v0.7.0 ;
+  %s ;
 
-	`, containerName, containerName, httpPort, stakingPort, subnetID.String(), stakerCertBase64, stakerKeyBase64, signerKeyBase64)
+	`, containerName, containerName, chainConfigDir, networkArgs, httpPort, stakingPort, subnetID.String(), stakerCertBase64, stakerKeyBase64, signerKeyBase64, config.NodeImage)
 
 	return script, nil
 }
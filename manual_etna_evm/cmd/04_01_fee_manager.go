@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ava-labs/subnet-evm/commontype"
+	"github.com/ava-labs/subnet-evm/ethclient"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/feemanager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	feeConfigGasLimit                 uint64
+	feeConfigTargetBlockRate          uint64
+	feeConfigMinBaseFee               uint64
+	feeConfigTargetGas                uint64
+	feeConfigBaseFeeChangeDenominator uint64
+	feeConfigMinBlockGasCost          uint64
+	feeConfigMaxBlockGasCost          uint64
+	feeConfigBlockGasCostStep         uint64
+)
+
+// feeConfigFlags are the flags of fee-config set, the inherited ones do not
+// change the fee config
+var feeConfigFlags = []string{
+	"gas-limit",
+	"target-block-rate",
+	"min-base-fee",
+	"target-gas",
+	"base-fee-change-denominator",
+	"min-block-gas-cost",
+	"max-block-gas-cost",
+	"block-gas-cost-step",
+}
+
+func init() {
+	rootCmd.AddCommand(feeConfigCmd)
+	feeConfigCmd.AddCommand(getFeeConfigCmd)
+	feeConfigCmd.AddCommand(setFeeConfigCmd)
+
+	setFeeConfigCmd.Flags().Uint64Var(&feeConfigGasLimit, "gas-limit", 0, "Block gas limit")
+	setFeeConfigCmd.Flags().Uint64Var(&feeConfigTargetBlockRate, "target-block-rate", 0, "Target seconds between blocks")
+	setFeeConfigCmd.Flags().Uint64Var(&feeConfigMinBaseFee, "min-base-fee", 0, "Minimum base fee in wei")
+	setFeeConfigCmd.Flags().Uint64Var(&feeConfigTargetGas, "target-gas", 0, "Target gas consumed per 10 seconds")
+	setFeeConfigCmd.Flags().Uint64Var(&feeConfigBaseFeeChangeDenominator, "base-fee-change-denominator", 0, "Base fee change denominator")
+	setFeeConfigCmd.Flags().Uint64Var(&feeConfigMinBlockGasCost, "min-block-gas-cost", 0, "Minimum block gas cost")
+	setFeeConfigCmd.Flags().Uint64Var(&feeConfigMaxBlockGasCost, "max-block-gas-cost", 0, "Maximum block gas cost")
+	setFeeConfigCmd.Flags().Uint64Var(&feeConfigBlockGasCostStep, "block-gas-cost-step", 0, "Block gas cost step")
+}
+
+var feeConfigCmd = &cobra.Command{
+	Use:   "fee-config",
+	Short: "Read or change the L1 fee config through the FeeManager precompile",
+}
+
+var getFeeConfigCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Print the current fee config",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("⛽ Reading fee config")
+
+		ethClient, _, err := getPrecompileTransactor(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		feeConfig, lastChangedAt, err := getFeeConfig(cmd.Context(), ethClient)
+		if err != nil {
+			return err
+		}
+
+		printFeeConfig(feeConfig)
+		fmt.Printf("Last changed at block: %d\n", lastChangedAt)
+		SetResult("feeConfig", feeConfig)
+		SetResult("lastChangedAt", lastChangedAt.Uint64())
+
+		return nil
+	},
+}
+
+var setFeeConfigCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Change the fee config, flags that are not set keep their current value",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("⛽ Changing fee config")
+
+		changed := false
+		for _, name := range feeConfigFlags {
+			changed = changed || cmd.Flags().Changed(name)
+		}
+		if !changed {
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("no fee config values were given, set one of --%s", strings.Join(feeConfigFlags, ", --")))
+		}
+
+		ethClient, opts, err := getPrecompileTransactor(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		feeConfig, _, err := getFeeConfig(cmd.Context(), ethClient)
+		if err != nil {
+			return err
+		}
+
+		flags := cmd.Flags()
+		if flags.Changed("gas-limit") {
+			feeConfig.GasLimit = new(big.Int).SetUint64(feeConfigGasLimit)
+		}
+		if flags.Changed("target-block-rate") {
+			feeConfig.TargetBlockRate = feeConfigTargetBlockRate
+		}
+		if flags.Changed("min-base-fee") {
+			feeConfig.MinBaseFee = new(big.Int).SetUint64(feeConfigMinBaseFee)
+		}
+		if flags.Changed("target-gas") {
+			feeConfig.TargetGas = new(big.Int).SetUint64(feeConfigTargetGas)
+		}
+		if flags.Changed("base-fee-change-denominator") {
+			feeConfig.BaseFeeChangeDenominator = new(big.Int).SetUint64(feeConfigBaseFeeChangeDenominator)
+		}
+		if flags.Changed("min-block-gas-cost") {
+			feeConfig.MinBlockGasCost = new(big.Int).SetUint64(feeConfigMinBlockGasCost)
+		}
+		if flags.Changed("max-block-gas-cost") {
+			feeConfig.MaxBlockGasCost = new(big.Int).SetUint64(feeConfigMaxBlockGasCost)
+		}
+		if flags.Changed("block-gas-cost-step") {
+			feeConfig.BlockGasCostStep = new(big.Int).SetUint64(feeConfigBlockGasCostStep)
+		}
+		if err := feeConfig.Verify(); err != nil {
+			return fmt.Errorf("invalid fee config: %w", err)
+		}
+
+		calldata, err := feemanager.PackSetFeeConfig(feeConfig)
+		if err != nil {
+			return fmt.Errorf("failed to pack setFeeConfig: %w", err)
+		}
+
+		receipt, err := transactPrecompile(cmd.Context(), ethClient, opts, feemanager.ContractAddress, calldata)
+		if err != nil {
+			return fmt.Errorf("failed to set fee config: %w", err)
+		}
+
+		log.Printf("✅ Fee config updated in block %d, tx %s\n", receipt.BlockNumber, receipt.TxHash.Hex())
+		printFeeConfig(feeConfig)
+		SetResult("feeConfig", feeConfig)
+		SetResult("txHash", receipt.TxHash.Hex())
+		SetResult("blockNumber", receipt.BlockNumber.Uint64())
+
+		return nil
+	},
+}
+
+func getFeeConfig(ctx context.Context, ethClient ethclient.Client) (commontype.FeeConfig, *big.Int, error) {
+	calldata, err := feemanager.PackGetFeeConfig()
+	if err != nil {
+		return commontype.FeeConfig{}, nil, fmt.Errorf("failed to pack getFeeConfig: %w", err)
+	}
+	output, err := callPrecompile(ctx, ethClient, feemanager.ContractAddress, calldata)
+	if err != nil {
+		return commontype.FeeConfig{}, nil, fmt.Errorf("failed to get fee config: %w", err)
+	}
+	feeConfig, err := feemanager.UnpackGetFeeConfigOutput(output, false)
+	if err != nil {
+		return commontype.FeeConfig{}, nil, fmt.Errorf("failed to unpack fee config: %w", err)
+	}
+
+	calldata, err = feemanager.PackGetFeeConfigLastChangedAt()
+	if err != nil {
+		return commontype.FeeConfig{}, nil, fmt.Errorf("failed to pack getFeeConfigLastChangedAt: %w", err)
+	}
+	output, err = callPrecompile(ctx, ethClient, feemanager.ContractAddress, calldata)
+	if err != nil {
+		return commontype.FeeConfig{}, nil, fmt.Errorf("failed to get fee config last changed at: %w", err)
+	}
+	lastChangedAt, err := feemanager.UnpackGetFeeConfigLastChangedAtOutput(output)
+	if err != nil {
+		return commontype.FeeConfig{}, nil, fmt.Errorf("failed to unpack fee config last changed at: %w", err)
+	}
+
+	return feeConfig, lastChangedAt, nil
+}
+
+func printFeeConfig(feeConfig commontype.FeeConfig) {
+	fmt.Println("Fee config:")
+	fmt.Printf("  Gas Limit: %s\n", feeConfig.GasLimit)
+	fmt.Printf("  Target Block Rate: %d\n", feeConfig.TargetBlockRate)
+	fmt.Printf("  Min Base Fee: %s\n", feeConfig.MinBaseFee)
+	fmt.Printf("  Target Gas: %s\n", feeConfig.TargetGas)
+	fmt.Printf("  Base Fee Change Denominator: %s\n", feeConfig.BaseFeeChangeDenominator)
+	fmt.Printf("  Min Block Gas Cost: %s\n", feeConfig.MinBlockGasCost)
+	fmt.Printf("  Max Block Gas Cost: %s\n", feeConfig.MaxBlockGasCost)
+	fmt.Printf("  Block Gas Cost Step: %s\n", feeConfig.BlockGasCostStep)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ava-labs/subnet-evm/precompile/contracts/nativeminter"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(mintNativeCoinCmd)
+}
+
+var mintNativeCoinCmd = &cobra.Command{
+	Use:   "mint-native <address> <amount>",
+	Short: "Mint native tokens through the NativeMinter precompile",
+	Long:  `Mint native tokens through the NativeMinter precompile. Amount is in whole tokens, e.g. "mint-native 0x... 1.5"`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🪙 Minting native tokens")
+
+		if !common.IsHexAddress(args[0]) {
+			return fmt.Errorf("invalid address: %s", args[0])
+		}
+		recipient := common.HexToAddress(args[0])
+
+		amount, err := ParseBalanceString(args[1], 18)
+		if err != nil {
+			return fmt.Errorf("failed to parse amount: %w", err)
+		}
+
+		ethClient, opts, err := getPrecompileTransactor(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		calldata, err := nativeminter.PackMintNativeCoin(recipient, amount)
+		if err != nil {
+			return fmt.Errorf("failed to pack mintNativeCoin: %w", err)
+		}
+
+		receipt, err := transactPrecompile(cmd.Context(), ethClient, opts, nativeminter.ContractAddress, calldata)
+		if err != nil {
+			return fmt.Errorf("failed to mint native tokens: %w", err)
+		}
+		log.Printf("✅ Minted %s tokens to %s in tx %s\n", GetBalanceString(amount, 18), recipient.Hex(), receipt.TxHash.Hex())
+
+		ctx, cancel := RPCContext(cmd.Context())
+		defer cancel()
+		balance, err := ethClient.BalanceAt(ctx, recipient, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get balance: %w", err)
+		}
+		fmt.Printf("Balance of %s: %s\n", recipient.Hex(), GetBalanceString(balance, 18))
+		SetResult("recipient", recipient.Hex())
+		SetResult("amount", amount.String())
+		SetResult("txHash", receipt.TxHash.Hex())
+		SetResult("balance", balance.String())
+
+		return nil
+	},
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ava-labs/subnet-evm/precompile/allowlist"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/deployerallowlist"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/txallowlist"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+const (
+	deployerAllowList = "deployer"
+	txAllowList       = "tx"
+)
+
+var (
+	allowListName string
+)
+
+func init() {
+	rootCmd.AddCommand(allowListCmd)
+	allowListCmd.PersistentFlags().StringVar(&allowListName, "list", "", fmt.Sprintf("Allow list to manage (%s or %s)", deployerAllowList, txAllowList))
+	allowListCmd.MarkPersistentFlagRequired("list")
+
+	allowListCmd.AddCommand(newSetAllowListRoleCmd("set-admin", allowlist.AdminRole))
+	allowListCmd.AddCommand(newSetAllowListRoleCmd("set-enabled", allowlist.EnabledRole))
+	allowListCmd.AddCommand(newSetAllowListRoleCmd("set-none", allowlist.NoRole))
+	allowListCmd.AddCommand(readAllowListCmd)
+}
+
+var allowListCmd = &cobra.Command{
+	Use:   "allow-list",
+	Short: "Manage the contract deployer and transaction allow lists",
+}
+
+func newSetAllowListRoleCmd(use string, role allowlist.Role) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <address>",
+		Short: fmt.Sprintf("Give an address the %s role", role),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			PrintHeader(fmt.Sprintf("📜 Setting %s role on the %s allow list", role, allowListName))
+
+			precompileAddress, err := getAllowListAddress(allowListName)
+			if err != nil {
+				return err
+			}
+			if !common.IsHexAddress(args[0]) {
+				return fmt.Errorf("invalid address: %s", args[0])
+			}
+			address := common.HexToAddress(args[0])
+
+			ethClient, opts, err := getPrecompileTransactor(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			calldata, err := allowlist.PackModifyAllowList(address, role)
+			if err != nil {
+				return fmt.Errorf("failed to pack allow list call: %w", err)
+			}
+
+			receipt, err := transactPrecompile(cmd.Context(), ethClient, opts, precompileAddress, calldata)
+			if err != nil {
+				return fmt.Errorf("failed to set %s role: %w", role, err)
+			}
+
+			log.Printf("✅ %s now has the %s role on the %s allow list, tx %s\n", address.Hex(), role, allowListName, receipt.TxHash.Hex())
+			SetResult("allowList", allowListName)
+			SetResult("address", address.Hex())
+			SetResult("role", role.String())
+			SetResult("txHash", receipt.TxHash.Hex())
+			return nil
+		},
+	}
+}
+
+var readAllowListCmd = &cobra.Command{
+	Use:   "read <address>",
+	Short: "Print the role of an address",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		precompileAddress, err := getAllowListAddress(allowListName)
+		if err != nil {
+			return err
+		}
+		if !common.IsHexAddress(args[0]) {
+			return fmt.Errorf("invalid address: %s", args[0])
+		}
+		address := common.HexToAddress(args[0])
+
+		ethClient, _, err := getPrecompileTransactor(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		calldata, err := allowlist.PackReadAllowList(address)
+		if err != nil {
+			return fmt.Errorf("failed to pack readAllowList: %w", err)
+		}
+
+		output, err := callPrecompile(cmd.Context(), ethClient, precompileAddress, calldata)
+		if err != nil {
+			return fmt.Errorf("failed to read allow list: %w", err)
+		}
+
+		role, err := allowlist.FromBig(new(big.Int).SetBytes(output))
+		if err != nil {
+			return fmt.Errorf("failed to parse role %x: %w", output, err)
+		}
+
+		fmt.Printf("Role of %s on the %s allow list: %s\n", address.Hex(), allowListName, role)
+		SetResult("allowList", allowListName)
+		SetResult("address", address.Hex())
+		SetResult("role", role.String())
+		return nil
+	},
+}
+
+func getAllowListAddress(name string) (common.Address, error) {
+	switch name {
+	case deployerAllowList:
+		return deployerallowlist.ContractAddress, nil
+	case txAllowList:
+		return txallowlist.ContractAddress, nil
+	default:
+		return common.Address{}, fmt.Errorf("invalid allow list: %s. Must be either '%s' or '%s'", name, deployerAllowList, txAllowList)
+	}
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/spf13/cobra"
+)
+
+var (
+	chainConfigProfile        string
+	chainConfigOverrides      []string
+	chainConfigResetOverrides bool
+	chainConfigNoRestart      bool
+)
+
+func init() {
+	rootCmd.AddCommand(chainConfigCmd)
+	chainConfigCmd.AddCommand(chainConfigProfilesCmd)
+	chainConfigCmd.AddCommand(chainConfigShowCmd)
+	chainConfigCmd.AddCommand(chainConfigApplyCmd)
+
+	chainConfigApplyCmd.Flags().StringVar(&chainConfigProfile, "profile", "", "Chain config profile to use, keeps the current one if empty")
+	chainConfigApplyCmd.Flags().StringArrayVar(&chainConfigOverrides, "set", nil, "Override a chain config key, e.g. --set log-level=info --set pruning-enabled=false")
+	chainConfigApplyCmd.Flags().BoolVar(&chainConfigResetOverrides, "reset", false, "Drop previously saved overrides")
+	chainConfigApplyCmd.Flags().BoolVar(&chainConfigNoRestart, "no-restart", false, "Only write the config files, do not restart the nodes")
+}
+
+var chainConfigCmd = &cobra.Command{
+	Use:   "chain-config",
+	Short: "Manage the L1 chain config of the local nodes",
+}
+
+var chainConfigProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List available chain config profiles",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profiles, err := ChainConfigProfiles()
+		if err != nil {
+			return err
+		}
+		selection, err := LoadChainConfigSelection()
+		if err != nil {
+			return err
+		}
+		for _, profile := range profiles {
+			marker := " "
+			if profile == selection.Profile {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\n", marker, profile)
+		}
+		SetResult("profiles", profiles)
+		SetResult("selected", selection.Profile)
+		return nil
+	},
+}
+
+var chainConfigShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the chain config that is written for every node",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selection, err := LoadChainConfigSelection()
+		if err != nil {
+			return err
+		}
+		chainConfig, err := RenderChainConfig(selection)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Profile: %s\n", selection.Profile)
+		fmt.Println(string(chainConfig))
+		SetResult("profile", selection.Profile)
+		SetResult("chainConfig", json.RawMessage(chainConfig))
+		return nil
+	},
+}
+
+var chainConfigApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Regenerate the chain config for every managed node and restart them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("⚙️  Applying chain config")
+
+		selection, err := LoadChainConfigSelection()
+		if err != nil {
+			return err
+		}
+		if chainConfigProfile != "" {
+			selection.Profile = chainConfigProfile
+		}
+		if chainConfigResetOverrides || selection.Overrides == nil {
+			selection.Overrides = map[string]interface{}{}
+		}
+		for _, override := range chainConfigOverrides {
+			key, value, err := ParseChainConfigOverride(override)
+			if err != nil {
+				return err
+			}
+			selection.Overrides[key] = value
+		}
+
+		// Render once before saving so a typo in the profile name does not end up in the workspace
+		if _, err := RenderChainConfig(selection); err != nil {
+			return err
+		}
+		if err := warnDebugChainConfig(selection); err != nil {
+			return err
+		}
+		if err := SaveChainConfigSelection(selection); err != nil {
+			return fmt.Errorf("failed to save chain config selection: %w", err)
+		}
+		SetResult("profile", selection.Profile)
+
+		chainID, err := helpers.LoadId(helpers.ChainIdPath)
+		if err != nil {
+			return fmt.Errorf("failed to load chain ID: %w", err)
+		}
+
+		nodes, err := GetManagedNodes()
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		var runner NodeRunner
+		if !chainConfigNoRestart {
+			runner, err = GetNodeRunner(ctx, "")
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, node := range nodes {
+			if err := WriteChainConfig(node.ChainConfigDir, chainID); err != nil {
+				return fmt.Errorf("failed to write chain config for %s: %w", node.Name, err)
+			}
+			log.Printf("Wrote %s chain config for %s\n", selection.Profile, node.Name)
+			AppendResult("written", node.Name)
+
+			if chainConfigNoRestart {
+				continue
+			}
+			restarted, err := runner.Restart(ctx, node.Name)
+			if err != nil {
+				return fmt.Errorf("failed to restart %s: %w", node.Name, err)
+			}
+			if !restarted {
+				log.Printf("%s is not running, it will pick up the config on next start\n", node.Name)
+				AppendResult("pending", node.Name)
+				continue
+			}
+			log.Printf("✅ Restarted %s\n", node.Name)
+			AppendResult("restarted", node.Name)
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	nodesLogsFollow bool
+	nodesLogsTail   int
+	nodesRecreate   bool
+	nodesWait       bool
+	nodesRunner     string
+
+	superviseAvalanchegoPath string
+	supervisePluginDir       string
+)
+
+func init() {
+	rootCmd.AddCommand(nodesCmd)
+	nodesCmd.AddCommand(nodesUpCmd)
+	nodesCmd.AddCommand(nodesDownCmd)
+	nodesCmd.AddCommand(nodesRestartCmd)
+	nodesCmd.AddCommand(nodesStatusCmd)
+	nodesCmd.AddCommand(nodesLogsCmd)
+	nodesCmd.AddCommand(nodesSuperviseCmd)
+
+	nodesCmd.PersistentFlags().StringVar(&nodesRunner, "runner", "", fmt.Sprintf("Node runner (%s or %s), defaults to the one used by the last nodes up", DockerRunner, ProcessRunner))
+	nodesUpCmd.Flags().BoolVar(&nodesRecreate, "recreate", false, "Recreate containers that are already running")
+	nodesUpCmd.Flags().BoolVar(&nodesWait, "wait", false, "Wait until the nodes have bootstrapped and serve the L1")
+	nodesLogsCmd.Flags().BoolVarP(&nodesLogsFollow, "follow", "f", false, "Follow log output")
+	nodesLogsCmd.Flags().IntVar(&nodesLogsTail, "tail", 100, "Number of lines to show from the end of the logs")
+
+	nodesSuperviseCmd.Flags().StringVar(&superviseAvalanchegoPath, "avalanchego-path", "", "Path to the avalanchego binary")
+	nodesSuperviseCmd.Flags().StringVar(&supervisePluginDir, "plugin-dir", "", "Folder with the subnet-evm plugin")
+}
+
+var nodesCmd = &cobra.Command{
+	Use:   "nodes",
+	Short: "Manage node0 and every added validator as one local cluster",
+	Long: `Manage node0 and every added validator as one local cluster.
+nodeN gets HTTP port 9650+2N, staking port 9651+2N, data dir data/nodeN and
+the credentials from data/add_validator_N.
+
+With --runner docker (default) containers are managed through the Docker
+Engine API ($DOCKER_HOST or /var/run/docker.sock). With --runner process a
+local avalanchego ($AVALANCHEGO_PATH or PATH) with the subnet-evm plugin from
+$AVALANCHEGO_PLUGIN_DIR (default ~/.avalanchego/plugins) runs under a
+supervisor that restarts it on crash, its log is data/nodeN/avalanchego.log.
+The runner given to nodes up is remembered in data/node_runner.txt.`,
+}
+
+var nodesUpCmd = &cobra.Command{
+	Use:   "up [node...]",
+	Short: "Start the nodes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🐳 Starting nodes")
+		if err := NodesUp(cmd.Context(), nodesRunner, args); err != nil {
+			return err
+		}
+		if !nodesWait {
+			return nil
+		}
+		return WaitForNodes(cmd.Context(), nodesRunner, args)
+	},
+}
+
+var nodesDownCmd = &cobra.Command{
+	Use:   "down [node...]",
+	Short: "Stop the nodes, data stays on disk",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🐳 Stopping nodes")
+
+		ctx := cmd.Context()
+		runner, err := GetNodeRunner(ctx, nodesRunner)
+		if err != nil {
+			return err
+		}
+
+		names := args
+		if len(names) == 0 {
+			names, err = allNodeNames(ctx, runner)
+			if err != nil {
+				return err
+			}
+		} else if _, err := selectNodes(names); err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			if err := runner.Stop(ctx, name); err != nil {
+				return fmt.Errorf("failed to stop %s: %w", name, err)
+			}
+			log.Printf("✅ Removed %s\n", name)
+			AppendResult("removed", name)
+		}
+		return nil
+	},
+}
+
+var nodesRestartCmd = &cobra.Command{
+	Use:   "restart [node...]",
+	Short: "Restart the running nodes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🐳 Restarting nodes")
+
+		nodes, err := selectNodes(args)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+		runner, err := GetNodeRunner(ctx, nodesRunner)
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodes {
+			restarted, err := runner.Restart(ctx, node.Name)
+			if err != nil {
+				return fmt.Errorf("failed to restart %s: %w", node.Name, err)
+			}
+			if !restarted {
+				log.Printf("%s is not running, start it with: go run . nodes up %s\n", node.Name, node.Name)
+				AppendResult("notRunning", node.Name)
+				continue
+			}
+			log.Printf("✅ Restarted %s\n", node.Name)
+			AppendResult("restarted", node.Name)
+		}
+		return nil
+	},
+}
+
+var nodesStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print every managed node with its ports and state",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nodes, err := GetManagedNodes()
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+		runner, err := GetNodeRunner(ctx, nodesRunner)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Runner: %s\n", runner.Name())
+		SetResult("runner", runner.Name())
+		SetResult("nodes", []any{})
+
+		for _, node := range nodes {
+			state, err := runner.Status(ctx, node.Name)
+			if err != nil {
+				return fmt.Errorf("failed to inspect %s: %w", node.Name, err)
+			}
+
+			nodeID := "unknown"
+			if id, _, err := l1.NodeInfoFromCreds(node.CredsFolder); err == nil {
+				nodeID = id.String()
+			}
+
+			fmt.Printf("%s: %s\n", node.Name, state.State)
+			if state.Health != "" {
+				fmt.Printf("  Health: %s\n", state.Health)
+			}
+			if state.State == "exited" || state.State == "dead" {
+				fmt.Printf("  Exit code: %d\n", state.ExitCode)
+			}
+			if state.RestartCount > 0 {
+				fmt.Printf("  Restarts: %d\n", state.RestartCount)
+			}
+			if state.Error != "" {
+				fmt.Printf("  Error: %s\n", state.Error)
+			}
+			fmt.Printf("  NodeID: %s\n", nodeID)
+			fmt.Printf("  URI: %s\n", node.URI())
+			fmt.Printf("  Staking port: %d\n", node.StakingPort)
+			fmt.Printf("  Data dir: %s\n", node.DataDir)
+			AppendResult("nodes", map[string]any{
+				"name":        node.Name,
+				"state":       state.State,
+				"health":      state.Health,
+				"exitCode":    state.ExitCode,
+				"restarts":    state.RestartCount,
+				"error":       state.Error,
+				"nodeID":      nodeID,
+				"uri":         node.URI(),
+				"stakingPort": node.StakingPort,
+				"dataDir":     node.DataDir,
+			})
+		}
+		return nil
+	},
+}
+
+var nodesLogsCmd = &cobra.Command{
+	Use:   "logs <node>",
+	Short: "Print the logs of a node",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nodes, err := selectNodes(args)
+		if err != nil {
+			return err
+		}
+
+		// Following ends on Ctrl-C through the command context
+		ctx := cmd.Context()
+		runner, err := GetNodeRunner(ctx, nodesRunner)
+		if err != nil {
+			return err
+		}
+		SetResult("node", nodes[0].Name)
+		options := NodeLogsOptions{Follow: nodesLogsFollow, Tail: nodesLogsTail}
+		if err := runner.Logs(ctx, nodes[0].Name, options, os.Stdout, os.Stderr); err != nil {
+			return fmt.Errorf("failed to read logs of %s: %w", nodes[0].Name, err)
+		}
+		return nil
+	},
+}
+
+var nodesSuperviseCmd = &cobra.Command{
+	Use:    "supervise <node>",
+	Short:  "Run avalanchego for a node in the foreground and restart it on crash",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nodes, err := selectNodes(args)
+		if err != nil {
+			return err
+		}
+		return SuperviseNode(nodes[0], superviseAvalanchegoPath, supervisePluginDir)
+	},
+}
+
+// NodesUp writes the chain configs and starts the given nodes with the
+// given runner, or all of them if no names are given
+func NodesUp(ctx context.Context, runnerName string, names []string) error {
+	allNodes, err := GetManagedNodes()
+	if err != nil {
+		return err
+	}
+	nodes, err := FilterManagedNodes(allNodes, names)
+	if err != nil {
+		return err
+	}
+	if err := PrepareNodes(nodes); err != nil {
+		return err
+	}
+
+	launch, err := LoadNodeLaunchConfig()
+	if err != nil {
+		return err
+	}
+	if err := WriteNodesCompose(allNodes, launch); err != nil {
+		return err
+	}
+
+	runner, err := GetNodeRunner(ctx, runnerName)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		started, err := runner.Start(ctx, node, launch, nodesRecreate)
+		if err != nil {
+			return fmt.Errorf("failed to start %s: %w", node.Name, err)
+		}
+		AppendResult("nodes", map[string]any{
+			"name":    node.Name,
+			"uri":     node.URI(),
+			"started": started,
+		})
+		if !started {
+			fmt.Printf("✅ %s is already running, RPC at %s\n", node.Name, node.URI())
+			continue
+		}
+		fmt.Printf("✅ %s started, RPC at %s, logs: go run . nodes logs %s\n", node.Name, node.URI(), node.Name)
+	}
+	return nil
+}
+
+// WaitForNodes waits until the given nodes, or all of them if no names are
+// given, serve the L1, one after the other, bounded by --node-ready-timeout
+func WaitForNodes(ctx context.Context, runnerName string, names []string) error {
+	nodes, err := selectNodes(names)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := NodeReadyContext(ctx)
+	defer cancel()
+	runner, err := GetNodeRunner(ctx, runnerName)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		readiness, err := NewNodeReadiness(node, runner)
+		if err != nil {
+			return err
+		}
+		if err := readiness.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func selectNodes(names []string) ([]ManagedNode, error) {
+	nodes, err := GetManagedNodes()
+	if err != nil {
+		return nil, err
+	}
+	return FilterManagedNodes(nodes, names)
+}
+
+// allNodeNames returns every node of the workspace plus nodes started by the
+// runner whose folder is already gone
+func allNodeNames(ctx context.Context, runner NodeRunner) ([]string, error) {
+	nodes, err := GetManagedNodes()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	seen := map[string]bool{}
+	for _, node := range nodes {
+		names = append(names, node.Name)
+		seen[node.Name] = true
+	}
+
+	started, err := runner.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range started {
+		if !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+var reconcileApply bool
+
+func init() {
+	rootCmd.AddCommand(reconcileCmd)
+	reconcileCmd.Flags().BoolVar(&reconcileApply, "apply", false, "Run the action of every discrepancy")
+}
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Find validations the P-chain and the validator manager disagree about",
+	Long: `Compare the P-chain validators of the L1 with the validations of the validator manager
+contract and print every discrepancy with the action that resolves it. A flow that stopped halfway
+leaves one behind, e.g. a validator registered on the P-chain that is still pending in the contract.
+With --apply the actions are run one after the other.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🔍 Reconciling P-chain and validator manager")
+
+		manager, err := GetValidatorManager()
+		if err != nil {
+			return err
+		}
+		discrepancies, err := manager.Reconcile(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to compare validators: %w", err)
+		}
+		SetResult("discrepancies", []any{})
+		if len(discrepancies) == 0 {
+			fmt.Println("✅ The P-chain and the validator manager agree")
+			return nil
+		}
+
+		var failed int
+		for _, discrepancy := range discrepancies {
+			fmt.Printf("⚠️ %s: %s\n", discrepancy.NodeID, discrepancy.Kind)
+			fmt.Printf("  Validation ID: %s\n", discrepancy.ValidationID)
+			fmt.Printf("  %s\n", discrepancy.Detail)
+			fmt.Printf("  Action: %s\n", reconcileActionHelp(discrepancy))
+			result := map[string]any{
+				"nodeID":       discrepancy.NodeID.String(),
+				"validationID": discrepancy.ValidationID.String(),
+				"kind":         string(discrepancy.Kind),
+				"detail":       discrepancy.Detail,
+				"action":       string(discrepancy.Action),
+			}
+
+			if reconcileApply && reconcileAutomatic(discrepancy.Action) {
+				applied, err := applyReconcileAction(cmd.Context(), manager, discrepancy)
+				for key, value := range applied {
+					result[key] = value
+				}
+				if err != nil {
+					failed++
+					log.Printf("❌ %s: %s\n", discrepancy.NodeID, err)
+					result["error"] = map[string]string{"message": err.Error(), "code": ErrorCode(err)}
+				} else {
+					log.Printf("✅ %s: %s done\n", discrepancy.NodeID, discrepancy.Action)
+					result["applied"] = true
+				}
+			}
+			AppendResult("discrepancies", result)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d actions failed, run the command again to retry them", failed)
+		}
+		if !reconcileApply {
+			fmt.Println("Run go run . reconcile --apply to run the actions")
+		}
+		return nil
+	},
+}
+
+// reconcileAutomatic reports whether --apply runs the action, the others
+// need a decision or a command of their own
+func reconcileAutomatic(action l1.ReconcileAction) bool {
+	switch action {
+	case l1.ActionCompleteRegistration, l1.ActionResubmitRegistration, l1.ActionInvalidateRegistration, l1.ActionResubmitWeight, l1.ActionCompleteRemoval, l1.ActionRemove:
+		return true
+	}
+	return false
+}
+
+// reconcileActionHelp says what the action of the discrepancy does
+func reconcileActionHelp(discrepancy l1.Discrepancy) string {
+	switch discrepancy.Action {
+	case l1.ActionCompleteRegistration:
+		return "complete the registration in the contract with the P-chain attestation"
+	case l1.ActionResubmitRegistration:
+		return "re-sign the registration message of the contract and register the node on the P-chain, needs the creds of a local node"
+	case l1.ActionInvalidateRegistration:
+		return "get the P-chain to sign that the validation never will be registered and invalidate it in the contract, which frees the node ID"
+	case l1.ActionResubmitWeight:
+		return "re-sign the last weight message of the contract and set the weight on the P-chain"
+	case l1.ActionCompleteRemoval:
+		return "complete the end of the validation in the contract with the P-chain attestation"
+	case l1.ActionRemove:
+		return fmt.Sprintf("remove the validator from the contract, like go run . remove-poa-validator %s", discrepancy.NodeID)
+	case l1.ActionTopUpOrRemove:
+		return fmt.Sprintf("reactivate it with go run . balance top-up %s <AVAX>, or if it was disabled on purpose remove it with go run . remove-poa-validator %s, not run by --apply", discrepancy.NodeID, discrepancy.NodeID)
+	case l1.ActionInitializeValidatorSet:
+		return "initialize the validator set with go run . initialize-validator-set if it was not, not run by --apply"
+	}
+	return "none, the contract and the P-chain can not be brought in line by this tool"
+}
+
+// applyReconcileAction runs the action of the discrepancy and returns the
+// txs it produced for the result object
+func applyReconcileAction(ctx context.Context, manager *l1.ValidatorManager, discrepancy l1.Discrepancy) (map[string]any, error) {
+	switch discrepancy.Action {
+	case l1.ActionCompleteRegistration:
+		txHash, err := manager.CompleteValidatorRegistration(ctx, discrepancy.ValidationID)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"completeTxHash": txHash.Hex()}, nil
+	case l1.ActionResubmitRegistration:
+		credsFolder, err := localCredsFolder(discrepancy.NodeID)
+		if err != nil {
+			return nil, err
+		}
+		validator, err := l1.ValidatorFromCreds(credsFolder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node info from %s: %w", credsFolder, err)
+		}
+		validator.Balance = 1 * units.Avax
+		txID, err := manager.ResubmitRegistration(ctx, discrepancy.ValidationID, validator)
+		result := map[string]any{"registerTxID": txID.String()}
+		if err != nil {
+			return result, err
+		}
+		txHash, err := manager.CompleteValidatorRegistration(ctx, discrepancy.ValidationID)
+		if err != nil {
+			return result, err
+		}
+		result["completeTxHash"] = txHash.Hex()
+		return result, nil
+	case l1.ActionInvalidateRegistration:
+		txHash, err := manager.InvalidateRegistration(ctx, discrepancy.ValidationID)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"completeTxHash": txHash.Hex()}, nil
+	case l1.ActionResubmitWeight:
+		txID, err := manager.ResubmitWeightUpdate(ctx, discrepancy.ValidationID, discrepancy.NodeID)
+		return map[string]any{"setWeightTxID": txID.String()}, err
+	case l1.ActionCompleteRemoval:
+		txHash, err := manager.CompleteValidatorRemoval(ctx, discrepancy.ValidationID)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"completeTxHash": txHash.Hex()}, nil
+	case l1.ActionRemove:
+		removal, err := manager.RemoveValidator(ctx, discrepancy.NodeID)
+		result := map[string]any{}
+		if removal != nil && removal.InitializeTxHash != (common.Hash{}) {
+			result["initializeTxHash"] = removal.InitializeTxHash.Hex()
+		}
+		if removal != nil && removal.CompleteTxHash != (common.Hash{}) {
+			result["completeTxHash"] = removal.CompleteTxHash.Hex()
+		}
+		return result, err
+	}
+	return nil, fmt.Errorf("action %s is not run by --apply", discrepancy.Action)
+}
+
+// localCredsFolder is the creds folder of the managed node with the node ID
+func localCredsFolder(nodeID ids.NodeID) (string, error) {
+	nodes, err := GetManagedNodes()
+	if err != nil {
+		return "", err
+	}
+	for _, node := range nodes {
+		folderNodeID, _, err := l1.NodeInfoFromCreds(node.CredsFolder)
+		if err == nil && folderNodeID == nodeID {
+			return node.CredsFolder, nil
+		}
+	}
+	return "", fmt.Errorf("no local node has the creds of %s: %w", nodeID, ErrValidatorNotFound)
+}
@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	balanceWatchBelow    string
+	balanceWatchAmount   string
+	balanceWatchMaxSpend string
+	balanceWatchInterval time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(balanceCmd)
+	balanceCmd.AddCommand(balanceShowCmd)
+	balanceCmd.AddCommand(balanceTopUpCmd)
+	balanceCmd.AddCommand(balanceWatchCmd)
+
+	balanceWatchCmd.Flags().StringVar(&balanceWatchBelow, "below", "0.1", "Top up validators whose balance in AVAX falls below this")
+	balanceWatchCmd.Flags().StringVar(&balanceWatchAmount, "amount", "0.5", "AVAX added by every top-up")
+	balanceWatchCmd.Flags().StringVar(&balanceWatchMaxSpend, "max-spend", "5", "Stop topping up once this much AVAX was spent in total")
+	balanceWatchCmd.Flags().DurationVar(&balanceWatchInterval, "interval", time.Minute, "Time between two balance checks")
+}
+
+var balanceCmd = &cobra.Command{
+	Use:   "balance",
+	Short: "Show and top up the balances L1 validators pay the continuous fee from",
+	Long: `Every L1 validator pays a continuous fee to the P-chain from its balance. Once the
+balance runs out the P-chain deactivates the validator: it stays registered but leaves the
+validator set until it is topped up again. The run-out times assume the minimum fee price of the
+network, the price only rises while more L1 validators than the target of the network are active.`,
+}
+
+var balanceShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the balance and projected run-out time of every validator",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("💰 Printing validator balances")
+
+		manager, err := GetValidatorManager()
+		if err != nil {
+			return err
+		}
+		network := manager.L1.Workspace.Network
+		rate := network.ValidatorFeeRate()
+		fmt.Printf("Fee rate: %d nAVAX/s, %s AVAX a day at the minimum price\n", rate, formatAvax(rate*uint64(24*time.Hour/time.Second)))
+		SetResult("feeRate", rate)
+		SetResult("validators", []any{})
+
+		balances, err := validatorBalances(cmd.Context(), manager)
+		if err != nil {
+			return err
+		}
+		table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "NODE ID\tBALANCE\tRUNS OUT (BEST CASE)\tIN\tVALIDATION ID")
+		now := time.Now()
+		for _, validator := range balances {
+			runOut := network.BalanceRunOut(validator.Balance)
+			result := map[string]any{
+				"nodeID":       validator.NodeID.String(),
+				"validationID": validator.ValidationID.String(),
+				"balance":      validator.Balance,
+			}
+			runsOut, in := "-", "deactivated"
+			if validator.Balance > 0 {
+				runsOut = now.Add(runOut).UTC().Format(time.RFC3339)
+				in = runOut.Round(time.Minute).String()
+				result["runsOutAt"] = runsOut
+				result["runsOutIn"] = runOut.String()
+				// The price rises while more L1 validators than the target
+				// are active, the P-chain API does not expose it
+				result["runOutEstimate"] = "best-case"
+			}
+			result["deactivated"] = validator.Balance == 0
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", validator.NodeID, formatAvax(validator.Balance), runsOut, in, validator.ValidationID)
+			AppendResult("validators", result)
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		for _, validator := range balances {
+			if validator.Balance == 0 {
+				log.Printf("⚠️ %s ran out and was deactivated, top it up with go run . balance top-up %s <AVAX>\n", validator.NodeID, validator.NodeID)
+			}
+		}
+		return nil
+	},
+}
+
+var balanceTopUpCmd = &cobra.Command{
+	Use:   "top-up <NodeID|validationID> <AVAX>",
+	Short: "Add AVAX of the signer to the balance of a validator",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("💰 Topping up validator balance")
+
+		amount, err := parseAvax(args[1])
+		if err != nil {
+			return err
+		}
+		manager, err := GetValidatorManager()
+		if err != nil {
+			return err
+		}
+		nodeID, validationID, err := resolveValidation(cmd.Context(), manager, args[0])
+		if err != nil {
+			return err
+		}
+		SetResult("nodeID", nodeID.String())
+		SetResult("validationID", validationID.String())
+		SetResult("amount", amount)
+
+		txID, err := manager.L1.IncreaseValidatorBalance(cmd.Context(), validationID, amount)
+		if txID != ids.Empty {
+			SetResult("txID", txID.String())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to top up %s: %w", nodeID, err)
+		}
+		log.Printf("✅ Added %s AVAX to the balance of %s, tx %s\n", formatAvax(amount), nodeID, txID)
+
+		validator, err := manager.L1.L1Validator(cmd.Context(), validationID)
+		if err != nil {
+			return err
+		}
+		SetResult("balance", validator.Balance)
+		fmt.Printf("Balance: %s AVAX, runs out in %s\n", formatAvax(validator.Balance), manager.L1.Workspace.Network.BalanceRunOut(validator.Balance).Round(time.Minute))
+		return nil
+	},
+}
+
+var balanceWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Top up validators whose balance falls below a threshold, up to a spend cap",
+	Long: `Check the balance of every validator each --interval and add --amount AVAX to the ones
+below --below AVAX, deactivated ones included. Once the top-ups spent --max-spend AVAX in total
+no more are issued and the command exits. Interrupt it to stop watching.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("💰 Watching validator balances")
+
+		below, err := parseAvax(balanceWatchBelow)
+		if err != nil {
+			return err
+		}
+		amount, err := parseAvax(balanceWatchAmount)
+		if err != nil {
+			return err
+		}
+		maxSpend, err := parseAvax(balanceWatchMaxSpend)
+		if err != nil {
+			return err
+		}
+		if amount == 0 || amount > maxSpend {
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("--amount must be more than 0 and at most --max-spend"))
+		}
+		manager, err := GetValidatorManager()
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+		SetResult("topUps", []any{})
+
+		var spent uint64
+		log.Printf("Topping up by %s AVAX below %s AVAX, up to %s AVAX in total\n", formatAvax(amount), formatAvax(below), formatAvax(maxSpend))
+		for {
+			balances, err := validatorBalances(ctx, manager)
+			if err != nil && ctx.Err() == nil {
+				// The next check may succeed, a watcher should not stop on
+				// a node that is briefly unreachable
+				log.Printf("⚠️ Failed to read balances: %s\n", err)
+			}
+			for _, validator := range balances {
+				if validator.Balance >= below || ctx.Err() != nil {
+					continue
+				}
+				if spent+amount > maxSpend {
+					SetResult("spent", spent)
+					return fmt.Errorf("%s is at %s AVAX but another top-up would exceed the spend cap of %s AVAX: %w", validator.NodeID, formatAvax(validator.Balance), formatAvax(maxSpend), ErrInsufficientBalance)
+				}
+				log.Printf("%s is at %s AVAX, topping up\n", validator.NodeID, formatAvax(validator.Balance))
+				txID, err := manager.L1.IncreaseValidatorBalance(ctx, validator.ValidationID, amount)
+				result := map[string]any{
+					"nodeID":       validator.NodeID.String(),
+					"validationID": validator.ValidationID.String(),
+					"amount":       amount,
+					"time":         time.Now().UTC().Format(time.RFC3339),
+				}
+				if txID != ids.Empty {
+					result["txID"] = txID.String()
+					// An issued tx may be accepted even if waiting for it failed
+					spent += amount
+				}
+				if err != nil {
+					log.Printf("❌ Failed to top up %s: %s\n", validator.NodeID, err)
+					result["error"] = map[string]string{"message": err.Error(), "code": ErrorCode(err)}
+				} else {
+					log.Printf("✅ Topped up %s, spent %s of %s AVAX\n", validator.NodeID, formatAvax(spent), formatAvax(maxSpend))
+				}
+				AppendResult("topUps", result)
+			}
+			SetResult("spent", spent)
+
+			select {
+			case <-ctx.Done():
+				log.Printf("Stopped watching, spent %s AVAX\n", formatAvax(spent))
+				return nil
+			case <-time.After(balanceWatchInterval):
+			}
+		}
+	},
+}
+
+// validatorBalance is the P-chain balance of a registered validator
+type validatorBalance struct {
+	NodeID       ids.NodeID
+	ValidationID ids.ID
+	Balance      uint64
+}
+
+// validatorBalances lists the balance of every validation the P-chain has,
+// deactivated ones included. It reads the P-chain only, so it works while the
+// L1 is down.
+func validatorBalances(ctx context.Context, manager *l1.ValidatorManager) ([]validatorBalance, error) {
+	conversionTxID, err := helpers.LoadId(helpers.ConversionIdPath)
+	if err != nil {
+		conversionTxID = ids.Empty
+	}
+	validations, missing, err := manager.PChainValidations(ctx, conversionTxID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get validators: %w", err)
+	}
+	for _, nodeID := range missing {
+		log.Printf("⚠️ %s is in the validator set but neither the conversion nor the warp index has its validation, its balance is not listed\n", nodeID)
+	}
+	balances := make([]validatorBalance, 0, len(validations))
+	for _, validation := range validations {
+		balances = append(balances, validatorBalance{
+			NodeID:       validation.Validator.NodeID,
+			ValidationID: validation.ValidationID,
+			Balance:      validation.Validator.Balance,
+		})
+	}
+	return balances, nil
+}
+
+// resolveValidation takes a NodeID or a validation ID and returns both. A
+// NodeID is looked up on the P-chain first, so it resolves while the L1 is
+// down, and in the contract for registrations the warp index does not have
+// yet.
+func resolveValidation(ctx context.Context, manager *l1.ValidatorManager, arg string) (ids.NodeID, ids.ID, error) {
+	if nodeID, err := ids.NodeIDFromString(arg); err == nil {
+		conversionTxID, err := helpers.LoadId(helpers.ConversionIdPath)
+		if err != nil {
+			conversionTxID = ids.Empty
+		}
+		validationID, err := manager.PChainValidationID(ctx, nodeID, conversionTxID)
+		if err == nil {
+			return nodeID, validationID, nil
+		}
+		if !errors.Is(err, ErrValidatorNotFound) {
+			return ids.EmptyNodeID, ids.Empty, fmt.Errorf("failed to get validation of %s: %w", nodeID, err)
+		}
+		validationID, err = manager.ValidationID(ctx, nodeID)
+		if err != nil {
+			return ids.EmptyNodeID, ids.Empty, fmt.Errorf("failed to get validation of %s: %w", nodeID, err)
+		}
+		return nodeID, validationID, nil
+	}
+	validationID, err := ids.FromString(arg)
+	if err != nil {
+		return ids.EmptyNodeID, ids.Empty, WithErrorCode(ErrCodeUsage, fmt.Errorf("%q is neither a NodeID nor a validation ID", arg))
+	}
+	validator, err := manager.L1.L1Validator(ctx, validationID)
+	if err != nil {
+		return ids.EmptyNodeID, ids.Empty, err
+	}
+	return validator.NodeID, validationID, nil
+}
+
+// parseAvax turns an AVAX amount like "0.5" into nAVAX
+func parseAvax(amount string) (uint64, error) {
+	value, err := ParseBalanceString(amount, 9)
+	if err != nil {
+		return 0, WithErrorCode(ErrCodeUsage, err)
+	}
+	if !value.IsUint64() {
+		return 0, WithErrorCode(ErrCodeUsage, fmt.Errorf("amount %s is too large", amount))
+	}
+	return value.Uint64(), nil
+}
+
+// formatAvax prints nAVAX as AVAX
+func formatAvax(amount uint64) string {
+	return GetBalanceString(new(big.Int).SetUint64(amount), 9)
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/spf13/cobra"
+)
+
+var disableValidatorYes bool
+
+func init() {
+	rootCmd.AddCommand(disableValidatorCmd)
+	disableValidatorCmd.Flags().BoolVarP(&disableValidatorYes, "yes", "y", false, fmt.Sprintf("Disable without asking for confirmation, required with -o %s", OutputJSON))
+}
+
+var disableValidatorCmd = &cobra.Command{
+	Use:   "disable-validator <NodeID|validationID>",
+	Short: "Deactivate an L1 validator directly on the P-chain, without the validator manager",
+	Long: `Issue a DisableL1ValidatorTx signed by the disable owner set at registration, the
+validator manager owner key here. The validator leaves the validator set and its remaining
+balance goes back to its remaining balance owner. Only the P-chain is needed, so this works
+while the L1 is down and remove-poa-validator can not run.
+
+The contract still has the validation active afterwards. Once the L1 is back, go run . reconcile
+reports it and go run . remove-poa-validator ends it in the contract.
+
+It asks for confirmation before issuing the tx, pass --yes to skip it in scripts.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🛑 Disabling L1 validator on the P-chain")
+
+		manager, err := GetValidatorManager()
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+		nodeID, validationID, err := resolveValidation(ctx, manager, args[0])
+		if err != nil {
+			return err
+		}
+		SetResult("nodeID", nodeID.String())
+		SetResult("validationID", validationID.String())
+
+		validator, err := manager.L1.L1Validator(ctx, validationID)
+		if err != nil {
+			return err
+		}
+		hrp := constants.GetHRP(manager.L1.Workspace.Network.ID)
+		refundOwners, err := formatPChainOwners(hrp, validator.RemainingBalanceOwner)
+		if err != nil {
+			return err
+		}
+		refundOwner := strings.Join(refundOwners, ", ")
+		fmt.Printf("Node ID: %s\n", nodeID)
+		fmt.Printf("Validation ID: %s\n", validationID)
+		fmt.Printf("Weight: %d\n", validator.Weight)
+		fmt.Printf("Balance: %s AVAX, refunded to %s\n", formatAvax(validator.Balance), refundOwner)
+		SetResult("weight", validator.Weight)
+		SetResult("refunded", validator.Balance)
+		SetResult("remainingBalanceOwner", refundOwners)
+		if validator.Balance == 0 {
+			log.Printf("⚠️ %s is already deactivated, disabling again only makes sure it stays so\n", nodeID)
+		}
+		if !disableValidatorYes {
+			// The result object is the only output in JSON mode, there is
+			// no one to answer a prompt
+			if JSONOutput() {
+				return WithErrorCode(ErrCodeUsage, fmt.Errorf("pass --yes to disable %s with -o %s", nodeID, OutputJSON))
+			}
+			confirmed, err := Confirm(os.Stdin, fmt.Sprintf("Disable %s and refund %s AVAX to %s?", nodeID, formatAvax(validator.Balance), refundOwner))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return WithErrorCode(ErrCodeCanceled, fmt.Errorf("disabling %s was not confirmed", nodeID))
+			}
+		}
+
+		txID, err := manager.L1.DisableValidator(ctx, validationID)
+		if txID != ids.Empty {
+			SetResult("txID", txID.String())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to disable %s: %w", nodeID, err)
+		}
+
+		disabled, err := manager.L1.L1Validator(ctx, validationID)
+		if err != nil {
+			return err
+		}
+		if disabled.Balance != 0 {
+			return fmt.Errorf("tx %s was accepted but %s still has a balance of %s AVAX", txID, nodeID, formatAvax(disabled.Balance))
+		}
+		log.Printf("✅ Disabled %s in tx %s, refunded %s AVAX to %s\n", nodeID, txID, formatAvax(validator.Balance), refundOwner)
+
+		fmt.Println("The validator manager still has the validation active. Once the L1 is back:")
+		fmt.Println("  go run . reconcile                         # reports it as deactivated-on-p-chain")
+		fmt.Printf("  go run . remove-poa-validator %s   # ends the validation in the contract\n", nodeID)
+		fmt.Printf("To reactivate it instead: go run . balance top-up %s <AVAX>\n", nodeID)
+		return nil
+	},
+}
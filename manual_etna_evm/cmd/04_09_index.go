@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	indexStartHeight uint64
+	indexSyncFirst   bool
+	indexSince       time.Duration
+	indexUntil       time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	indexCmd.AddCommand(indexSyncCmd)
+	indexCmd.AddCommand(indexHistoryCmd)
+	indexCmd.AddCommand(indexTimelineCmd)
+
+	indexSyncCmd.Flags().Uint64Var(&indexStartHeight, "p-chain-from", 0, "P-chain height a new index starts from, 0 for the first block after the L1 genesis")
+	for _, queryCmd := range []*cobra.Command{indexHistoryCmd, indexTimelineCmd} {
+		queryCmd.Flags().BoolVar(&indexSyncFirst, "sync", false, "Sync the index before the query, needs the L1 and the P-chain")
+	}
+	indexTimelineCmd.Flags().DurationVar(&indexSince, "since", 0, "Only entries of the last duration, e.g. 24h, 0 for all")
+	indexTimelineCmd.Flags().DurationVar(&indexUntil, "until", 0, "Only entries older than the duration, e.g. 1h, 0 up to now")
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Index the history of the L1 validators and query it",
+	Long: `Keep the history of every validation in data/validator_index.db: the events of the
+validator manager, the warp messages it sent, and the P-chain txs about its validations
+(conversion, RegisterL1Validator, SetL1ValidatorWeight, IncreaseL1ValidatorBalance and
+DisableL1Validator). Syncs are incremental, the queries read the database alone and work while
+the L1 is down.`,
+}
+
+var indexSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Add the L1 blocks and P-chain blocks since the last sync to the index",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🗂️ Syncing validator index")
+
+		index, err := openValidatorIndex(cmd.Context(), true)
+		if err != nil {
+			return err
+		}
+		defer index.Close()
+		status, err := index.Status()
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ Index has %d entries of %d validations, next L1 block %d, next P-chain height %d\n", status.Entries, status.Validations, status.NextBlock, status.NextHeight)
+		SetResult("status", status)
+		return nil
+	},
+}
+
+var indexHistoryCmd = &cobra.Command{
+	Use:   "history <NodeID|validationID>",
+	Short: "Print the indexed history of a validation, or of every validation of a node",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🗂️ Printing validator history")
+
+		index, err := openValidatorIndex(cmd.Context(), indexSyncFirst)
+		if err != nil {
+			return err
+		}
+		defer index.Close()
+
+		var validationIDs []ids.ID
+		if nodeID, err := ids.NodeIDFromString(args[0]); err == nil {
+			validationIDs, err = index.NodeValidations(nodeID)
+			if err != nil {
+				return err
+			}
+			if len(validationIDs) == 0 {
+				return fmt.Errorf("no indexed validation of %s, sync with go run . index sync: %w", nodeID, ErrValidatorNotFound)
+			}
+		} else {
+			validationID, err := ids.FromString(args[0])
+			if err != nil {
+				return WithErrorCode(ErrCodeUsage, fmt.Errorf("%q is neither a NodeID nor a validation ID", args[0]))
+			}
+			validationIDs = []ids.ID{validationID}
+		}
+
+		SetResult("validations", []any{})
+		for _, validationID := range validationIDs {
+			entries, err := index.History(validationID)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return fmt.Errorf("no indexed history of validation %s, sync with go run . index sync: %w", validationID, ErrValidatorNotFound)
+			}
+			fmt.Printf("Validation %s:\n", validationID)
+			table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(table, "TIME\tCHAIN\tHEIGHT\tKIND\tDETAILS\tTX")
+			for _, entry := range entries {
+				fmt.Fprintf(table, "%s\t%s\t%d\t%s\t%s\t%s\n", entry.Time.UTC().Format(time.RFC3339), entry.Chain, entry.Height, entry.Kind, formatIndexFields(entry), entry.TxID)
+			}
+			if err := table.Flush(); err != nil {
+				return err
+			}
+			AppendResult("validations", map[string]any{
+				"validationID": validationID.String(),
+				"entries":      entries,
+			})
+		}
+		return nil
+	},
+}
+
+var indexTimelineCmd = &cobra.Command{
+	Use:   "timeline",
+	Short: "Print the indexed entries of every validation in time order",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🗂️ Printing validator timeline")
+
+		index, err := openValidatorIndex(cmd.Context(), indexSyncFirst)
+		if err != nil {
+			return err
+		}
+		defer index.Close()
+
+		var since, until time.Time
+		if indexSince > 0 {
+			since = time.Now().Add(-indexSince)
+		}
+		if indexUntil > 0 {
+			until = time.Now().Add(-indexUntil)
+		}
+		entries, err := index.Timeline(since, until)
+		if err != nil {
+			return err
+		}
+		nodes, err := index.ValidationNodes()
+		if err != nil {
+			return err
+		}
+
+		SetResult("entries", []any{})
+		table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "TIME\tCHAIN\tNODE ID\tKIND\tDETAILS\tVALIDATION ID")
+		for _, entry := range entries {
+			node := "-"
+			if nodeID, ok := nodes[entry.ValidationID]; ok {
+				node = nodeID.String()
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n", entry.Time.UTC().Format(time.RFC3339), entry.Chain, node, entry.Kind, formatIndexFields(entry), entry.ValidationID)
+			AppendResult("entries", entry)
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			log.Println("No indexed entries, sync with go run . index sync")
+		}
+		return nil
+	},
+}
+
+// openValidatorIndex opens the index of the data folder, synced with the L1
+// and the P-chain first if sync is set. The caller closes it.
+func openValidatorIndex(ctx context.Context, sync bool) (*l1.ValidatorIndex, error) {
+	index, err := l1.OpenValidatorIndex(helpers.ValidatorIndexPath)
+	if err != nil {
+		return nil, err
+	}
+	index.StartHeight = indexStartHeight
+	if !sync {
+		return index, nil
+	}
+	manager, err := GetValidatorManager()
+	if err == nil {
+		err = manager.SyncValidatorIndex(ctx, index)
+	}
+	if err != nil {
+		index.Close()
+		return nil, fmt.Errorf("failed to sync validator index: %w", err)
+	}
+	return index, nil
+}
+
+// formatIndexFields is the fields of the entry as sorted key=value pairs
+func formatIndexFields(entry l1.IndexEntry) string {
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys)+1)
+	if entry.NodeID != "" {
+		pairs = append(pairs, "node="+entry.NodeID)
+	}
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, entry.Fields[key]))
+	}
+	if len(pairs) == 0 {
+		return "-"
+	}
+	return strings.Join(pairs, " ")
+}
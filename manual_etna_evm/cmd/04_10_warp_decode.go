@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	warpVerify bool
+	warpSubnet string
+)
+
+func init() {
+	rootCmd.AddCommand(warpCmd)
+	warpCmd.AddCommand(warpDecodeCmd)
+	warpDecodeCmd.Flags().BoolVar(&warpVerify, "verify", false, "Check the signature against the current validator set on the P-chain")
+	warpDecodeCmd.Flags().StringVar(&warpSubnet, "subnet", "", "Subnet whose validators signed, defaults to the subnet of the source chain, or the L1 of the data folder for P-chain messages")
+}
+
+var warpCmd = &cobra.Command{
+	Use:   "warp",
+	Short: "Inspect warp messages",
+}
+
+var warpDecodeCmd = &cobra.Command{
+	Use:   "decode <hex|file|->",
+	Short: "Decode a signed or unsigned warp message and its ACP-77 payload",
+	Long: `Decode a warp message given as hex, with or without 0x, or as a file holding it in hex or
+raw bytes, - reads stdin. Signed and unsigned messages are accepted. The AddressedCall payload
+is decoded as RegisterL1Validator, L1ValidatorRegistration, L1ValidatorWeight,
+SubnetToL1Conversion or ValidationUptime, with the validation ID it is about.
+
+With --verify the signature is checked like a receiving chain would, against the validator set
+of the signing subnet at the current P-chain height and a 67% quorum.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		PrintHeader("🔎 Decoding warp message")
+
+		messageBytes, err := readWarpMessage(args[0])
+		if err != nil {
+			return err
+		}
+		decoded, err := l1.DecodeWarpMessage(messageBytes)
+		if err != nil {
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("failed to decode warp message: %w", err))
+		}
+		printWarpMessage(decoded)
+
+		if !warpVerify {
+			return nil
+		}
+		if decoded.Signature == nil {
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("--verify needs a signed message"))
+		}
+		network, err := GetL1Network()
+		if err != nil {
+			return fmt.Errorf("failed to get network: %w", err)
+		}
+		subnetID, err := warpSigningSubnet(cmd, network, decoded.Unsigned.SourceChainID)
+		if err != nil {
+			return err
+		}
+		rpcCtx, cancel := RPCContext(cmd.Context())
+		defer cancel()
+		status, err := network.VerifyWarpSignature(rpcCtx, decoded, subnetID)
+		if err != nil {
+			return fmt.Errorf("failed to verify signature: %w", err)
+		}
+		SetResult("signatureStatus", status)
+		if status.Valid {
+			fmt.Printf("Signature status: ✅ valid, %d of %d weight of subnet %s at P-chain height %d\n", status.SignedWeight, status.TotalWeight, subnetID, status.Height)
+		} else {
+			fmt.Printf("Signature status: ❌ invalid, %s (%d of %d weight of subnet %s at P-chain height %d)\n", status.Reason, status.SignedWeight, status.TotalWeight, subnetID, status.Height)
+		}
+		return nil
+	},
+}
+
+// readWarpMessage takes the message from the argument as hex, from a file
+// holding hex or raw bytes, or from stdin for -
+func readWarpMessage(arg string) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch info, statErr := os.Stat(arg); {
+	case arg == "-":
+		data, err = io.ReadAll(os.Stdin)
+	case statErr == nil && !info.IsDir():
+		data, err = os.ReadFile(arg)
+	default:
+		messageBytes, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(arg), "0x"))
+		if err != nil {
+			return nil, WithErrorCode(ErrCodeUsage, fmt.Errorf("%q is neither hex nor a file: %w", arg, err))
+		}
+		return messageBytes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", arg, err)
+	}
+	if messageBytes, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x")); err == nil {
+		return messageBytes, nil
+	}
+	return data, nil
+}
+
+// warpSigningSubnet is the subnet of --subnet, of the source chain, or of the
+// data folder for messages of the P-chain
+func warpSigningSubnet(cmd *cobra.Command, network l1.Network, sourceChainID ids.ID) (ids.ID, error) {
+	if warpSubnet != "" {
+		subnetID, err := ids.FromString(warpSubnet)
+		if err != nil {
+			return ids.Empty, WithErrorCode(ErrCodeUsage, fmt.Errorf("invalid --subnet %q: %w", warpSubnet, err))
+		}
+		return subnetID, nil
+	}
+	if sourceChainID == constants.PlatformChainID {
+		subnetID, err := helpers.LoadId(helpers.SubnetIdPath)
+		if err != nil {
+			return ids.Empty, WithErrorCode(ErrCodeUsage, fmt.Errorf("P-chain messages are verified by the receiving L1, pass its subnet with --subnet: %w", err))
+		}
+		return subnetID, nil
+	}
+	rpcCtx, cancel := RPCContext(cmd.Context())
+	defer cancel()
+	return network.SigningSubnetID(rpcCtx, sourceChainID)
+}
+
+// printWarpMessage prints the message and records it as the result
+func printWarpMessage(decoded *l1.DecodedWarpMessage) {
+	unsigned := decoded.Unsigned
+	source := unsigned.SourceChainID.String()
+	if unsigned.SourceChainID == constants.PlatformChainID {
+		source += " (P-chain)"
+	}
+	fmt.Printf("Message ID: %s\n", unsigned.ID())
+	fmt.Printf("Network ID: %d (%s)\n", unsigned.NetworkID, constants.NetworkName(unsigned.NetworkID))
+	fmt.Printf("Source chain: %s\n", source)
+	SetResult("messageID", unsigned.ID().String())
+	SetResult("networkID", unsigned.NetworkID)
+	SetResult("sourceChainID", unsigned.SourceChainID.String())
+	SetResult("payloadType", decoded.PayloadType)
+
+	switch decoded.PayloadType {
+	case l1.WarpPayloadHash:
+		fmt.Printf("Payload: Hash %s\n", decoded.Hash)
+		SetResult("hash", decoded.Hash.String())
+	case l1.WarpPayloadAddressedCall:
+		sourceAddress := "none"
+		if len(decoded.SourceAddress) == common.AddressLength {
+			sourceAddress = common.BytesToAddress(decoded.SourceAddress).Hex()
+		} else if len(decoded.SourceAddress) > 0 {
+			sourceAddress = fmt.Sprintf("%x", decoded.SourceAddress)
+		}
+		fmt.Printf("Payload: AddressedCall from %s\n", sourceAddress)
+		SetResult("sourceAddress", sourceAddress)
+		if decoded.MessageType == "" {
+			fmt.Println("Message: not an ACP-77 message")
+			break
+		}
+		fmt.Printf("Message: %s\n", decoded.MessageType)
+		fields := make(map[string]any, len(decoded.Fields))
+		for _, field := range decoded.Fields {
+			fmt.Printf("  %s: %s\n", field.Name, formatWarpField(field.Value))
+			fields[field.Name] = field.Value
+		}
+		SetResult("messageType", decoded.MessageType)
+		SetResult("fields", fields)
+		if decoded.ValidationID != ids.Empty {
+			fmt.Printf("Validation ID: %s\n", decoded.ValidationID)
+			SetResult("validationID", decoded.ValidationID.String())
+		}
+	}
+
+	SetResult("signed", decoded.Signature != nil)
+	if decoded.Signature == nil {
+		fmt.Println("Signature: none, unsigned message")
+		return
+	}
+	bitset := fmt.Sprintf("%x", decoded.Signature.Signers)
+	if decoded.SignersErr != nil {
+		fmt.Printf("Signature: ❌ signers bitset %s does not parse: %s\n", bitset, decoded.SignersErr)
+	} else {
+		fmt.Printf("Signature: %d signers %v, bitset %s\n", len(decoded.Signers), decoded.Signers, bitset)
+	}
+	SetResult("signers", decoded.Signers)
+	SetResult("signersBitset", bitset)
+	SetResult("signature", fmt.Sprintf("%x", decoded.Signature.Signature[:]))
+}
+
+// formatWarpField prints owners as threshold and addresses
+func formatWarpField(value any) string {
+	owner, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	addresses, _ := owner["addresses"].([]string)
+	return fmt.Sprintf("%v of [%s]", owner["threshold"], strings.Join(addresses, ", "))
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+)
+
+//go:embed node/chain_configs/*.json
+var chainConfigProfilesFS embed.FS
+
+const (
+	defaultChainConfigProfile = "validator"
+	// debugChainConfigProfile enables every debug API, for local networks only
+	debugChainConfigProfile = "debug"
+)
+
+// ChainConfigSelection is the chain config profile picked for the managed
+// nodes, saved in the workspace so launch-node and add-poa-validator reuse it
+type ChainConfigSelection struct {
+	Profile   string                 `json:"profile"`
+	Overrides map[string]interface{} `json:"overrides,omitempty"`
+}
+
+func ChainConfigProfiles() ([]string, error) {
+	entries, err := chainConfigProfilesFS.ReadDir("node/chain_configs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chain config profiles: %w", err)
+	}
+	profiles := []string{}
+	for _, entry := range entries {
+		profiles = append(profiles, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+func LoadChainConfigSelection() (ChainConfigSelection, error) {
+	exists, err := helpers.FileExists(helpers.ChainConfigSelectionPath)
+	if err != nil {
+		return ChainConfigSelection{}, err
+	}
+	if !exists {
+		return ChainConfigSelection{Profile: defaultChainConfigProfile}, nil
+	}
+
+	selectionBytes, err := helpers.LoadBytes(helpers.ChainConfigSelectionPath)
+	if err != nil {
+		return ChainConfigSelection{}, err
+	}
+	var selection ChainConfigSelection
+	if err := json.Unmarshal(selectionBytes, &selection); err != nil {
+		return ChainConfigSelection{}, fmt.Errorf("failed to parse %s: %w", helpers.ChainConfigSelectionPath, err)
+	}
+	return selection, nil
+}
+
+func SaveChainConfigSelection(selection ChainConfigSelection) error {
+	selectionBytes, err := json.MarshalIndent(selection, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal chain config selection: %w", err)
+	}
+	return helpers.SaveBytes(helpers.ChainConfigSelectionPath, selectionBytes)
+}
+
+// RenderChainConfig returns the profile JSON with the overrides applied on top
+func RenderChainConfig(selection ChainConfigSelection) ([]byte, error) {
+	profileBytes, err := chainConfigProfilesFS.ReadFile(fmt.Sprintf("node/chain_configs/%s.json", selection.Profile))
+	if err != nil {
+		profiles, _ := ChainConfigProfiles()
+		return nil, fmt.Errorf("unknown chain config profile %q, available profiles: %s", selection.Profile, strings.Join(profiles, ", "))
+	}
+
+	chainConfig := map[string]interface{}{}
+	if err := json.Unmarshal(profileBytes, &chainConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse chain config profile %s: %w", selection.Profile, err)
+	}
+	for key, value := range selection.Overrides {
+		chainConfig[key] = value
+	}
+
+	return json.MarshalIndent(chainConfig, "", "  ")
+}
+
+// WriteChainConfig writes <chainConfigDir>/<chainID>/config.json using the
+// saved selection
+func WriteChainConfig(chainConfigDir string, chainID ids.ID) error {
+	selection, err := LoadChainConfigSelection()
+	if err != nil {
+		return fmt.Errorf("failed to load chain config selection: %w", err)
+	}
+	chainConfig, err := RenderChainConfig(selection)
+	if err != nil {
+		return err
+	}
+	return helpers.SaveBytes(filepath.Join(chainConfigDir, chainID.String(), "config.json"), chainConfig)
+}
+
+// warnDebugChainConfig warns when the debug profile is picked for a node that
+// joins a public network, its debug APIs are open to anyone reaching the RPC
+func warnDebugChainConfig(selection ChainConfigSelection) error {
+	if selection.Profile != debugChainConfigProfile {
+		return nil
+	}
+	network, err := LoadNetworkSelection()
+	if err != nil {
+		return err
+	}
+	if !network.IsLocal() {
+		log.Printf("⚠️ The %s profile enables every debug API, use it on local networks only, e.g. --profile %s on %s\n", debugChainConfigProfile, defaultChainConfigProfile, network.Name)
+	}
+	return nil
+}
+
+// ParseChainConfigOverride parses key=value, the value is decoded as JSON
+// when possible so numbers, booleans and lists keep their type
+func ParseChainConfigOverride(override string) (string, interface{}, error) {
+	key, rawValue, found := strings.Cut(override, "=")
+	if !found || key == "" {
+		return "", nil, fmt.Errorf("invalid override %q, expected key=value", override)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(rawValue), &value); err != nil {
+		value = rawValue
+	}
+	return key, value, nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	commandTimeout   time.Duration
+	txTimeout        time.Duration
+	nodeReadyTimeout time.Duration
+	rpcTimeout       time.Duration
+
+	cancelCommandTimeout context.CancelFunc = func() {}
+)
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.DurationVar(&commandTimeout, "timeout", 0, "Bound the whole command, e.g. 30m, 0 for no limit")
+	flags.DurationVar(&txTimeout, "tx-timeout", l1.PChainTxTimeout, "Bound waiting for a tx to be accepted and for the validator set to reflect it")
+	flags.DurationVar(&nodeReadyTimeout, "node-ready-timeout", NodeReadyTimeout, "Bound waiting for a node to bootstrap and serve the L1")
+	flags.DurationVar(&rpcTimeout, "rpc-timeout", l1.DefaultRPCTimeout, "Bound single RPC calls")
+}
+
+// signalContext is canceled on the first SIGINT or SIGTERM. The running step
+// then stops at the next point where the data folder is consistent, a second
+// signal exits right away.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+		case <-ctx.Done():
+			return
+		}
+		// Back to the default handling, which exits
+		signal.Stop(signals)
+		log.Println("⚠️ Interrupted, stopping after the current step. Interrupt again to exit right away")
+		cancel()
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// applyCommandTimeout bounds the context of the command by --timeout
+func applyCommandTimeout(cmd *cobra.Command) {
+	if commandTimeout <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), commandTimeout)
+	cmd.SetContext(ctx)
+	cancelCommandTimeout = cancel
+}
+
+// RPCContext bounds a single RPC call by --rpc-timeout
+func RPCContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, rpcTimeout)
+}
+
+// TxContext bounds waiting for a tx by --tx-timeout
+func TxContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, txTimeout)
+}
+
+// NodeReadyContext bounds waiting for a node by --node-ready-timeout
+func NodeReadyContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, nodeReadyTimeout)
+}
+
+// IsInterrupted reports whether err comes from the command being canceled or
+// running into a timeout, as opposed to a failure of the step itself
+func IsInterrupted(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
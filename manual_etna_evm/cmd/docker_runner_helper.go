@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/template"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers/docker"
+)
+
+// NodeContainerLabel marks containers created by this tool, the value is the node name
+const NodeContainerLabel = "etna-devnet.node"
+
+// ContainerConfig mounts the data folder at /data and the credentials over
+// the default staking folder when they live somewhere else
+func (n ManagedNode) ContainerConfig(launch NodeLaunchConfig) (docker.ContainerConfig, error) {
+	dataFolder, err := filepath.Abs("data")
+	if err != nil {
+		return docker.ContainerConfig{}, fmt.Errorf("failed to resolve data folder: %w", err)
+	}
+	binds := []string{dataFolder + ":/data"}
+
+	if filepath.Clean(n.CredsFolder) != filepath.Clean(n.StakingFolder()) {
+		credsFolder, err := filepath.Abs(n.CredsFolder)
+		if err != nil {
+			return docker.ContainerConfig{}, fmt.Errorf("failed to resolve creds folder: %w", err)
+		}
+		binds = append(binds, credsFolder+":"+ContainerPath(n.StakingFolder())+":ro")
+	}
+	if launch.Network.GenesisFile != "" {
+		binds = append(binds, launch.Network.GenesisFile+":"+containerGenesisPath+":ro")
+	}
+
+	return docker.ContainerConfig{
+		Image:  config.NodeImage,
+		User:   fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		Env:    n.Env(launch),
+		Labels: map[string]string{NodeContainerLabel: n.Name},
+		HostConfig: docker.HostConfig{
+			NetworkMode:   "host",
+			Binds:         binds,
+			RestartPolicy: docker.RestartPolicy{Name: "unless-stopped"},
+		},
+	}, nil
+}
+
+var composeTemplate = template.Must(template.New("compose").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+}).Parse(`# Generated by "nodes up" from the workspace, do not edit by hand.
+# "nodes up" creates the same containers through the Docker Engine API.
+name: etna-devnet
+services:
+{{- range .Services }}
+  {{ .Name }}:
+    container_name: {{ .Name }}
+    image: {{ quote .Config.Image }}
+    network_mode: {{ .Config.HostConfig.NetworkMode }}
+    user: {{ quote .Config.User }}
+    restart: {{ .Config.HostConfig.RestartPolicy.Name }}
+    labels:
+{{- range $key, $value := .Config.Labels }}
+      {{ quote $key }}: {{ quote $value }}
+{{- end }}
+    volumes:
+{{- range .Config.HostConfig.Binds }}
+      - {{ quote . }}
+{{- end }}
+    environment:
+{{- range .Config.Env }}
+      - {{ quote . }}
+{{- end }}
+{{- end }}
+`))
+
+// WriteNodesCompose renders a compose project with the containers of every
+// managed node to data/docker-compose.yml, for use with docker compose
+// directly or as a record of how the nodes run
+func WriteNodesCompose(nodes []ManagedNode, launch NodeLaunchConfig) error {
+	type service struct {
+		Name   string
+		Config docker.ContainerConfig
+	}
+	services := make([]service, 0, len(nodes))
+	for _, node := range nodes {
+		containerConfig, err := node.ContainerConfig(launch)
+		if err != nil {
+			return err
+		}
+		services = append(services, service{Name: node.Name, Config: containerConfig})
+	}
+
+	var compose bytes.Buffer
+	if err := composeTemplate.Execute(&compose, map[string]any{"Services": services}); err != nil {
+		return fmt.Errorf("failed to render compose file: %w", err)
+	}
+	return helpers.SaveBytes(helpers.NodesComposePath, compose.Bytes())
+}
+
+// dockerNodeRunner runs every node in its own container of config.NodeImage
+type dockerNodeRunner struct {
+	client *docker.Client
+}
+
+func (r *dockerNodeRunner) Name() string {
+	return DockerRunner
+}
+
+// Start leaves a running container created by this tool alone unless
+// recreate is set, anything else with the same name is replaced so config
+// changes are picked up
+func (r *dockerNodeRunner) Start(ctx context.Context, node ManagedNode, launch NodeLaunchConfig, recreate bool) (bool, error) {
+	info, err := r.client.InspectContainer(ctx, node.Name)
+	if err != nil && !errors.Is(err, docker.ErrNotFound) {
+		return false, err
+	}
+	if info != nil {
+		if info.State.Running && info.Config.Labels[NodeContainerLabel] == node.Name && !recreate {
+			return false, nil
+		}
+		if err := r.client.RemoveContainer(ctx, node.Name, true); err != nil && !errors.Is(err, docker.ErrNotFound) {
+			return false, err
+		}
+	}
+
+	containerConfig, err := node.ContainerConfig(launch)
+	if err != nil {
+		return false, err
+	}
+
+	if err := EnsureImage(ctx, r.client, containerConfig.Image); err != nil {
+		return false, err
+	}
+	if _, err := r.client.CreateContainer(ctx, node.Name, containerConfig); err != nil {
+		return false, err
+	}
+	if err := r.client.StartContainer(ctx, node.Name); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *dockerNodeRunner) Stop(ctx context.Context, name string) error {
+	if err := r.client.StopContainer(ctx, name, nodeStopTimeout); err != nil {
+		if errors.Is(err, docker.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := r.client.RemoveContainer(ctx, name, true); err != nil && !errors.Is(err, docker.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *dockerNodeRunner) Restart(ctx context.Context, name string) (bool, error) {
+	info, err := r.client.InspectContainer(ctx, name)
+	if errors.Is(err, docker.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !info.State.Running {
+		return false, nil
+	}
+	if err := r.client.RestartContainer(ctx, name, nodeStopTimeout); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *dockerNodeRunner) Status(ctx context.Context, name string) (NodeState, error) {
+	info, err := r.client.InspectContainer(ctx, name)
+	if errors.Is(err, docker.ErrNotFound) {
+		return NodeState{State: "missing"}, nil
+	}
+	if err != nil {
+		return NodeState{}, err
+	}
+
+	state := NodeState{
+		State:        info.State.Status,
+		ExitCode:     info.State.ExitCode,
+		RestartCount: info.RestartCount,
+		Error:        info.State.Error,
+	}
+	if info.State.Health != nil {
+		state.Health = info.State.Health.Status
+	}
+	if info.State.OOMKilled && state.Error == "" {
+		state.Error = "killed by the OOM killer"
+	}
+	return state, nil
+}
+
+func (r *dockerNodeRunner) Logs(ctx context.Context, name string, options NodeLogsOptions, stdout io.Writer, stderr io.Writer) error {
+	return r.client.ContainerLogs(ctx, name, docker.LogsOptions{Follow: options.Follow, Tail: options.Tail}, stdout, stderr)
+}
+
+func (r *dockerNodeRunner) List(ctx context.Context) ([]string, error) {
+	containers, err := r.client.ListContainers(ctx, NodeContainerLabel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node containers: %w", err)
+	}
+	names := []string{}
+	for _, container := range containers {
+		names = append(names, container.Name())
+	}
+	return names, nil
+}
+
+// EnsureImage pulls the image if it is not available locally
+func EnsureImage(ctx context.Context, client *docker.Client, image string) error {
+	exists, err := client.ImageExists(ctx, image)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	log.Printf("Pulling %s\n", image)
+	return client.PullImage(ctx, image)
+}
+
+// NewDockerClient connects to the daemon and fails early with a readable
+// error if it is not reachable
+func NewDockerClient(ctx context.Context) (*docker.Client, error) {
+	client, err := docker.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to docker, is it running and is the socket accessible? %w", err)
+	}
+	return client, nil
+}
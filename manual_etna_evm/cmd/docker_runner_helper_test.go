@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers/docker"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers/docker/dockertest"
+)
+
+func TestDockerNodeRunner(t *testing.T) {
+	ctx := context.Background()
+	engine := dockertest.NewEngine(t)
+	client, err := docker.NewClientWithHost(engine.Host)
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	var runner NodeRunner = &dockerNodeRunner{client: client}
+	if runner.Name() != DockerRunner {
+		t.Errorf("name = %s, want %s", runner.Name(), DockerRunner)
+	}
+
+	node := newManagedNode(1, "data/add_validator_1/", "data/add_validator_1/chains")
+	launch := NodeLaunchConfig{
+		SubnetID: "2u3hGpKAXL2LjpKdK7MnCqFZ1LWEm2njfSfRhBYjzKrfdCX4Ey",
+		Network:  NodeNetworkConfig{NetworkID: "fuji", PublicIP: "127.0.0.1"},
+	}
+
+	started, err := runner.Start(ctx, node, launch, false)
+	if err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+	if !started {
+		t.Error("start of a new node returned false")
+	}
+	if !engine.HasImage(config.NodeImage) {
+		t.Errorf("image %s was not pulled", config.NodeImage)
+	}
+	request, ok := engine.LastRequest(http.MethodPost, "/containers/create")
+	if !ok {
+		t.Fatal("no create request")
+	}
+	var created docker.ContainerConfig
+	if err := json.Unmarshal(request.Body, &created); err != nil {
+		t.Fatalf("create body is not a container config: %s", err)
+	}
+	dataFolder, _ := filepath.Abs("data")
+	credsFolder, _ := filepath.Abs("data/add_validator_1")
+	wantBinds := []string{dataFolder + ":/data", credsFolder + ":/data/node1/staking:ro"}
+	if created.Image != config.NodeImage || created.Labels[NodeContainerLabel] != "node1" || !slices.Equal(created.HostConfig.Binds, wantBinds) {
+		t.Errorf("unexpected create body %s", request.Body)
+	}
+	if !slices.Contains(created.Env, "AVALANCHEGO_TRACK_SUBNETS="+launch.SubnetID) || !slices.Contains(created.Env, "AVALANCHEGO_DATA_DIR=/data/node1") {
+		t.Errorf("env = %v, want the subnet and the data dir as seen from the container", created.Env)
+	}
+
+	started, err = runner.Start(ctx, node, launch, false)
+	if err != nil || started {
+		t.Errorf("start of a running node = %v, %v, want false", started, err)
+	}
+	requests := len(engine.Requests())
+	started, err = runner.Start(ctx, node, launch, true)
+	if err != nil || !started {
+		t.Errorf("recreate = %v, %v, want true", started, err)
+	}
+	if _, ok := engine.LastRequest(http.MethodDelete, "/containers/node1"); !ok || len(engine.Requests()) == requests {
+		t.Error("recreate did not remove the old container")
+	}
+
+	engine.Container(t, "node1", func(c *dockertest.Container) {
+		c.Health = "starting"
+		c.RestartCount = 1
+		c.Logs = []dockertest.LogFrame{{Stream: 1, Data: "out\n"}, {Stream: 2, Data: "err\n"}}
+	})
+	state, err := runner.Status(ctx, "node1")
+	if err != nil {
+		t.Fatalf("failed to get status: %s", err)
+	}
+	if want := (NodeState{State: "running", Health: "starting", RestartCount: 1}); state != want {
+		t.Errorf("status = %+v, want %+v", state, want)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := runner.Logs(ctx, "node1", NodeLogsOptions{}, &stdout, &stderr); err != nil {
+		t.Fatalf("failed to read logs: %s", err)
+	}
+	if stdout.String() != "out\n" || stderr.String() != "err\n" {
+		t.Errorf("logs = %q, %q, want out and err apart", stdout.String(), stderr.String())
+	}
+
+	engine.AddContainer("unrelated", dockertest.Container{})
+	names, err := runner.List(ctx)
+	if err != nil {
+		t.Fatalf("failed to list: %s", err)
+	}
+	if !slices.Equal(names, []string{"node1"}) {
+		t.Errorf("list = %v, want node1", names)
+	}
+
+	restarted, err := runner.Restart(ctx, "node1")
+	if err != nil || !restarted {
+		t.Errorf("restart = %v, %v, want true", restarted, err)
+	}
+
+	engine.Container(t, "node1", func(c *dockertest.Container) {
+		c.Running = false
+		c.ExitCode = 137
+		c.OOMKilled = true
+	})
+	state, err = runner.Status(ctx, "node1")
+	if err != nil {
+		t.Fatalf("failed to get status: %s", err)
+	}
+	if state.State != "exited" || state.ExitCode != 137 || state.Error != "killed by the OOM killer" {
+		t.Errorf("status of a killed node = %+v", state)
+	}
+	restarted, err = runner.Restart(ctx, "node1")
+	if err != nil || restarted {
+		t.Errorf("restart of a stopped node = %v, %v, want false", restarted, err)
+	}
+
+	if err := runner.Stop(ctx, "node1"); err != nil {
+		t.Fatalf("failed to stop: %s", err)
+	}
+	if engine.Exists("node1") {
+		t.Error("stop did not remove the container")
+	}
+	if err := runner.Stop(ctx, "node1"); err != nil {
+		t.Errorf("stop of a missing node failed: %s", err)
+	}
+	state, err = runner.Status(ctx, "node1")
+	if err != nil || state.State != "missing" {
+		t.Errorf("status of a missing node = %+v, %v, want missing", state, err)
+	}
+	restarted, err = runner.Restart(ctx, "node1")
+	if err != nil || restarted {
+		t.Errorf("restart of a missing node = %v, %v, want false", restarted, err)
+	}
+}
+
+func TestWriteNodesCompose(t *testing.T) {
+	chdirTemp(t)
+	if err := GenerateCredsIfNotExists("data/add_validator_1/"); err != nil {
+		t.Fatal(err)
+	}
+	nodes := []ManagedNode{
+		newManagedNode(0, "data/node0/staking/", "data/chains"),
+		newManagedNode(1, "data/add_validator_1/", "data/add_validator_1/chains"),
+	}
+	launch := NodeLaunchConfig{
+		SubnetID: "2u3hGpKAXL2LjpKdK7MnCqFZ1LWEm2njfSfRhBYjzKrfdCX4Ey",
+		Network:  NodeNetworkConfig{NetworkID: "fuji", PublicIP: "127.0.0.1"},
+	}
+	if err := WriteNodesCompose(nodes, launch); err != nil {
+		t.Fatalf("failed to write compose file: %s", err)
+	}
+	compose, err := helpers.LoadText(helpers.NodesComposePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every line of the API runner's container config is in the project
+	for _, node := range nodes {
+		containerConfig, err := node.ContainerConfig(launch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			"  " + node.Name + ":\n    container_name: " + node.Name + "\n",
+			"image: " + strconv.Quote(containerConfig.Image),
+			`"` + NodeContainerLabel + `": "` + node.Name + `"`,
+		}
+		for _, bind := range containerConfig.HostConfig.Binds {
+			want = append(want, "- "+strconv.Quote(bind))
+		}
+		for _, env := range containerConfig.Env {
+			want = append(want, "- "+strconv.Quote(env))
+		}
+		for _, line := range want {
+			if !strings.Contains(compose, line) {
+				t.Errorf("compose file has no %q for %s:\n%s", line, node.Name, compose)
+			}
+		}
+	}
+	if !strings.Contains(compose, "network_mode: host") || !strings.Contains(compose, "restart: unless-stopped") {
+		t.Errorf("compose file does not run the nodes like the API runner:\n%s", compose)
+	}
+}
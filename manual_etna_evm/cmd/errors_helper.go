@@ -0,0 +1,17 @@
+package cmd
+
+import "github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+
+// The sentinel errors live in the l1 package, these keep errors.Is checks in
+// the CLI short
+var (
+	ErrTxDropped              = l1.ErrTxDropped
+	ErrTxAborted              = l1.ErrTxAborted
+	ErrNodeAlreadyRegistered  = l1.ErrNodeAlreadyRegistered
+	ErrInvalidValidatorStatus = l1.ErrInvalidValidatorStatus
+	ErrValidatorNotFound      = l1.ErrValidatorNotFound
+	ErrChurnLimitExceeded     = l1.ErrChurnLimitExceeded
+	ErrInsufficientBalance    = l1.ErrInsufficientBalance
+	ErrInvalidCredentials     = l1.ErrInvalidCredentials
+	ErrRegistrationNotExpired = l1.ErrRegistrationNotExpired
+)
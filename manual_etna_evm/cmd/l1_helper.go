@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Values of the --churn flag
+const (
+	ChurnWait   = "wait"
+	ChurnRefuse = "refuse"
+)
+
+var (
+	walletSnapshotMaxAge time.Duration
+	churnPolicy          string
+
+	// wallets is shared by the workspaces of the command, so the UTXOs of
+	// the owner key are synced at most once
+	wallets *l1.WalletService
+)
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&walletSnapshotMaxAge, "wallet-snapshot-max-age", l1.DefaultWalletSnapshotMaxAge, "Reuse the P-chain UTXOs saved by an earlier command if they are at most this old and no P-chain block spent one since, 0 always syncs")
+	rootCmd.PersistentFlags().StringVar(&churnPolicy, "churn", ChurnWait, fmt.Sprintf("When a validator weight does not fit the churn period of the contract, %s for the next period or %s", ChurnWait, ChurnRefuse))
+}
+
+// GetWorkspace is the l1 workspace of the selected network, signing with the
+// validator manager owner key and bounded by the timeout flags
+func GetWorkspace() (*l1.Workspace, error) {
+	network, err := GetL1Network()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network: %w", err)
+	}
+	key, err := helpers.LoadSecp256k1PrivateKey(helpers.ValidatorManagerOwnerKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load validator manager owner key: %w", err)
+	}
+	workspace := l1.NewWorkspace(network, key)
+	workspace.TxTimeout = txTimeout
+	workspace.RPCTimeout = rpcTimeout
+	if wallets == nil {
+		wallets = workspace.Wallets
+		wallets.SnapshotPath = helpers.WalletSnapshotPath
+		wallets.SnapshotMaxAge = walletSnapshotMaxAge
+	}
+	workspace.Wallets = wallets
+	return workspace, nil
+}
+
+// GetL1 is the L1 of the data folder, reached through node0
+func GetL1() (*l1.L1, error) {
+	workspace, err := GetWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	subnetID, err := helpers.LoadId(helpers.SubnetIdPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subnet ID: %w", err)
+	}
+	chainID, err := helpers.LoadId(helpers.ChainIdPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chain ID: %w", err)
+	}
+	node0 := newManagedNode(0, helpers.Node0KeysFolder, "data/chains")
+	return workspace.L1(subnetID, chainID, node0.URI()), nil
+}
+
+// GetValidatorManager is the validator manager proxy of the L1, with the warp
+// message index of the data folder and the churn policy of the flags
+func GetValidatorManager() (*l1.ValidatorManager, error) {
+	chain, err := GetL1()
+	if err != nil {
+		return nil, err
+	}
+	manager := chain.ValidatorManager(common.HexToAddress(config.ProxyContractAddress))
+	switch churnPolicy {
+	case ChurnWait:
+		manager.ChurnPolicy = l1.ChurnWait
+	case ChurnRefuse:
+		manager.ChurnPolicy = l1.ChurnRefuse
+	default:
+		return nil, WithErrorCode(ErrCodeUsage, fmt.Errorf("unknown churn policy %q, use %s or %s", churnPolicy, ChurnWait, ChurnRefuse))
+	}
+	manager.WarpIndex, err = l1.OpenWarpIndex(helpers.WarpIndexPath)
+	if err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
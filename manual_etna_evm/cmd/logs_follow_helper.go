@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/ethclient"
+	"github.com/ava-labs/subnet-evm/interfaces"
+)
+
+// errLogSinkFailed means --exec or --webhook kept refusing a log, following
+// stops so the log is not skipped
+var errLogSinkFailed = errors.New("log sink failed")
+
+const (
+	// logSinkAttempts bounds how often a log is handed to a sink before
+	// following stops
+	logSinkAttempts = 3
+	// logsReconnectDelay is the wait before connecting again to a node that
+	// went away
+	logsReconnectDelay = 5 * time.Second
+)
+
+// logsCursor is the position after the last log handled: every log of an
+// earlier block and the logs of Block before LogIndex
+type logsCursor struct {
+	Block    uint64 `json:"block"`
+	LogIndex uint   `json:"logIndex"`
+}
+
+func (c logsCursor) handled(vLog types.Log) bool {
+	return vLog.BlockNumber < c.Block || (vLog.BlockNumber == c.Block && vLog.Index < c.LogIndex)
+}
+
+// logsFollower prints the logs of a query as they arrive and keeps its
+// cursor in the data folder
+type logsFollower struct {
+	port      string
+	registry  *l1.EventRegistry
+	query     interfaces.FilterQuery
+	csvWriter *csv.Writer
+
+	cursor  logsCursor
+	started bool
+	count   int
+}
+
+// followContractLogs follows the logs of the query until ctx is done,
+// reconnecting to the node whenever it goes away
+func followContractLogs(ctx context.Context, port string, registry *l1.EventRegistry, query interfaces.FilterQuery, csvWriter *csv.Writer) error {
+	follower := &logsFollower{port: port, registry: registry, query: query, csvWriter: csvWriter}
+	if logsFromBlockSet {
+		follower.cursor, follower.started = logsCursor{Block: logsFromBlock}, true
+	} else {
+		cursor, found, err := loadLogsCursor()
+		if err != nil {
+			return err
+		}
+		follower.cursor, follower.started = cursor, found
+	}
+	if follower.started {
+		log.Printf("Following logs from block %d\n", follower.cursor.Block)
+	}
+
+	for {
+		err := follower.run(ctx)
+		if ctx.Err() != nil {
+			log.Printf("Stopped following after %d logs, next block %d\n", follower.count, follower.cursor.Block)
+			SetResult("handled", follower.count)
+			SetResult("cursor", follower.cursor)
+			return nil
+		}
+		if errors.Is(err, errLogSinkFailed) {
+			return err
+		}
+		log.Printf("⚠️ Lost the node: %s, reconnecting in %s\n", err, logsReconnectDelay)
+		select {
+		case <-ctx.Done():
+		case <-time.After(logsReconnectDelay):
+		}
+	}
+}
+
+// run follows the logs over one connection to the node. It first reads the
+// logs the cursor is behind on, then takes new ones from a websocket
+// subscription or by polling.
+func (f *logsFollower) run(ctx context.Context) error {
+	client, _, err := GetLocalEthClient(ctx, f.port)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Subscribed before catching up, so no log falls between the two. Logs
+	// both deliver are skipped by the cursor.
+	logs := make(chan types.Log, 256)
+	var subscription interfaces.Subscription
+	wsClient, err := f.dialWebsocket(ctx)
+	if err == nil {
+		defer wsClient.Close()
+		subscription, err = wsClient.SubscribeFilterLogs(ctx, f.query, logs)
+	}
+	if err != nil {
+		log.Printf("⚠️ No websocket log subscription (%s), polling every %s\n", err, logsPollInterval)
+	} else {
+		defer subscription.Unsubscribe()
+	}
+
+	if err := f.catchUp(ctx, client); err != nil {
+		return err
+	}
+	if subscription == nil {
+		return f.poll(ctx, client)
+	}
+	log.Println("Waiting for new logs")
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-subscription.Err():
+			return fmt.Errorf("log subscription ended: %w", err)
+		case vLog := <-logs:
+			// Removed logs are only sent on reorgs, which accepted blocks
+			// do not have
+			if vLog.Removed || f.cursor.handled(vLog) {
+				continue
+			}
+			if err := f.handle(ctx, vLog); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// dialWebsocket connects to the websocket endpoint of the L1 on the node
+func (f *logsFollower) dialWebsocket(ctx context.Context) (ethclient.Client, error) {
+	chainID, err := helpers.LoadId(helpers.ChainIdPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chain ID: %w", err)
+	}
+	rpcCtx, cancel := RPCContext(ctx)
+	defer cancel()
+	return ethclient.DialContext(rpcCtx, fmt.Sprintf("ws://127.0.0.1:%s/ext/bc/%s/ws", f.port, chainID))
+}
+
+// catchUp handles the logs from the cursor up to the latest block. Without
+// a cursor it starts after the latest block.
+func (f *logsFollower) catchUp(ctx context.Context, client ethclient.Client) error {
+	rpcCtx, cancel := RPCContext(ctx)
+	latest, err := client.BlockNumber(rpcCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %w", err)
+	}
+	if !f.started {
+		f.cursor, f.started = logsCursor{Block: latest + 1}, true
+		log.Printf("Following logs from block %d\n", f.cursor.Block)
+		return saveLogsCursor(f.cursor)
+	}
+	if f.cursor.Block > latest {
+		return nil
+	}
+	logs, err := filterLogsInRange(ctx, client, f.query, f.cursor.Block, latest)
+	if err != nil {
+		return err
+	}
+	for _, vLog := range logs {
+		if f.cursor.handled(vLog) {
+			continue
+		}
+		if err := f.handle(ctx, vLog); err != nil {
+			return err
+		}
+	}
+	f.cursor = logsCursor{Block: latest + 1}
+	return saveLogsCursor(f.cursor)
+}
+
+// poll catches up every --poll-interval
+func (f *logsFollower) poll(ctx context.Context, client ethclient.Client) error {
+	ticker := time.NewTicker(logsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := f.catchUp(ctx, client); err != nil {
+			return err
+		}
+	}
+}
+
+// handle prints the log, hands it to the sinks and moves the cursor past it
+func (f *logsFollower) handle(ctx context.Context, vLog types.Log) error {
+	result, err := printContractLog(f.registry, vLog, f.csvWriter)
+	if err != nil {
+		return err
+	}
+	if f.csvWriter != nil {
+		f.csvWriter.Flush()
+		if err := f.csvWriter.Error(); err != nil {
+			return err
+		}
+	}
+	if logsExec != "" || logsWebhook != "" {
+		payload, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		if err := deliverLog(ctx, payload); err != nil {
+			return fmt.Errorf("%w: log %d of block %d: %w", errLogSinkFailed, vLog.Index, vLog.BlockNumber, err)
+		}
+	}
+	f.count++
+	f.cursor = logsCursor{Block: vLog.BlockNumber, LogIndex: vLog.Index + 1}
+	return saveLogsCursor(f.cursor)
+}
+
+// deliverLog hands the log to --exec and --webhook, retrying each a few
+// times
+func deliverLog(ctx context.Context, payload []byte) error {
+	sinks := []struct {
+		enabled bool
+		deliver func(context.Context, []byte) error
+	}{
+		{logsExec != "", execLogSink},
+		{logsWebhook != "", webhookLogSink},
+	}
+	for _, sink := range sinks {
+		if !sink.enabled {
+			continue
+		}
+		var err error
+		for attempt := 1; attempt <= logSinkAttempts; attempt++ {
+			if err = sink.deliver(ctx, payload); err == nil {
+				break
+			}
+			log.Printf("⚠️ Attempt %d of %d to deliver log failed: %s\n", attempt, logSinkAttempts, err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execLogSink runs --exec with the log on stdin
+func execLogSink(ctx context.Context, payload []byte) error {
+	command := exec.CommandContext(ctx, "sh", "-c", logsExec)
+	command.Stdin = bytes.NewReader(payload)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("failed to run %q: %w", logsExec, err)
+	}
+	return nil
+}
+
+// webhookLogSink posts the log to --webhook, any status but 2xx fails
+func webhookLogSink(ctx context.Context, payload []byte) error {
+	rpcCtx, cancel := RPCContext(ctx)
+	defer cancel()
+	request, err := http.NewRequestWithContext(rpcCtx, http.MethodPost, logsWebhook, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", response.Status)
+	}
+	return nil
+}
+
+// loadLogsCursor is the cursor of the last run, found is false if there was
+// none
+func loadLogsCursor() (logsCursor, bool, error) {
+	data, err := helpers.LoadBytes(helpers.LogsFollowCursorPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return logsCursor{}, false, nil
+	}
+	if err != nil {
+		return logsCursor{}, false, err
+	}
+	var cursor logsCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return logsCursor{}, false, fmt.Errorf("failed to parse %s: %w", helpers.LogsFollowCursorPath, err)
+	}
+	return cursor, true, nil
+}
+
+func saveLogsCursor(cursor logsCursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	return helpers.SaveBytes(helpers.LogsFollowCursorPath, data)
+}
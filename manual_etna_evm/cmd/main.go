@@ -4,8 +4,19 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Execute runs the command and with --output json prints its result object
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, cancel := signalContext()
+	defer cancel()
+	defer func() { cancelCommandTimeout() }()
+
+	executed, err := rootCmd.ExecuteContextC(ctx)
+	if JSONOutput() {
+		if writeErr := writeResult(executed, err); writeErr != nil && err == nil {
+			return writeErr
+		}
+	}
+	return err
 }
 
 var rootCmd = &cobra.Command{
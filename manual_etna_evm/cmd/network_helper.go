@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	cliconstants "github.com/ava-labs/avalanche-cli/pkg/constants"
+	"github.com/ava-labs/avalanchego/tests/fixture/tmpnet"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+)
+
+const (
+	FujiNetwork  = "fuji"
+	LocalNetwork = "local"
+
+	// The local network uses the avalanche-cli local network ID, for which the
+	// signature aggregator knows that Etna is active from genesis
+	localNetworkID = cliconstants.LocalNetworkID
+)
+
+// NetworkSelection is the primary network the workspace was created on,
+// Fuji unless local-network start was run first
+type NetworkSelection struct {
+	Name      string `json:"name"`
+	TmpnetDir string `json:"tmpnetDir,omitempty"`
+}
+
+func (s NetworkSelection) IsLocal() bool {
+	return s.Name == LocalNetwork
+}
+
+// LoadNetworkSelection defaults to Fuji if the workspace has no selection
+func LoadNetworkSelection() (NetworkSelection, error) {
+	exists, err := helpers.FileExists(helpers.NetworkSelectionPath)
+	if err != nil {
+		return NetworkSelection{}, err
+	}
+	if !exists {
+		return NetworkSelection{Name: FujiNetwork}, nil
+	}
+
+	selectionBytes, err := helpers.LoadBytes(helpers.NetworkSelectionPath)
+	if err != nil {
+		return NetworkSelection{}, err
+	}
+	var selection NetworkSelection
+	if err := json.Unmarshal(selectionBytes, &selection); err != nil {
+		return NetworkSelection{}, fmt.Errorf("failed to parse %s: %w", helpers.NetworkSelectionPath, err)
+	}
+	if selection.Name != FujiNetwork && selection.Name != LocalNetwork {
+		return NetworkSelection{}, fmt.Errorf("unknown network %q in %s", selection.Name, helpers.NetworkSelectionPath)
+	}
+	return selection, nil
+}
+
+func SaveNetworkSelection(selection NetworkSelection) error {
+	selectionBytes, err := json.MarshalIndent(selection, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal network selection: %w", err)
+	}
+	return helpers.SaveBytes(helpers.NetworkSelectionPath, selectionBytes)
+}
+
+// ReadTmpnet reads the local network, node URIs are only set for running nodes
+func (s NetworkSelection) ReadTmpnet() (*tmpnet.Network, error) {
+	if !s.IsLocal() {
+		return nil, fmt.Errorf("workspace is on %s, not on a local network", s.Name)
+	}
+	network, err := tmpnet.ReadNetwork(s.TmpnetDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local network at %s: %w", s.TmpnetDir, err)
+	}
+	return network, nil
+}
+
+// GetRPCURL returns the base URL of the primary network API, for the local
+// network the first running node
+func GetRPCURL() (string, error) {
+	selection, err := LoadNetworkSelection()
+	if err != nil {
+		return "", err
+	}
+	if !selection.IsLocal() {
+		return config.RPC_URL, nil
+	}
+
+	network, err := selection.ReadTmpnet()
+	if err != nil {
+		return "", err
+	}
+	for _, node := range network.Nodes {
+		if node.URI != "" {
+			return node.URI, nil
+		}
+	}
+	return "", fmt.Errorf("local network is not running, start it with: go run . local-network start")
+}
+
+// GetL1Network is the selected primary network for the l1 package
+func GetL1Network() (l1.Network, error) {
+	selection, err := LoadNetworkSelection()
+	if err != nil {
+		return l1.Network{}, err
+	}
+	if !selection.IsLocal() {
+		return l1.FujiNetwork(), nil
+	}
+
+	rpcURL, err := GetRPCURL()
+	if err != nil {
+		return l1.Network{}, err
+	}
+	return l1.Network{ID: localNetworkID, URI: rpcURL}, nil
+}
+
+// NodeNetworkConfig is what a node needs to join the selected primary network
+type NodeNetworkConfig struct {
+	NetworkID string
+	// GenesisFile is a host path, empty for networks built into avalanchego
+	GenesisFile  string
+	BootstrapIPs []string
+	BootstrapIDs []string
+	// PublicIP is resolved through ifconfig.me if empty
+	PublicIP string
+}
+
+// LoadNodeNetworkConfig bootstraps local nodes from the running local
+// network nodes, Fuji nodes use the built in bootstrappers
+func LoadNodeNetworkConfig() (NodeNetworkConfig, error) {
+	selection, err := LoadNetworkSelection()
+	if err != nil {
+		return NodeNetworkConfig{}, err
+	}
+	if !selection.IsLocal() {
+		return NodeNetworkConfig{NetworkID: FujiNetwork}, nil
+	}
+
+	network, err := selection.ReadTmpnet()
+	if err != nil {
+		return NodeNetworkConfig{}, err
+	}
+	networkConfig := NodeNetworkConfig{
+		NetworkID:   strconv.FormatUint(uint64(network.GetNetworkID()), 10),
+		GenesisFile: filepath.Join(network.Dir, "genesis.json"),
+		PublicIP:    "127.0.0.1",
+	}
+	for _, node := range network.Nodes {
+		if !node.StakingAddress.IsValid() {
+			continue
+		}
+		networkConfig.BootstrapIPs = append(networkConfig.BootstrapIPs, node.StakingAddress.String())
+		networkConfig.BootstrapIDs = append(networkConfig.BootstrapIDs, node.NodeID.String())
+	}
+	if len(networkConfig.BootstrapIPs) == 0 {
+		return NodeNetworkConfig{}, fmt.Errorf("local network is not running, start it with: go run . local-network start")
+	}
+	return networkConfig, nil
+}
+
+// settings takes the genesis path as the node sees it
+func (c NodeNetworkConfig) settings(genesisPath string) []nodeSetting {
+	settings := []nodeSetting{{"network-id", c.NetworkID}}
+	if c.GenesisFile != "" {
+		settings = append(settings, nodeSetting{"genesis-file", genesisPath})
+	}
+	if len(c.BootstrapIPs) > 0 {
+		settings = append(settings,
+			nodeSetting{"bootstrap-ips", strings.Join(c.BootstrapIPs, ",")},
+			nodeSetting{"bootstrap-ids", strings.Join(c.BootstrapIDs, ",")},
+		)
+	}
+	if c.PublicIP != "" {
+		settings = append(settings, nodeSetting{"public-ip", c.PublicIP})
+	} else {
+		settings = append(settings, nodeSetting{"public-ip-resolution-service", "ifconfigme"})
+	}
+	return settings
+}
@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+)
+
+// ManagedNode is a node started by this tool: node0 from launch-node and
+// nodeN for every data/add_validator_N folder. All paths are relative to the
+// working directory, the container sees the data folder mounted at /data.
+type ManagedNode struct {
+	Name           string
+	Index          int
+	HTTPPort       int
+	StakingPort    int
+	DataDir        string
+	CredsFolder    string
+	ChainConfigDir string
+}
+
+func newManagedNode(index int, credsFolder string, chainConfigDir string) ManagedNode {
+	httpPort := 9650 + index*2
+	return ManagedNode{
+		Name:           fmt.Sprintf("node%d", index),
+		Index:          index,
+		HTTPPort:       httpPort,
+		StakingPort:    httpPort + 1,
+		DataDir:        fmt.Sprintf("data/node%d", index),
+		CredsFolder:    credsFolder,
+		ChainConfigDir: chainConfigDir,
+	}
+}
+
+func (n ManagedNode) URI() string {
+	return fmt.Sprintf("http://127.0.0.1:%d", n.HTTPPort)
+}
+
+func GetManagedNodes() ([]ManagedNode, error) {
+	nodes := []ManagedNode{newManagedNode(0, helpers.Node0KeysFolder, "data/chains")}
+
+	folders, err := filepath.Glob("data/add_validator_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list add validator folders: %w", err)
+	}
+	for _, folder := range folders {
+		index, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(folder), "add_validator_"))
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(folder)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		// A folder without creds belongs to a validator that was never
+		// registered, there is no node to start for it
+		hasCreds, err := hasNodeCreds(folder)
+		if err != nil {
+			return nil, err
+		}
+		if !hasCreds {
+			continue
+		}
+		nodes = append(nodes, newManagedNode(index, folder+"/", filepath.Join(folder, "chains")))
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Index < nodes[j].Index })
+	return nodes, nil
+}
+
+// hasNodeCreds reports whether folder has the staking key, certificate and
+// BLS key of a node
+func hasNodeCreds(folder string) (bool, error) {
+	for _, file := range []string{"staker.crt", "staker.key", "signer.key"} {
+		exists, err := helpers.FileExists(filepath.Join(folder, file))
+		if err != nil {
+			return false, fmt.Errorf("failed to check if %s exists: %w", filepath.Join(folder, file), err)
+		}
+		if !exists {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// FilterManagedNodes returns the nodes with the given names, or all of them
+// if no names are given
+func FilterManagedNodes(nodes []ManagedNode, names []string) ([]ManagedNode, error) {
+	if len(names) == 0 {
+		return nodes, nil
+	}
+	filtered := []ManagedNode{}
+	for _, name := range names {
+		found := false
+		for _, node := range nodes {
+			if node.Name == name {
+				filtered = append(filtered, node)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown node %s", name)
+		}
+	}
+	return filtered, nil
+}
+
+// StakingFolder is where avalanchego looks for staker.key, staker.crt and
+// signer.key by default
+func (n ManagedNode) StakingFolder() string {
+	return filepath.Join(n.DataDir, "staking")
+}
+
+// ContainerPath maps a path inside the data folder to where the container sees it
+func ContainerPath(path string) string {
+	return "/" + filepath.ToSlash(filepath.Clean(path))
+}
+
+// nodeSetting is one avalanchego option, key in flag form without dashes
+type nodeSetting struct {
+	Key   string
+	Value string
+}
+
+// EnvVar is the setting in the form the node image reads, AVALANCHEGO_HTTP_PORT=9650
+func (s nodeSetting) EnvVar() string {
+	return "AVALANCHEGO_" + strings.ToUpper(strings.ReplaceAll(s.Key, "-", "_")) + "=" + s.Value
+}
+
+// NodeLaunchConfig is the workspace state every node is started with
+type NodeLaunchConfig struct {
+	SubnetID string
+	Network  NodeNetworkConfig
+}
+
+func LoadNodeLaunchConfig() (NodeLaunchConfig, error) {
+	subnetID, err := helpers.LoadId(helpers.SubnetIdPath)
+	if err != nil {
+		return NodeLaunchConfig{}, fmt.Errorf("failed to load subnet ID: %w", err)
+	}
+	network, err := LoadNodeNetworkConfig()
+	if err != nil {
+		return NodeLaunchConfig{}, err
+	}
+	return NodeLaunchConfig{SubnetID: subnetID.String(), Network: network}, nil
+}
+
+// settings is the avalanchego configuration shared by every runner, path
+// maps workspace paths to where avalanchego sees them
+func (n ManagedNode) settings(launch NodeLaunchConfig, pluginDir string, path func(string) string, genesisPath string) []nodeSetting {
+	settings := []nodeSetting{
+		{"chain-config-dir", path(n.ChainConfigDir)},
+		{"data-dir", path(n.DataDir)},
+		{"plugin-dir", pluginDir},
+		{"http-port", strconv.Itoa(n.HTTPPort)},
+		{"staking-port", strconv.Itoa(n.StakingPort)},
+		{"track-subnets", launch.SubnetID},
+		{"http-allowed-hosts", "*"},
+		{"http-host", "0.0.0.0"},
+		{"partial-sync-primary-network", "true"},
+	}
+	return append(settings, launch.Network.settings(genesisPath)...)
+}
+
+// containerGenesisPath is where the genesis of a local network is mounted
+const containerGenesisPath = "/genesis.json"
+
+// Env is the avalanchego configuration of the node, paths as seen from the container
+func (n ManagedNode) Env(launch NodeLaunchConfig) []string {
+	env := []string{}
+	for _, setting := range n.settings(launch, "/plugins/", ContainerPath, containerGenesisPath) {
+		env = append(env, setting.EnvVar())
+	}
+	return env
+}
+
+// Flags is the avalanchego configuration of the node for a local binary,
+// the credentials are passed as files instead of being mounted
+func (n ManagedNode) Flags(launch NodeLaunchConfig, pluginDir string) ([]string, error) {
+	var pathErr error
+	absPath := func(path string) string {
+		abs, err := filepath.Abs(path)
+		if err != nil && pathErr == nil {
+			pathErr = fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		return abs
+	}
+
+	settings := n.settings(launch, absPath(pluginDir), absPath, absPath(launch.Network.GenesisFile))
+	settings = append(settings,
+		nodeSetting{"staking-tls-key-file", absPath(filepath.Join(n.CredsFolder, "staker.key"))},
+		nodeSetting{"staking-tls-cert-file", absPath(filepath.Join(n.CredsFolder, "staker.crt"))},
+		nodeSetting{"staking-signer-key-file", absPath(filepath.Join(n.CredsFolder, "signer.key"))},
+	)
+	if pathErr != nil {
+		return nil, pathErr
+	}
+
+	flags := []string{}
+	for _, setting := range settings {
+		flags = append(flags, fmt.Sprintf("--%s=%s", setting.Key, setting.Value))
+	}
+	return flags, nil
+}
+
+// PrepareNodes writes everything a node needs before its container starts:
+// the data folder and its chain config
+func PrepareNodes(nodes []ManagedNode) error {
+	chainID, err := helpers.LoadId(helpers.ChainIdPath)
+	if err != nil {
+		return fmt.Errorf("failed to load chain ID: %w", err)
+	}
+	for _, node := range nodes {
+		if err := os.MkdirAll(node.DataDir, 0755); err != nil {
+			return fmt.Errorf("failed to create data dir for %s: %w", node.Name, err)
+		}
+		if err := WriteChainConfig(node.ChainConfigDir, chainID); err != nil {
+			return fmt.Errorf("failed to write chain config for %s: %w", node.Name, err)
+		}
+	}
+	return nil
+}
+
+// NodeState describes a node for status output, State is "missing" if the
+// runner knows nothing about the node
+type NodeState struct {
+	State        string
+	Health       string
+	ExitCode     int
+	RestartCount int
+	Error        string
+}
+
+type NodeLogsOptions struct {
+	Follow bool
+	// Tail is the number of lines from the end, 0 or less means all
+	Tail int
+}
+
+// NodeRunner starts and stops managed nodes, in containers or as local processes
+type NodeRunner interface {
+	Name() string
+	// Start returns false if the node was already running and recreate is not set
+	Start(ctx context.Context, node ManagedNode, launch NodeLaunchConfig, recreate bool) (bool, error)
+	// Stop is a no-op for nodes that are not running
+	Stop(ctx context.Context, name string) error
+	// Restart returns false if the node is not running
+	Restart(ctx context.Context, name string) (bool, error)
+	Status(ctx context.Context, name string) (NodeState, error)
+	Logs(ctx context.Context, name string, options NodeLogsOptions, stdout io.Writer, stderr io.Writer) error
+	// List returns the names of nodes the runner started, including ones
+	// whose workspace folder is already gone
+	List(ctx context.Context) ([]string, error)
+}
+
+const (
+	DockerRunner  = "docker"
+	ProcessRunner = "process"
+)
+
+const nodeStopTimeout = 30 * time.Second
+
+// LoadNodeRunnerName returns the runner saved by the last nodes up, $NODE_RUNNER
+// or docker
+func LoadNodeRunnerName() (string, error) {
+	exists, err := helpers.FileExists(helpers.NodeRunnerPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to check node runner file: %w", err)
+	}
+	if exists {
+		name, err := helpers.LoadText(helpers.NodeRunnerPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to load node runner: %w", err)
+		}
+		return name, nil
+	}
+	if name := os.Getenv("NODE_RUNNER"); name != "" {
+		return name, nil
+	}
+	return DockerRunner, nil
+}
+
+// GetNodeRunner connects the named runner, the saved one if name is empty.
+// A name that is set explicitly is saved for later commands.
+func GetNodeRunner(ctx context.Context, name string) (NodeRunner, error) {
+	save := name != ""
+	if name == "" {
+		var err error
+		name, err = LoadNodeRunnerName()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var runner NodeRunner
+	switch name {
+	case DockerRunner:
+		client, err := NewDockerClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		runner = &dockerNodeRunner{client: client}
+	case ProcessRunner:
+		runner = newProcessNodeRunner()
+	default:
+		return nil, fmt.Errorf("unknown node runner %q, use %s or %s", name, DockerRunner, ProcessRunner)
+	}
+
+	if save {
+		if err := helpers.SaveText(helpers.NodeRunnerPath, name); err != nil {
+			return nil, fmt.Errorf("failed to save node runner: %w", err)
+		}
+	}
+	return runner, nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+)
+
+func TestGetManagedNodes(t *testing.T) {
+	chdirTemp(t)
+	for _, folder := range []string{"data/add_validator_1/", "data/add_validator_3/"} {
+		if err := GenerateCredsIfNotExists(folder); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Left behind by a run that never registered the node
+	if err := os.MkdirAll("data/add_validator_2", 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Creds of a node that are missing the BLS key
+	if err := GenerateCredsIfNotExists("data/add_validator_4/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove("data/add_validator_4/signer.key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := helpers.SaveText("data/add_validator_5", "not a folder"); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := GetManagedNodes()
+	if err != nil {
+		t.Fatalf("failed to get nodes: %s", err)
+	}
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	if want := []string{"node0", "node1", "node3"}; !slices.Equal(names, want) {
+		t.Errorf("nodes = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers/docker"
+	"github.com/spf13/cobra"
+)
+
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// Stable error codes of --output json, scripts match on these rather than on
+// the message
+const (
+	ErrCodeFailed            = "command_failed"
+	ErrCodeUsage             = "usage"
+	ErrCodeTimeout           = "timeout"
+	ErrCodeCanceled          = "canceled"
+	ErrCodeMissingFile       = "missing_file"
+	ErrCodeTxDropped         = "tx_dropped"
+	ErrCodeTxAborted         = "tx_aborted"
+	ErrCodeDockerUnavailable = "docker_unavailable"
+	ErrCodeNotFound          = "not_found"
+	ErrCodeConflict          = "conflict"
+	ErrCodeInsufficientFunds = "insufficient_funds"
+	ErrCodeNodeNotReady      = "node_not_ready"
+	ErrCodeChurnLimit        = "churn_limit"
+)
+
+var outputFormat string
+
+// resultOutput is the real stdout, in JSON mode os.Stdout is pointed at
+// stderr so only the result object ends up on stdout
+var resultOutput io.Writer = os.Stdout
+
+// commandResult collects what the running command produced
+var commandResult = map[string]any{}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", OutputText, fmt.Sprintf("Output format, %s or %s. With %s human logs go to stderr and stdout gets a single result object", OutputText, OutputJSON, OutputJSON))
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		switch outputFormat {
+		case OutputText:
+		case OutputJSON:
+			// Everything printed for humans, including by libraries, goes to
+			// stderr from here on
+			os.Stdout = os.Stderr
+		default:
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("unknown output format %q, use %s or %s", outputFormat, OutputText, OutputJSON))
+		}
+		applyCommandTimeout(cmd)
+		return nil
+	}
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return WithErrorCode(ErrCodeUsage, err)
+	})
+}
+
+// JSONOutput reports whether the result object is printed
+func JSONOutput() bool {
+	return outputFormat == OutputJSON
+}
+
+// SetResult records a value the command produced for the result object
+func SetResult(key string, value any) {
+	commandResult[key] = value
+}
+
+// AppendResult adds a value to a list in the result object
+func AppendResult(key string, value any) {
+	list, _ := commandResult[key].([]any)
+	commandResult[key] = append(list, value)
+}
+
+// CodedError gives an error one of the ErrCode values
+type CodedError struct {
+	Code string
+	Err  error
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+func WithErrorCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CodedError{Code: code, Err: err}
+}
+
+// ErrorCode is the code of the first CodedError in the chain, or derived
+// from the sentinel errors the error wraps
+func ErrorCode(err error) string {
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	switch {
+	case errors.Is(err, ErrTxDropped):
+		return ErrCodeTxDropped
+	case errors.Is(err, ErrTxAborted):
+		return ErrCodeTxAborted
+	case errors.Is(err, ErrInsufficientBalance):
+		return ErrCodeInsufficientFunds
+	case errors.Is(err, ErrValidatorNotFound):
+		return ErrCodeNotFound
+	case errors.Is(err, ErrChurnLimitExceeded):
+		return ErrCodeChurnLimit
+	case errors.Is(err, ErrNodeAlreadyRegistered), errors.Is(err, ErrInvalidValidatorStatus), errors.Is(err, ErrRegistrationNotExpired):
+		return ErrCodeConflict
+	case errors.Is(err, docker.ErrDaemonUnavailable):
+		return ErrCodeDockerUnavailable
+	case errors.Is(err, docker.ErrNotFound):
+		return ErrCodeNotFound
+	case errors.Is(err, docker.ErrConflict):
+		return ErrCodeConflict
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrCodeTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrCodeCanceled
+	case errors.Is(err, os.ErrNotExist):
+		return ErrCodeMissingFile
+	}
+	return ErrCodeFailed
+}
+
+type resultError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type resultObject struct {
+	Command string         `json:"command"`
+	OK      bool           `json:"ok"`
+	Result  map[string]any `json:"result"`
+	Error   *resultError   `json:"error,omitempty"`
+}
+
+// writeResult prints the result object of the executed command, failed
+// commands keep what they produced before the error
+func writeResult(executed *cobra.Command, err error) error {
+	object := resultObject{OK: err == nil, Result: commandResult}
+	if executed != nil && executed != rootCmd {
+		object.Command = strings.TrimPrefix(executed.CommandPath(), rootCmd.Name()+" ")
+	}
+	if err != nil {
+		object.Error = &resultError{Code: ErrorCode(err), Message: err.Error()}
+	}
+
+	encoder := json.NewEncoder(resultOutput)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(object); err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+// Confirm prints question and reads the answer from in, anything but y or
+// yes is a no. It is a no as well if in ends before a line.
+func Confirm(in io.Reader, question string) (bool, error) {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
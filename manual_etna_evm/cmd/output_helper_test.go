@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "y\n", want: true},
+		{input: "yes\n", want: true},
+		{input: " YES \n", want: true},
+		{input: "yes", want: true},
+		{input: "n\n", want: false},
+		{input: "\n", want: false},
+		{input: "", want: false},
+		{input: "yep\n", want: false},
+	}
+	for _, test := range tests {
+		got, err := Confirm(strings.NewReader(test.input), "Disable?")
+		if err != nil {
+			t.Fatalf("Confirm(%q) failed: %s", test.input, err)
+		}
+		if got != test.want {
+			t.Errorf("Confirm(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
+)
+
+// NewPChainTxTracker tracks txs through the RPC node of the workspace network
+func NewPChainTxTracker() (*l1.PChainTxTracker, error) {
+	rpcURL, err := GetRPCURL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RPC URL: %w", err)
+	}
+	return l1.NewPChainTxTracker(rpcURL, log.Default()), nil
+}
+
+// AwaitPChainTx waits for a tx with a fresh tracker, bounded by --tx-timeout
+func AwaitPChainTx(ctx context.Context, txID ids.ID, what string) error {
+	tracker, err := NewPChainTxTracker()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := TxContext(ctx)
+	defer cancel()
+	return tracker.AwaitTx(ctx, txID, what)
+}
+
+// pendingTxPath keeps the ID of a tx that was issued but not confirmed,
+// because the command was interrupted or timed out while waiting for it
+func pendingTxPath(path string) string {
+	return path + ".pending"
+}
+
+// SavePendingTx keeps txID for ResumePendingTx if err is an interruption,
+// so the next run waits for the tx instead of issuing another one. It
+// returns err.
+func SavePendingTx(path string, txID ids.ID, err error) error {
+	if txID == ids.Empty || !IsInterrupted(err) {
+		return err
+	}
+	if saveErr := helpers.SaveId(pendingTxPath(path), txID); saveErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to save pending tx %s: %w", txID, saveErr))
+	}
+	log.Printf("⏸️ Tx %s was issued, run the command again to resume waiting for it\n", txID)
+	return err
+}
+
+// ResumePendingTx waits for the tx a previous run left pending and saves its
+// ID to path once confirmed. It returns ids.Empty if there is no pending tx,
+// or if the tx was dropped and has to be issued again.
+func ResumePendingTx(ctx context.Context, path string, await func(ctx context.Context, txID ids.ID) error) (ids.ID, error) {
+	pendingPath := pendingTxPath(path)
+	exists, err := helpers.FileExists(pendingPath)
+	if err != nil || !exists {
+		return ids.Empty, err
+	}
+	txID, err := helpers.LoadId(pendingPath)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("failed to load pending tx: %w", err)
+	}
+
+	log.Printf("Resuming pending tx %s\n", txID)
+	err = await(ctx, txID)
+	if errors.Is(err, ErrTxDropped) || errors.Is(err, ErrTxAborted) {
+		log.Printf("Pending tx %s did not make it: %s, issuing a new one\n", txID, err)
+		if err := os.Remove(pendingPath); err != nil {
+			return ids.Empty, fmt.Errorf("failed to remove pending tx: %w", err)
+		}
+		return ids.Empty, nil
+	}
+	if err != nil {
+		return ids.Empty, err
+	}
+
+	if err := helpers.SaveId(path, txID); err != nil {
+		return ids.Empty, err
+	}
+	if err := os.Remove(pendingPath); err != nil {
+		return ids.Empty, fmt.Errorf("failed to remove pending tx: %w", err)
+	}
+	return txID, nil
+}
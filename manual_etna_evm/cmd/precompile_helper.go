@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/accounts/abi/bind"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/ethclient"
+	"github.com/ava-labs/subnet-evm/interfaces"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// getPrecompileTransactor connects to node0 and prepares transact options
+// signed by the validator manager owner key, which is the admin of every
+// precompile enabled in the genesis. Transactions are sent with ctx.
+func getPrecompileTransactor(ctx context.Context) (ethclient.Client, *bind.TransactOpts, error) {
+	ecdsaKey, err := helpers.LoadSecp256k1PrivateKeyECDSA(helpers.ValidatorManagerOwnerKeyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load private key: %w", err)
+	}
+
+	ethClient, evmChainId, err := GetLocalEthClient(ctx, "9650")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to client: %w", err)
+	}
+
+	opts, err := bind.NewKeyedTransactorWithChainID(ecdsaKey, evmChainId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create transactor: %w", err)
+	}
+	opts.Context = ctx
+
+	return ethClient, opts, nil
+}
+
+// transactPrecompile sends already packed calldata to a precompile and waits
+// up to --tx-timeout for the transaction to be mined.
+func transactPrecompile(ctx context.Context, ethClient ethclient.Client, opts *bind.TransactOpts, address common.Address, calldata []byte) (*types.Receipt, error) {
+	contract := bind.NewBoundContract(address, abi.ABI{}, ethClient, ethClient, ethClient)
+
+	tx, err := contract.RawTransact(opts, calldata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send transaction to %s: %w", address, err)
+	}
+	log.Printf("Sent transaction %s to %s\n", tx.Hash().Hex(), address)
+
+	ctx, cancel := TxContext(ctx)
+	defer cancel()
+
+	receipt, err := bind.WaitMined(ctx, ethClient, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for transaction confirmation: %w", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("transaction %s reverted", tx.Hash().Hex())
+	}
+
+	return receipt, nil
+}
+
+// callPrecompile runs a read-only call against a precompile and returns the
+// raw ABI encoded output.
+func callPrecompile(ctx context.Context, ethClient ethclient.Client, address common.Address, calldata []byte) ([]byte, error) {
+	ctx, cancel := RPCContext(ctx)
+	defer cancel()
+	output, err := ethClient.CallContract(ctx, interfaces.CallMsg{
+		To:   &address,
+		Data: calldata,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", address, err)
+	}
+	return output, nil
+}
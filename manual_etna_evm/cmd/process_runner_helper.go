@@ -0,0 +1,409 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+)
+
+const (
+	supervisorPidFile   = "supervisor.pid"
+	avalanchegoPidFile  = "avalanchego.pid"
+	avalanchegoLogFile  = "avalanchego.log"
+	supervisorStateFile = "supervisor.json"
+
+	maxRestartBackoff = 30 * time.Second
+	// A node that ran this long before crashing restarts without backoff
+	stableRunDuration = time.Minute
+)
+
+// processNodeRunner runs a local avalanchego binary per node, each one
+// watched by a detached "nodes supervise" process that restarts it on crash.
+// PID files, the log and the supervisor state live in the node data dir.
+type processNodeRunner struct {
+	avalanchegoPath string
+	pluginDir       string
+}
+
+// newProcessNodeRunner takes the binary from $AVALANCHEGO_PATH or PATH and
+// the plugins from $AVALANCHEGO_PLUGIN_DIR or ~/.avalanchego/plugins
+func newProcessNodeRunner() *processNodeRunner {
+	avalanchegoPath := os.Getenv("AVALANCHEGO_PATH")
+	if avalanchegoPath == "" {
+		avalanchegoPath = "avalanchego"
+	}
+	pluginDir := os.Getenv("AVALANCHEGO_PLUGIN_DIR")
+	if pluginDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			pluginDir = filepath.Join(home, ".avalanchego", "plugins")
+		}
+	}
+	return &processNodeRunner{avalanchegoPath: avalanchegoPath, pluginDir: pluginDir}
+}
+
+// SupervisorState is written by the supervisor after every start and exit of avalanchego
+type SupervisorState struct {
+	Restarts     int    `json:"restarts"`
+	LastExitCode int    `json:"lastExitCode"`
+	LastError    string `json:"lastError,omitempty"`
+}
+
+func (r *processNodeRunner) Name() string {
+	return ProcessRunner
+}
+
+// Start does not pass the launch config on, the supervisor loads it from the
+// workspace before every start of avalanchego so it follows a restarted
+// local network
+func (r *processNodeRunner) Start(ctx context.Context, node ManagedNode, launch NodeLaunchConfig, recreate bool) (bool, error) {
+	if pid, alive := readAlivePid(filepath.Join(node.DataDir, supervisorPidFile)); alive {
+		if !recreate {
+			return false, nil
+		}
+		if err := stopSupervisor(ctx, node.DataDir, pid); err != nil {
+			return false, err
+		}
+	}
+
+	avalanchegoPath, err := exec.LookPath(r.avalanchegoPath)
+	if err != nil {
+		return false, fmt.Errorf("avalanchego binary not found, set AVALANCHEGO_PATH: %w", err)
+	}
+	pluginPath := filepath.Join(r.pluginDir, constants.SubnetEVMID.String())
+	if _, err := os.Stat(pluginPath); err != nil {
+		return false, fmt.Errorf("subnet-evm plugin not found at %s, set AVALANCHEGO_PLUGIN_DIR: %w", pluginPath, err)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return false, fmt.Errorf("failed to find own executable: %w", err)
+	}
+
+	logFile, err := os.OpenFile(filepath.Join(node.DataDir, avalanchegoLogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer logFile.Close()
+
+	supervisor := exec.Command(executable, "nodes", "supervise", node.Name,
+		"--avalanchego-path", avalanchegoPath,
+		"--plugin-dir", r.pluginDir,
+	)
+	supervisor.Stdout = logFile
+	supervisor.Stderr = logFile
+	// A new session keeps the supervisor alive after this command exits
+	supervisor.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := supervisor.Start(); err != nil {
+		return false, fmt.Errorf("failed to start supervisor: %w", err)
+	}
+
+	pid := supervisor.Process.Pid
+	if err := helpers.SaveText(filepath.Join(node.DataDir, supervisorPidFile), strconv.Itoa(pid)); err != nil {
+		return false, fmt.Errorf("failed to save supervisor PID: %w", err)
+	}
+	if err := supervisor.Process.Release(); err != nil {
+		return false, fmt.Errorf("failed to detach supervisor: %w", err)
+	}
+	return true, nil
+}
+
+func (r *processNodeRunner) Stop(ctx context.Context, name string) error {
+	dataDir := managedNodeDataDir(name)
+	pid, alive := readAlivePid(filepath.Join(dataDir, supervisorPidFile))
+	if !alive {
+		// The supervisor might have been killed without taking avalanchego down
+		if nodePid, nodeAlive := readAlivePid(filepath.Join(dataDir, avalanchegoPidFile)); nodeAlive {
+			_ = syscall.Kill(nodePid, syscall.SIGKILL)
+		}
+		removePidFiles(dataDir)
+		return nil
+	}
+	return stopSupervisor(ctx, dataDir, pid)
+}
+
+// Restart asks the supervisor to restart avalanchego, the supervisor keeps running
+func (r *processNodeRunner) Restart(ctx context.Context, name string) (bool, error) {
+	pid, alive := readAlivePid(filepath.Join(managedNodeDataDir(name), supervisorPidFile))
+	if !alive {
+		return false, nil
+	}
+	if err := syscall.Kill(pid, syscall.SIGHUP); err != nil {
+		return false, fmt.Errorf("failed to signal supervisor %d: %w", pid, err)
+	}
+	return true, nil
+}
+
+func (r *processNodeRunner) Status(ctx context.Context, name string) (NodeState, error) {
+	dataDir := managedNodeDataDir(name)
+
+	var supervisorState SupervisorState
+	statePath := filepath.Join(dataDir, supervisorStateFile)
+	exists, err := helpers.FileExists(statePath)
+	if err != nil {
+		return NodeState{}, err
+	}
+	if !exists {
+		return NodeState{State: "missing"}, nil
+	}
+	stateBytes, err := os.ReadFile(statePath)
+	if err != nil {
+		return NodeState{}, fmt.Errorf("failed to read supervisor state: %w", err)
+	}
+	if err := json.Unmarshal(stateBytes, &supervisorState); err != nil {
+		return NodeState{}, fmt.Errorf("failed to parse supervisor state: %w", err)
+	}
+
+	state := NodeState{
+		State:        "exited",
+		ExitCode:     supervisorState.LastExitCode,
+		RestartCount: supervisorState.Restarts,
+		Error:        supervisorState.LastError,
+	}
+	if _, alive := readAlivePid(filepath.Join(dataDir, supervisorPidFile)); alive {
+		state.State = "restarting"
+		if _, nodeAlive := readAlivePid(filepath.Join(dataDir, avalanchegoPidFile)); nodeAlive {
+			state.State = "running"
+		}
+	}
+	return state, nil
+}
+
+// Logs prints the log file, following polls it for new lines until ctx is done
+func (r *processNodeRunner) Logs(ctx context.Context, name string, options NodeLogsOptions, stdout io.Writer, stderr io.Writer) error {
+	logFile, err := os.Open(filepath.Join(managedNodeDataDir(name), avalanchegoLogFile))
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer logFile.Close()
+
+	if options.Tail > 0 {
+		lines := []string{}
+		scanner := bufio.NewScanner(logFile)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+			if len(lines) > options.Tail {
+				lines = lines[1:]
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read log file: %w", err)
+		}
+		for _, line := range lines {
+			fmt.Fprintln(stdout, line)
+		}
+	} else if _, err := io.Copy(stdout, logFile); err != nil {
+		return fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	if !options.Follow {
+		return nil
+	}
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := io.Copy(stdout, logFile); err != nil {
+				return fmt.Errorf("failed to read log file: %w", err)
+			}
+		}
+	}
+}
+
+func (r *processNodeRunner) List(ctx context.Context) ([]string, error) {
+	pidFiles, err := filepath.Glob(filepath.Join("data", "node*", supervisorPidFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list supervisor PID files: %w", err)
+	}
+	names := []string{}
+	for _, pidFile := range pidFiles {
+		if _, alive := readAlivePid(pidFile); alive {
+			names = append(names, filepath.Base(filepath.Dir(pidFile)))
+		}
+	}
+	return names, nil
+}
+
+// SuperviseNode runs avalanchego in the foreground and restarts it when it
+// exits. SIGHUP restarts it on purpose, SIGTERM and SIGINT stop both.
+func SuperviseNode(node ManagedNode, avalanchegoPath string, pluginDir string) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	statePath := filepath.Join(node.DataDir, supervisorStateFile)
+	nodePidPath := filepath.Join(node.DataDir, avalanchegoPidFile)
+	defer removePidFiles(node.DataDir)
+
+	state := SupervisorState{}
+	if err := saveSupervisorState(statePath, state); err != nil {
+		return err
+	}
+
+	backoff := time.Second
+	for {
+		launch, err := LoadNodeLaunchConfig()
+		if err != nil {
+			return err
+		}
+		flags, err := node.Flags(launch, pluginDir)
+		if err != nil {
+			return err
+		}
+
+		avalanchego := exec.Command(avalanchegoPath, flags...)
+		avalanchego.Stdout = os.Stdout
+		avalanchego.Stderr = os.Stderr
+		startedAt := time.Now()
+		if err := avalanchego.Start(); err != nil {
+			return fmt.Errorf("failed to start avalanchego: %w", err)
+		}
+		if err := helpers.SaveText(nodePidPath, strconv.Itoa(avalanchego.Process.Pid)); err != nil {
+			_ = avalanchego.Process.Kill()
+			return fmt.Errorf("failed to save avalanchego PID: %w", err)
+		}
+		log.Printf("Supervisor started avalanchego for %s with PID %d\n", node.Name, avalanchego.Process.Pid)
+
+		exited := make(chan error, 1)
+		go func() { exited <- avalanchego.Wait() }()
+
+		var exitErr error
+		restartRequested := false
+		select {
+		case exitErr = <-exited:
+		case sig := <-signals:
+			log.Printf("Supervisor got %s, stopping avalanchego for %s\n", sig, node.Name)
+			exitErr = terminateProcess(avalanchego.Process, exited)
+			if sig != syscall.SIGHUP {
+				return nil
+			}
+			restartRequested = true
+		}
+		_ = os.Remove(nodePidPath)
+
+		if restartRequested {
+			backoff = time.Second
+			continue
+		}
+
+		state.Restarts++
+		state.LastExitCode = avalanchego.ProcessState.ExitCode()
+		state.LastError = ""
+		if exitErr != nil {
+			state.LastError = exitErr.Error()
+		}
+		if err := saveSupervisorState(statePath, state); err != nil {
+			return err
+		}
+
+		if time.Since(startedAt) > stableRunDuration {
+			backoff = time.Second
+		}
+		log.Printf("avalanchego for %s exited with code %d, restarting in %s\n", node.Name, state.LastExitCode, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case sig := <-signals:
+			if sig != syscall.SIGHUP {
+				return nil
+			}
+		}
+		backoff = min(backoff*2, maxRestartBackoff)
+	}
+}
+
+// terminateProcess sends SIGTERM and kills the process if it is still
+// running after nodeStopTimeout
+func terminateProcess(process *os.Process, exited chan error) error {
+	_ = process.Signal(syscall.SIGTERM)
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(nodeStopTimeout):
+		_ = process.Kill()
+		return <-exited
+	}
+}
+
+// stopSupervisor sends SIGTERM to the supervisor and waits for it to take
+// avalanchego down, killing both if that takes too long
+func stopSupervisor(ctx context.Context, dataDir string, pid int) error {
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("failed to signal supervisor %d: %w", pid, err)
+	}
+
+	deadline := time.Now().Add(nodeStopTimeout + 5*time.Second)
+	for processAlive(pid) && time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+
+	if processAlive(pid) {
+		_ = syscall.Kill(pid, syscall.SIGKILL)
+		if nodePid, alive := readAlivePid(filepath.Join(dataDir, avalanchegoPidFile)); alive {
+			_ = syscall.Kill(nodePid, syscall.SIGKILL)
+		}
+	}
+	removePidFiles(dataDir)
+	return nil
+}
+
+func saveSupervisorState(path string, state SupervisorState) error {
+	stateBytes, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal supervisor state: %w", err)
+	}
+	if err := helpers.SaveBytes(path, stateBytes); err != nil {
+		return fmt.Errorf("failed to save supervisor state: %w", err)
+	}
+	return nil
+}
+
+func removePidFiles(dataDir string) {
+	_ = os.Remove(filepath.Join(dataDir, supervisorPidFile))
+	_ = os.Remove(filepath.Join(dataDir, avalanchegoPidFile))
+}
+
+// readAlivePid returns the PID in the file and whether that process is running
+func readAlivePid(path string) (int, bool) {
+	text, err := helpers.LoadText(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(text)
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, processAlive(pid)
+}
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// managedNodeDataDir is the data dir of a node by name, also for nodes whose
+// add validator folder is gone
+func managedNodeDataDir(name string) string {
+	return filepath.Join("data", name)
+}
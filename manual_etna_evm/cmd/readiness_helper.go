@@ -0,0 +1,411 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanchego/api/health"
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/ava-labs/avalanchego/ids"
+	avagoconstants "github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+	"github.com/ava-labs/subnet-evm/ethclient"
+)
+
+const (
+	// NodeReadyTimeout is how long a freshly started node gets to bootstrap
+	// the P-chain and the L1
+	NodeReadyTimeout = 15 * time.Minute
+
+	readinessPollInterval   = 2 * time.Second
+	readinessRequestTimeout = 5 * time.Second
+	// readinessReportInterval repeats an unchanged status so a long bootstrap
+	// does not look stuck
+	readinessReportInterval = 30 * time.Second
+	// The L1 chain is only created after the P-chain is bootstrapped, a node
+	// that still does not know it after this long does not run it at all
+	readinessChainGracePeriod = 2 * time.Minute
+	// readinessMaxRestarts is how many crashes during the wait mean the node
+	// is crash looping rather than starting
+	readinessMaxRestarts = 3
+)
+
+// NodeReadiness waits until a node serves the L1: the node API answers on the
+// expected network, the P-chain and the L1 chain are bootstrapped, the health
+// checks pass and the L1 RPC serves its head block
+type NodeReadiness struct {
+	URI string
+	// ChainID is the L1 chain, ids.Empty only waits for the primary network
+	ChainID ids.ID
+	// SubnetID narrows the health checks to the L1, ids.Empty checks everything
+	SubnetID ids.ID
+
+	// Runner and NodeName are optional, with them the wait fails as soon as
+	// the node exits or keeps crashing instead of running into the timeout
+	Runner   NodeRunner
+	NodeName string
+}
+
+// NewNodeReadiness waits for the L1 of the workspace on a managed node
+func NewNodeReadiness(node ManagedNode, runner NodeRunner) (NodeReadiness, error) {
+	launch, err := LoadNodeLaunchConfig()
+	if err != nil {
+		return NodeReadiness{}, err
+	}
+	chainID, err := helpers.LoadId(helpers.ChainIdPath)
+	if err != nil {
+		return NodeReadiness{}, fmt.Errorf("failed to load chain ID: %w", err)
+	}
+	subnetID, err := ids.FromString(launch.SubnetID)
+	if err != nil {
+		return NodeReadiness{}, fmt.Errorf("failed to parse subnet ID %s: %w", launch.SubnetID, err)
+	}
+	return NodeReadiness{
+		URI:      node.URI(),
+		ChainID:  chainID,
+		SubnetID: subnetID,
+		Runner:   runner,
+		NodeName: node.Name,
+	}, nil
+}
+
+func (r NodeReadiness) name() string {
+	if r.NodeName != "" {
+		return r.NodeName
+	}
+	return r.URI
+}
+
+// readinessStatus is the outcome of one round of checks
+type readinessStatus struct {
+	Ready bool
+	Phase string
+	// Detail is progress or the reason the phase is not done yet
+	Detail string
+	// Fatal means waiting longer will not help
+	Fatal error
+}
+
+func (s readinessStatus) String() string {
+	if s.Detail == "" {
+		return s.Phase
+	}
+	return s.Phase + ": " + s.Detail
+}
+
+// readinessWaiter keeps what one round of checks needs to know about the
+// previous ones
+type readinessWaiter struct {
+	NodeReadiness
+	infoClient   info.Client
+	healthClient health.Client
+
+	initialRestarts  int
+	restartsKnown    bool
+	networkChecked   bool
+	pBootstrappedAt  time.Time
+	pChainHeight     uint64
+	pChainHeightErr  error
+	pChainHeightRead bool
+}
+
+// Wait polls the node until it is ready, ctx bounds the whole wait
+func (r NodeReadiness) Wait(ctx context.Context) error {
+	w := &readinessWaiter{
+		NodeReadiness: r,
+		infoClient:    info.NewClient(r.URI),
+		healthClient:  health.NewClient(r.URI),
+	}
+
+	var last readinessStatus
+	var lastReport time.Time
+	for {
+		status := w.check(ctx)
+		if status.Ready {
+			if status.Detail != "" {
+				log.Printf("✅ %s is ready, L1 %s\n", r.name(), status.Detail)
+				return nil
+			}
+			log.Printf("✅ %s is ready\n", r.name())
+			return nil
+		}
+		if status.Fatal != nil {
+			return WithErrorCode(ErrCodeNodeNotReady, fmt.Errorf("%s failed while %s: %w", r.name(), status.Phase, status.Fatal))
+		}
+		if status.String() != last.String() || time.Since(lastReport) >= readinessReportInterval {
+			log.Printf("⏳ %s: %s\n", r.name(), status)
+			lastReport = time.Now()
+		}
+		last = status
+
+		select {
+		case <-ctx.Done():
+			return WithErrorCode(ErrCodeNodeNotReady, fmt.Errorf("%s is not ready, still %s: %w", r.name(), status, ctx.Err()))
+		case <-time.After(readinessPollInterval):
+		}
+	}
+}
+
+func (w *readinessWaiter) check(ctx context.Context) readinessStatus {
+	if status, done := w.checkRunner(ctx); !done {
+		return status
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, readinessRequestTimeout)
+	defer cancel()
+
+	if !w.networkChecked {
+		networkID, err := w.infoClient.GetNetworkID(reqCtx)
+		if err != nil {
+			return readinessStatus{Phase: "starting", Detail: "node API is not answering yet"}
+		}
+		expectedID, err := expectedNetworkID()
+		if err != nil {
+			return readinessStatus{Phase: "starting", Fatal: err}
+		}
+		if networkID != expectedID {
+			return readinessStatus{Phase: "starting", Fatal: fmt.Errorf("node is on network %d but the workspace is on network %d", networkID, expectedID)}
+		}
+		w.networkChecked = true
+	}
+
+	if w.pBootstrappedAt.IsZero() {
+		bootstrapped, err := w.infoClient.IsBootstrapped(reqCtx, "P")
+		if err != nil {
+			return readinessStatus{Phase: "bootstrapping P-chain", Detail: err.Error()}
+		}
+		if !bootstrapped {
+			return readinessStatus{Phase: "bootstrapping P-chain", Detail: w.pChainProgress(reqCtx)}
+		}
+		w.pBootstrappedAt = time.Now()
+	}
+
+	if w.ChainID != ids.Empty {
+		bootstrapped, err := w.infoClient.IsBootstrapped(reqCtx, w.ChainID.String())
+		if err != nil {
+			if strings.Contains(err.Error(), "there is no chain") {
+				if time.Since(w.pBootstrappedAt) > readinessChainGracePeriod {
+					return readinessStatus{Phase: "bootstrapping L1", Fatal: fmt.Errorf("node does not run chain %s, check that it tracks subnet %s and has the subnet-evm plugin", w.ChainID, w.SubnetID)}
+				}
+				return readinessStatus{Phase: "bootstrapping L1", Detail: "waiting for the node to create the chain"}
+			}
+			return readinessStatus{Phase: "bootstrapping L1", Detail: err.Error()}
+		}
+		if !bootstrapped {
+			return readinessStatus{Phase: "bootstrapping L1", Detail: w.chainProgress(reqCtx, w.ChainID.String(), 0)}
+		}
+	}
+
+	var tags []string
+	if w.SubnetID != ids.Empty {
+		tags = []string{w.SubnetID.String()}
+	}
+	reply, err := w.healthClient.Health(reqCtx, tags)
+	if err != nil {
+		return readinessStatus{Phase: "health checks", Detail: err.Error()}
+	}
+	if !reply.Healthy {
+		return readinessStatus{Phase: "health checks", Detail: failingHealthChecks(reply)}
+	}
+
+	if w.ChainID != ids.Empty {
+		head, err := l1HeadBlock(reqCtx, w.URI, w.ChainID)
+		if err != nil {
+			return readinessStatus{Phase: "L1 blocks", Detail: err.Error()}
+		}
+		return readinessStatus{Ready: true, Detail: head}
+	}
+	return readinessStatus{Ready: true}
+}
+
+// checkRunner fails the wait if the node is gone or crash looping, done is
+// false with a status to report if the node is not running yet
+func (w *readinessWaiter) checkRunner(ctx context.Context) (readinessStatus, bool) {
+	if w.Runner == nil || w.NodeName == "" {
+		return readinessStatus{}, true
+	}
+	state, err := w.Runner.Status(ctx, w.NodeName)
+	if err != nil {
+		return readinessStatus{Phase: "starting", Detail: fmt.Sprintf("failed to inspect node: %s", err)}, false
+	}
+
+	if !w.restartsKnown {
+		w.initialRestarts = state.RestartCount
+		w.restartsKnown = true
+	}
+	logsHint := fmt.Sprintf("see go run . nodes logs %s", w.NodeName)
+
+	switch state.State {
+	case "missing":
+		return readinessStatus{Phase: "starting", Fatal: fmt.Errorf("node is not running, start it with: go run . nodes up %s", w.NodeName)}, false
+	case "exited", "dead":
+		reason := fmt.Sprintf("node exited with code %d", state.ExitCode)
+		if state.Error != "" {
+			reason += ": " + state.Error
+		}
+		return readinessStatus{Phase: "starting", Fatal: fmt.Errorf("%s, %s", reason, logsHint)}, false
+	}
+	if restarts := state.RestartCount - w.initialRestarts; restarts >= readinessMaxRestarts {
+		return readinessStatus{Phase: "starting", Fatal: fmt.Errorf("node crashed %d times, last exit code %d, %s", restarts, state.ExitCode, logsHint)}, false
+	}
+	if state.State == "restarting" {
+		return readinessStatus{Phase: "starting", Detail: fmt.Sprintf("node is restarting after exit code %d", state.ExitCode)}, false
+	}
+	return readinessStatus{}, true
+}
+
+// pChainProgress estimates the bootstrap progress from the block counters of
+// the node against the P-chain height of the RPC node. A node that restarts
+// bootstrapping only fetches the missing blocks so the estimate is low then.
+func (w *readinessWaiter) pChainProgress(ctx context.Context) string {
+	if !w.pChainHeightRead {
+		w.pChainHeightRead = true
+		rpcURL, err := GetRPCURL()
+		if err == nil {
+			w.pChainHeight, err = platformvm.NewClient(rpcURL).GetHeight(ctx)
+		}
+		w.pChainHeightErr = err
+	}
+	target := uint64(0)
+	if w.pChainHeightErr == nil {
+		target = w.pChainHeight
+	}
+	return w.chainProgress(ctx, "P", target)
+}
+
+// chainProgress reports the fetch and execute phases of a bootstrapping
+// chain, target is the expected number of blocks or 0 if unknown
+func (w *readinessWaiter) chainProgress(ctx context.Context, chain string, target uint64) string {
+	counters, err := bootstrapCounters(ctx, w.URI, chain)
+	if err != nil {
+		return "bootstrapping"
+	}
+	if counters.accepted > 0 && counters.fetched > 0 {
+		return fmt.Sprintf("executing blocks %s (%d/%d)", percent(counters.accepted, counters.fetched), counters.accepted, counters.fetched)
+	}
+	if target > 0 {
+		return fmt.Sprintf("fetching blocks %s (%d/~%d)", percent(counters.fetched, target), counters.fetched, target)
+	}
+	return fmt.Sprintf("fetching blocks (%d fetched)", counters.fetched)
+}
+
+func percent(done uint64, total uint64) string {
+	if total == 0 {
+		return "0%"
+	}
+	p := done * 100 / total
+	if p > 99 {
+		// The counters run slightly ahead or behind the target until the
+		// phase is done
+		p = 99
+	}
+	return fmt.Sprintf("%d%%", p)
+}
+
+type blockCounters struct {
+	fetched  uint64
+	accepted uint64
+}
+
+// bootstrapCounters reads the bootstrap block counters of a chain from the
+// Prometheus metrics of the node
+func bootstrapCounters(ctx context.Context, uri string, chain string) (blockCounters, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri+"/ext/metrics", nil)
+	if err != nil {
+		return blockCounters{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return blockCounters{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return blockCounters{}, fmt.Errorf("metrics API answered %s", resp.Status)
+	}
+
+	label := fmt.Sprintf(`chain="%s"`, chain)
+	counters := blockCounters{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var target *uint64
+		switch {
+		case strings.HasPrefix(line, "avalanche_snowman_bs_fetched{"):
+			target = &counters.fetched
+		case strings.HasPrefix(line, "avalanche_snowman_bs_accepted{"):
+			target = &counters.accepted
+		default:
+			continue
+		}
+		if !strings.Contains(line, label) {
+			continue
+		}
+		fields := strings.Fields(line)
+		value, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			return blockCounters{}, fmt.Errorf("failed to parse metric %q: %w", line, err)
+		}
+		*target = uint64(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return blockCounters{}, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	return counters, nil
+}
+
+func failingHealthChecks(reply *health.APIReply) string {
+	failing := []string{}
+	for name, result := range reply.Checks {
+		if result.Error == nil {
+			continue
+		}
+		failing = append(failing, fmt.Sprintf("%s (%s)", name, *result.Error))
+	}
+	if len(failing) == 0 {
+		return "node reports unhealthy"
+	}
+	sort.Strings(failing)
+	return "failing " + strings.Join(failing, ", ")
+}
+
+// l1HeadBlock checks that the L1 RPC answers and serves its head block.
+// subnet-evm only builds blocks when there are transactions, so an idle L1
+// is ready once the head is served.
+func l1HeadBlock(ctx context.Context, uri string, chainID ids.ID) (string, error) {
+	client, err := ethclient.DialContext(ctx, fmt.Sprintf("%s/ext/bc/%s/rpc", uri, chainID))
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to L1 RPC: %w", err)
+	}
+	defer client.Close()
+
+	evmChainID, err := client.ChainID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("L1 RPC is not answering: %w", err)
+	}
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("L1 RPC does not serve the head block: %w", err)
+	}
+	return fmt.Sprintf("chain ID %d at block %d", evmChainID, head.Number), nil
+}
+
+// expectedNetworkID is the primary network the workspace was created on
+func expectedNetworkID() (uint32, error) {
+	selection, err := LoadNetworkSelection()
+	if err != nil {
+		return 0, err
+	}
+	if selection.IsLocal() {
+		return localNetworkID, nil
+	}
+	return avagoconstants.FujiID, nil
+}
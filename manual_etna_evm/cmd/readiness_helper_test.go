@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/api/health"
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/ava-labs/avalanchego/ids"
+	avagoconstants "github.com/ava-labs/avalanchego/utils/constants"
+	avajson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// fakeReadinessNode answers the info, health and L1 RPC requests a
+// readiness check makes
+type fakeReadinessNode struct {
+	chainID        ids.ID
+	networkID      uint32
+	pBootstrapped  bool
+	l1Bootstrapped bool
+	failingCheck   string
+	// head is the head block of the L1, nil if the RPC does not serve it
+	head *types.Header
+}
+
+func (n *fakeReadinessNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var result any
+	var err error
+	switch request.Method {
+	case "info.getNetworkID":
+		result = info.GetNetworkIDReply{NetworkID: avajson.Uint32(n.networkID)}
+	case "info.isBootstrapped":
+		var args info.IsBootstrappedArgs
+		if err := json.Unmarshal(request.Params, &args); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch args.Chain {
+		case "P":
+			result = info.IsBootstrappedResponse{IsBootstrapped: n.pBootstrapped}
+		case n.chainID.String():
+			result = info.IsBootstrappedResponse{IsBootstrapped: n.l1Bootstrapped}
+		default:
+			err = fmt.Errorf("there is no chain with alias/ID '%s'", args.Chain)
+		}
+	case "health.health":
+		reply := health.APIReply{Healthy: n.failingCheck == "", Checks: map[string]health.Result{}}
+		if n.failingCheck != "" {
+			message := "not ready"
+			reply.Checks[n.failingCheck] = health.Result{Error: &message}
+		}
+		result = reply
+	case "eth_chainId":
+		result = hexutil.Uint64(99)
+	case "eth_getBlockByNumber":
+		if n.head != nil {
+			result = n.head
+		}
+	default:
+		err = fmt.Errorf("unexpected method %s", request.Method)
+	}
+
+	response := map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": result}
+	if err != nil {
+		delete(response, "result")
+		response["error"] = map[string]any{"code": -32000, "message": err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func TestReadinessCheck(t *testing.T) {
+	chdirTemp(t)
+	chainID := ids.ID{9, 8, 7}
+	header := func(number int64, at time.Time) *types.Header {
+		return &types.Header{Number: big.NewInt(number), Time: uint64(at.Unix()), Difficulty: big.NewInt(1), GasLimit: 8_000_000}
+	}
+	ready := fakeReadinessNode{
+		chainID:        chainID,
+		networkID:      avagoconstants.FujiID,
+		pBootstrapped:  true,
+		l1Bootstrapped: true,
+		head:           header(3, time.Now()),
+	}
+
+	tests := []struct {
+		name       string
+		change     func(*fakeReadinessNode)
+		wantReady  bool
+		wantFatal  bool
+		wantPhase  string
+		wantDetail string
+	}{
+		{
+			name:       "head block served",
+			wantReady:  true,
+			wantDetail: "chain ID 99 at block 3",
+		},
+		{
+			// subnet-evm only builds blocks for transactions, the head of an
+			// idle L1 is as old as its last transaction or its genesis
+			name:       "idle L1 with an old head",
+			change:     func(n *fakeReadinessNode) { n.head = header(0, time.Now().Add(-24*time.Hour)) },
+			wantReady:  true,
+			wantDetail: "chain ID 99 at block 0",
+		},
+		{
+			name:      "wrong network",
+			change:    func(n *fakeReadinessNode) { n.networkID = avagoconstants.MainnetID },
+			wantFatal: true,
+			wantPhase: "starting",
+		},
+		{
+			name:      "bootstrapping the P-chain",
+			change:    func(n *fakeReadinessNode) { n.pBootstrapped = false },
+			wantPhase: "bootstrapping P-chain",
+		},
+		{
+			name:      "bootstrapping the L1",
+			change:    func(n *fakeReadinessNode) { n.l1Bootstrapped = false },
+			wantPhase: "bootstrapping L1",
+		},
+		{
+			name:       "failing health check",
+			change:     func(n *fakeReadinessNode) { n.failingCheck = "network" },
+			wantPhase:  "health checks",
+			wantDetail: "failing network (not ready)",
+		},
+		{
+			name:      "head block not served",
+			change:    func(n *fakeReadinessNode) { n.head = nil },
+			wantPhase: "L1 blocks",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := ready
+			if test.change != nil {
+				test.change(&node)
+			}
+			server := httptest.NewServer(&node)
+			defer server.Close()
+
+			w := &readinessWaiter{
+				NodeReadiness: NodeReadiness{URI: server.URL, ChainID: chainID},
+				infoClient:    info.NewClient(server.URL),
+				healthClient:  health.NewClient(server.URL),
+				// The progress estimate asks the public P-chain for its height
+				pChainHeightRead: true,
+			}
+			status := w.check(context.Background())
+			if status.Ready != test.wantReady || (status.Fatal != nil) != test.wantFatal {
+				t.Fatalf("status = %s, ready %v, fatal %v, want ready %v, fatal %v", status, status.Ready, status.Fatal, test.wantReady, test.wantFatal)
+			}
+			if status.Phase != test.wantPhase {
+				t.Errorf("phase = %q, want %q", status.Phase, test.wantPhase)
+			}
+			if test.wantDetail != "" && !strings.Contains(status.Detail, test.wantDetail) {
+				t.Errorf("detail = %q, want %q", status.Detail, test.wantDetail)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/pkg/l1"
 	"github.com/spf13/cobra"
 )
 
@@ -26,13 +27,13 @@ var GenerateNewValidatorKeys = &cobra.Command{
 		PrintHeader("🧱 Generating new validator keys")
 
 		if len(args) == 0 {
-			return fmt.Errorf("amount of validator keys is required")
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("amount of validator keys is required"))
 		}
 
 		// get node index id from args
 		count, err := strconv.Atoi(args[0])
 		if err != nil {
-			return fmt.Errorf("invalid amount of validator keys: %w", err)
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("invalid amount of validator keys: %w", err))
 		}
 
 		for i := 0; i < count; i++ {
@@ -72,7 +73,7 @@ var GenerateNewValidatorKeys = &cobra.Command{
 }
 
 func printBLSKeysOfValidator(folderPath string) error {
-	nodeId, proofOfPossession, err := NodeInfoFromCreds(folderPath)
+	nodeId, proofOfPossession, err := l1.NodeInfoFromCreds(folderPath)
 	if err != nil {
 		return fmt.Errorf("failed to get node info from creds: %w", err)
 	}
@@ -94,6 +95,18 @@ func printBLSKeysOfValidator(folderPath string) error {
 	fmt.Printf("- AVALANCHEGO_STAKING_TLS_CERT_FILE_CONTENT=%s\n", stakerCertBase64)
 	fmt.Printf("- BLS_KEY_BASE64=%s\n\n\n", signerKeyBase64)
 
+	AppendResult("validators", map[string]any{
+		"folder":            folderPath,
+		"nodeID":            nodeId.String(),
+		"publicKey":         publicKey,
+		"proofOfPossession": pop,
+		"env": map[string]string{
+			"AVALANCHEGO_STAKING_TLS_KEY_FILE_CONTENT":  stakerKeyBase64,
+			"AVALANCHEGO_STAKING_TLS_CERT_FILE_CONTENT": stakerCertBase64,
+			"BLS_KEY_BASE64": signerKeyBase64,
+		},
+	})
+
 	return nil
 }
 
@@ -103,7 +116,7 @@ var PrintBLSKeysOfValidator = &cobra.Command{
 	Long:  `Print BLS keys of validator`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
-			return fmt.Errorf("node index id is required")
+			return WithErrorCode(ErrCodeUsage, fmt.Errorf("node index id is required"))
 		}
 
 		// get node index id from args
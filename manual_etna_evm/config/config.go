@@ -5,6 +5,7 @@ const (
 	L1_CHAIN_ID               = 12345
 	ProxyContractAddress      = "0xFEEDC0DE0000000000000000000000000000000"
 	ProxyAdminContractAddress = "0xC0FFEE1234567890aBcDEF1234567890AbCdEf34"
+	NodeImage                 = "containerman17/avalanchego-subnetevm:v1.12.0_v0.7.0"
 
 	PoSNativeMode = "pos-native"
 	PoAMode       = "poa"
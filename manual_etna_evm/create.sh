@@ -3,10 +3,16 @@
 set -exuo pipefail
 
 export L1_VALIDATOR_TYPE="poa"
+# fuji or local, local runs everything offline against a tmpnet primary network
+export NETWORK="${NETWORK:-fuji}"
 
 echo "Building etnacli"
 go build -o ./etnacli .
 
+if [ "${NETWORK}" = "local" ]; then
+  ./etnacli local-network start
+fi
+
 ./etnacli generate-keys
 ./etnacli transfer-coins
 ./etnacli create-subnet
@@ -0,0 +1,171 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	DefaultHost = "unix:///var/run/docker.sock"
+	APIVersion  = "v1.41"
+)
+
+var (
+	ErrDaemonUnavailable = errors.New("docker daemon is not reachable")
+	ErrNotFound          = errors.New("not found")
+	ErrConflict          = errors.New("conflict")
+	ErrNotModified       = errors.New("not modified")
+)
+
+// APIError is a non 2xx answer of the Docker Engine API
+type APIError struct {
+	Op         string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("docker %s: %d %s", e.Op, e.StatusCode, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrNotModified:
+		return e.StatusCode == http.StatusNotModified
+	}
+	return false
+}
+
+// Client talks to the Docker Engine API, by default over the local socket
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient connects to $DOCKER_HOST or the local socket if it is not set
+func NewClient() (*Client, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = DefaultHost
+	}
+	return NewClientWithHost(host)
+}
+
+// NewClientWithHost accepts unix://, tcp:// and http:// hosts
+func NewClientWithHost(host string) (*Client, error) {
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %s: %w", host, err)
+	}
+
+	switch hostURL.Scheme {
+	case "unix":
+		socketPath := hostURL.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		}
+		return &Client{
+			httpClient: &http.Client{Transport: transport},
+			baseURL:    "http://docker/" + APIVersion,
+		}, nil
+	case "tcp", "http":
+		return &Client{
+			httpClient: &http.Client{},
+			baseURL:    "http://" + hostURL.Host + "/" + APIVersion,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme %q", hostURL.Scheme)
+	}
+}
+
+// do sends a request and returns the response for 2xx answers, the caller
+// has to close the body. Everything else is turned into an *APIError.
+func (c *Client) do(ctx context.Context, op string, method string, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("docker %s: failed to marshal request: %w", op, err)
+		}
+		reader = bytes.NewReader(bodyBytes)
+	}
+
+	requestURL := c.baseURL + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
+	if err != nil {
+		return nil, fmt.Errorf("docker %s: failed to create request: %w", op, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("docker %s: %w", op, ctx.Err())
+		}
+		return nil, fmt.Errorf("docker %s: %w: %s", op, ErrDaemonUnavailable, err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	apiErr := &APIError{Op: op, StatusCode: resp.StatusCode}
+	var errorBody struct {
+		Message string `json:"message"`
+	}
+	respBytes, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(respBytes, &errorBody); err == nil && errorBody.Message != "" {
+		apiErr.Message = errorBody.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(respBytes))
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return nil, apiErr
+}
+
+// doJSON sends a request and decodes the JSON answer into out if it is not nil
+func (c *Client) doJSON(ctx context.Context, op string, method string, path string, query url.Values, body interface{}, out interface{}) error {
+	resp, err := c.do(ctx, op, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("docker %s: failed to decode response: %w", op, err)
+	}
+	return nil
+}
+
+// Ping checks that the daemon is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	return c.doJSON(ctx, "ping", http.MethodGet, "/_ping", nil, nil, nil)
+}
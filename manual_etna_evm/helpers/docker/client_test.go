@@ -0,0 +1,227 @@
+package docker_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers/docker"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers/docker/dockertest"
+)
+
+const testImage = "avalanchego:test"
+
+func newTestClient(t *testing.T) (*docker.Client, *dockertest.Engine) {
+	t.Helper()
+	engine := dockertest.NewEngine(t)
+	engine.AddImage(testImage)
+	client, err := docker.NewClientWithHost(engine.Host)
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("failed to ping: %s", err)
+	}
+	return client, engine
+}
+
+func TestAPIErrorIs(t *testing.T) {
+	tests := []struct {
+		status int
+		target error
+		want   bool
+	}{
+		{http.StatusNotFound, docker.ErrNotFound, true},
+		{http.StatusNotFound, docker.ErrConflict, false},
+		{http.StatusConflict, docker.ErrConflict, true},
+		{http.StatusConflict, docker.ErrNotFound, false},
+		{http.StatusNotModified, docker.ErrNotModified, true},
+		{http.StatusInternalServerError, docker.ErrNotFound, false},
+		{http.StatusInternalServerError, docker.ErrDaemonUnavailable, false},
+	}
+	for _, test := range tests {
+		err := error(&docker.APIError{Op: "test", StatusCode: test.status, Message: "message"})
+		if got := errors.Is(err, test.target); got != test.want {
+			t.Errorf("errors.Is(%d, %v) = %v, want %v", test.status, test.target, got, test.want)
+		}
+	}
+}
+
+func TestContainerLifecycle(t *testing.T) {
+	ctx := context.Background()
+	client, engine := newTestClient(t)
+
+	config := docker.ContainerConfig{
+		Image:  testImage,
+		User:   "1000:1000",
+		Env:    []string{"AVALANCHEGO_HTTP_PORT=9650"},
+		Labels: map[string]string{"etna-devnet.node": "node0"},
+		HostConfig: docker.HostConfig{
+			NetworkMode:   "host",
+			Binds:         []string{"/data:/data"},
+			RestartPolicy: docker.RestartPolicy{Name: "unless-stopped"},
+		},
+	}
+	id, err := client.CreateContainer(ctx, "node0", config)
+	if err != nil {
+		t.Fatalf("failed to create: %s", err)
+	}
+	if id == "" {
+		t.Fatal("create returned no ID")
+	}
+	request, ok := engine.LastRequest(http.MethodPost, "/containers/create")
+	if !ok {
+		t.Fatal("no create request")
+	}
+	if name := request.Query.Get("name"); name != "node0" {
+		t.Errorf("create name = %q, want node0", name)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(request.Body, &body); err != nil {
+		t.Fatalf("create body is not JSON: %s", err)
+	}
+	hostConfig, _ := body["HostConfig"].(map[string]any)
+	restartPolicy, _ := hostConfig["RestartPolicy"].(map[string]any)
+	if body["Image"] != testImage || body["User"] != "1000:1000" || hostConfig["NetworkMode"] != "host" || restartPolicy["Name"] != "unless-stopped" {
+		t.Errorf("unexpected create body %s", request.Body)
+	}
+	if _, ok := body["Cmd"]; ok {
+		t.Errorf("create body has an empty Cmd: %s", request.Body)
+	}
+
+	if _, err := client.CreateContainer(ctx, "node0", config); !errors.Is(err, docker.ErrConflict) {
+		t.Errorf("second create error = %v, want ErrConflict", err)
+	}
+
+	if err := client.StartContainer(ctx, "node0"); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+	if err := client.StartContainer(ctx, "node0"); err != nil {
+		t.Errorf("starting a running container failed: %s", err)
+	}
+	if err := client.RemoveContainer(ctx, "node0", false); !errors.Is(err, docker.ErrConflict) {
+		t.Errorf("removing a running container error = %v, want ErrConflict", err)
+	}
+
+	engine.Container(t, "node0", func(c *dockertest.Container) {
+		c.Health = "healthy"
+		c.RestartCount = 2
+	})
+	info, err := client.InspectContainer(ctx, "node0")
+	if err != nil {
+		t.Fatalf("failed to inspect: %s", err)
+	}
+	if info.ID != id || !info.State.Running || info.State.Status != "running" || info.RestartCount != 2 {
+		t.Errorf("unexpected inspect %+v", info)
+	}
+	if info.State.Health == nil || info.State.Health.Status != "healthy" {
+		t.Errorf("health = %+v, want healthy", info.State.Health)
+	}
+	if info.Config.Labels["etna-devnet.node"] != "node0" {
+		t.Errorf("labels = %v, want the node label", info.Config.Labels)
+	}
+
+	if err := client.StopContainer(ctx, "node0", 30*time.Second); err != nil {
+		t.Fatalf("failed to stop: %s", err)
+	}
+	request, _ = engine.LastRequest(http.MethodPost, "/containers/node0/stop")
+	if timeout := request.Query.Get("t"); timeout != "30" {
+		t.Errorf("stop timeout = %q, want 30", timeout)
+	}
+	if err := client.StopContainer(ctx, "node0", time.Second); err != nil {
+		t.Errorf("stopping a stopped container failed: %s", err)
+	}
+
+	if err := client.RemoveContainer(ctx, "node0", true); err != nil {
+		t.Fatalf("failed to remove: %s", err)
+	}
+	request, _ = engine.LastRequest(http.MethodDelete, "/containers/node0")
+	if force := request.Query.Get("force"); force != "true" {
+		t.Errorf("remove force = %q, want true", force)
+	}
+	if _, err := client.InspectContainer(ctx, "node0"); !errors.Is(err, docker.ErrNotFound) {
+		t.Errorf("inspect of a removed container error = %v, want ErrNotFound", err)
+	}
+	if err := client.StartContainer(ctx, "node0"); !errors.Is(err, docker.ErrNotFound) {
+		t.Errorf("start of a removed container error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestContainerLogs(t *testing.T) {
+	frames := []dockertest.LogFrame{
+		{Stream: 1, Data: "starting\n"},
+		{Stream: 2, Data: "warning: low disk\n"},
+		{Stream: 1, Data: "bootstrapped\n"},
+		{Stream: 2, Data: ""},
+	}
+	tests := []struct {
+		name       string
+		tty        bool
+		wantStdout string
+		wantStderr string
+	}{
+		{"multiplexed", false, "starting\nbootstrapped\n", "warning: low disk\n"},
+		{"tty", true, "starting\nwarning: low disk\nbootstrapped\n", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, engine := newTestClient(t)
+			engine.AddContainer("node0", dockertest.Container{
+				Config: docker.ContainerConfig{Image: testImage, Tty: test.tty},
+				Logs:   frames,
+			})
+
+			var stdout, stderr bytes.Buffer
+			if err := client.ContainerLogs(context.Background(), "node0", docker.LogsOptions{Tail: 100}, &stdout, &stderr); err != nil {
+				t.Fatalf("failed to read logs: %s", err)
+			}
+			if stdout.String() != test.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), test.wantStdout)
+			}
+			if stderr.String() != test.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), test.wantStderr)
+			}
+			request, _ := engine.LastRequest(http.MethodGet, "/containers/node0/logs")
+			if request.Query.Get("tail") != "100" || request.Query.Get("stdout") != "true" || request.Query.Get("stderr") != "true" || request.Query.Get("follow") != "false" {
+				t.Errorf("unexpected logs query %v", request.Query)
+			}
+		})
+	}
+
+	client, _ := newTestClient(t)
+	if err := client.ContainerLogs(context.Background(), "missing", docker.LogsOptions{}, &bytes.Buffer{}, &bytes.Buffer{}); !errors.Is(err, docker.ErrNotFound) {
+		t.Errorf("logs of a missing container error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestListContainers(t *testing.T) {
+	client, engine := newTestClient(t)
+	engine.AddContainer("node0", dockertest.Container{Config: docker.ContainerConfig{Image: testImage, Labels: map[string]string{"etna-devnet.node": "node0"}}})
+	engine.AddContainer("other", dockertest.Container{Config: docker.ContainerConfig{Image: testImage}})
+
+	containers, err := client.ListContainers(context.Background(), "etna-devnet.node")
+	if err != nil {
+		t.Fatalf("failed to list: %s", err)
+	}
+	if len(containers) != 1 || containers[0].Name() != "node0" {
+		t.Errorf("containers = %+v, want node0 only", containers)
+	}
+	request, _ := engine.LastRequest(http.MethodGet, "/containers/json")
+	if request.Query.Get("all") != "true" || request.Query.Get("filters") != `{"label":["etna-devnet.node"]}` {
+		t.Errorf("unexpected list query %v", request.Query)
+	}
+}
+
+func TestDaemonUnavailable(t *testing.T) {
+	client, err := docker.NewClientWithHost("unix:///nonexistent/docker.sock")
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	if err := client.Ping(context.Background()); !errors.Is(err, docker.ErrDaemonUnavailable) {
+		t.Errorf("ping error = %v, want ErrDaemonUnavailable", err)
+	}
+}
@@ -0,0 +1,259 @@
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RestartPolicy struct {
+	Name string `json:"Name"`
+}
+
+type HostConfig struct {
+	NetworkMode   string        `json:"NetworkMode,omitempty"`
+	Binds         []string      `json:"Binds,omitempty"`
+	RestartPolicy RestartPolicy `json:"RestartPolicy,omitempty"`
+}
+
+// ContainerConfig is the subset of the create container body this tool needs
+type ContainerConfig struct {
+	Image      string            `json:"Image"`
+	User       string            `json:"User,omitempty"`
+	Env        []string          `json:"Env,omitempty"`
+	Cmd        []string          `json:"Cmd,omitempty"`
+	Tty        bool              `json:"Tty,omitempty"`
+	Labels     map[string]string `json:"Labels,omitempty"`
+	HostConfig HostConfig        `json:"HostConfig"`
+}
+
+type Health struct {
+	Status        string `json:"Status"`
+	FailingStreak int    `json:"FailingStreak"`
+}
+
+type ContainerState struct {
+	Status     string  `json:"Status"`
+	Running    bool    `json:"Running"`
+	Restarting bool    `json:"Restarting"`
+	OOMKilled  bool    `json:"OOMKilled"`
+	ExitCode   int     `json:"ExitCode"`
+	Error      string  `json:"Error"`
+	StartedAt  string  `json:"StartedAt"`
+	FinishedAt string  `json:"FinishedAt"`
+	Health     *Health `json:"Health,omitempty"`
+}
+
+type ContainerInfo struct {
+	ID           string          `json:"Id"`
+	Name         string          `json:"Name"`
+	Image        string          `json:"Image"`
+	RestartCount int             `json:"RestartCount"`
+	State        ContainerState  `json:"State"`
+	Config       ContainerConfig `json:"Config"`
+}
+
+type ContainerSummary struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Status string            `json:"Status"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// Name returns the container name without the leading slash
+func (s ContainerSummary) Name() string {
+	if len(s.Names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(s.Names[0], "/")
+}
+
+// CreateContainer creates a named container and returns its ID
+func (c *Client) CreateContainer(ctx context.Context, name string, config ContainerConfig) (string, error) {
+	var created struct {
+		ID       string   `json:"Id"`
+		Warnings []string `json:"Warnings"`
+	}
+	err := c.doJSON(ctx, "create "+name, http.MethodPost, "/containers/create", url.Values{"name": {name}}, config, &created)
+	if err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// StartContainer starts a container, starting a running one is not an error
+func (c *Client) StartContainer(ctx context.Context, name string) error {
+	err := c.doJSON(ctx, "start "+name, http.MethodPost, "/containers/"+url.PathEscape(name)+"/start", nil, nil, nil)
+	if errors.Is(err, ErrNotModified) {
+		return nil
+	}
+	return err
+}
+
+// StopContainer stops a container and kills it after timeout, stopping a
+// stopped one is not an error
+func (c *Client) StopContainer(ctx context.Context, name string, timeout time.Duration) error {
+	query := url.Values{"t": {strconv.Itoa(int(timeout.Seconds()))}}
+	err := c.doJSON(ctx, "stop "+name, http.MethodPost, "/containers/"+url.PathEscape(name)+"/stop", query, nil, nil)
+	if errors.Is(err, ErrNotModified) {
+		return nil
+	}
+	return err
+}
+
+// RestartContainer stops and starts a container
+func (c *Client) RestartContainer(ctx context.Context, name string, timeout time.Duration) error {
+	query := url.Values{"t": {strconv.Itoa(int(timeout.Seconds()))}}
+	return c.doJSON(ctx, "restart "+name, http.MethodPost, "/containers/"+url.PathEscape(name)+"/restart", query, nil, nil)
+}
+
+// RemoveContainer removes a container, force also removes a running one
+func (c *Client) RemoveContainer(ctx context.Context, name string, force bool) error {
+	query := url.Values{"force": {strconv.FormatBool(force)}}
+	return c.doJSON(ctx, "remove "+name, http.MethodDelete, "/containers/"+url.PathEscape(name), query, nil, nil)
+}
+
+// InspectContainer returns ErrNotFound if there is no such container
+func (c *Client) InspectContainer(ctx context.Context, name string) (*ContainerInfo, error) {
+	var info ContainerInfo
+	err := c.doJSON(ctx, "inspect "+name, http.MethodGet, "/containers/"+url.PathEscape(name)+"/json", nil, nil, &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ListContainers lists running and stopped containers that carry the label,
+// either "key" or "key=value"
+func (c *Client) ListContainers(ctx context.Context, label string) ([]ContainerSummary, error) {
+	query := url.Values{"all": {"true"}}
+	if label != "" {
+		filters, err := json.Marshal(map[string][]string{"label": {label}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal filters: %w", err)
+		}
+		query.Set("filters", string(filters))
+	}
+
+	var containers []ContainerSummary
+	if err := c.doJSON(ctx, "list containers", http.MethodGet, "/containers/json", query, nil, &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// ImageExists reports whether the image is available locally
+func (c *Client) ImageExists(ctx context.Context, image string) (bool, error) {
+	err := c.doJSON(ctx, "inspect image "+image, http.MethodGet, "/images/"+image+"/json", nil, nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PullImage pulls an image and waits for the pull to finish
+func (c *Client) PullImage(ctx context.Context, image string) error {
+	name, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, tag = image[:i], image[i+1:]
+	}
+
+	resp, err := c.do(ctx, "pull "+image, http.MethodPost, "/images/create", url.Values{"fromImage": {name}, "tag": {tag}}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The daemon answers 200 right away and reports failures inside the progress stream
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var progress struct {
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&progress); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("docker pull %s: failed to read progress: %w", image, err)
+		}
+		if progress.Error != "" {
+			return &APIError{Op: "pull " + image, StatusCode: http.StatusInternalServerError, Message: progress.Error}
+		}
+	}
+}
+
+type LogsOptions struct {
+	Follow bool
+	// Tail is the number of lines from the end, 0 or less means all
+	Tail int
+}
+
+// ContainerLogs copies stdout and stderr of a container until the logs end,
+// or until ctx is cancelled when following
+func (c *Client) ContainerLogs(ctx context.Context, name string, options LogsOptions, stdout io.Writer, stderr io.Writer) error {
+	query := url.Values{
+		"stdout": {"true"},
+		"stderr": {"true"},
+		"follow": {strconv.FormatBool(options.Follow)},
+		"tail":   {"all"},
+	}
+	if options.Tail > 0 {
+		query.Set("tail", strconv.Itoa(options.Tail))
+	}
+
+	info, err := c.InspectContainer(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, "logs "+name, http.MethodGet, "/containers/"+url.PathEscape(name)+"/logs", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Containers without a TTY get stdout and stderr multiplexed into one stream
+	if !info.Config.Tty {
+		err = demuxLogs(resp.Body, stdout, stderr)
+	} else {
+		_, err = io.Copy(stdout, resp.Body)
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// demuxLogs splits the multiplexed log stream, every frame starts with an
+// 8 byte header: stream type, 3 zero bytes and the big endian payload size
+func demuxLogs(r io.Reader, stdout io.Writer, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read log frame header: %w", err)
+		}
+
+		out := stdout
+		if header[0] == 2 {
+			out = stderr
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:8]))
+		if _, err := io.CopyN(out, r, size); err != nil {
+			return fmt.Errorf("failed to read log frame: %w", err)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+// Package dockertest serves a fake Docker Engine API on a unix socket, enough
+// of it for the containers this tool manages
+package dockertest
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers/docker"
+)
+
+// Request is a request the engine received, the path without the API version
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   []byte
+}
+
+// LogFrame is a chunk of the output of a container, Stream is 1 for stdout
+// and 2 for stderr
+type LogFrame struct {
+	Stream byte
+	Data   string
+}
+
+// Container is a container of the engine, tests may change its fields
+// between requests through Container
+type Container struct {
+	ID           string
+	Config       docker.ContainerConfig
+	Running      bool
+	ExitCode     int
+	RestartCount int
+	OOMKilled    bool
+	Error        string
+	// Health is the status of the health check, empty for none
+	Health string
+	Logs   []LogFrame
+}
+
+// Engine is the fake daemon, Host is what docker.NewClientWithHost takes
+type Engine struct {
+	Host string
+
+	mu         sync.Mutex
+	containers map[string]*Container
+	images     map[string]bool
+	requests   []Request
+	nextID     int
+}
+
+// NewEngine starts an engine that is stopped when the test ends
+func NewEngine(t testing.TB) *Engine {
+	t.Helper()
+	// Socket paths are limited to about 100 bytes, t.TempDir is often longer
+	dir, err := os.MkdirTemp("", "docker")
+	if err != nil {
+		t.Fatalf("failed to create socket folder: %s", err)
+	}
+	socket := filepath.Join(dir, "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %s", socket, err)
+	}
+
+	e := &Engine{
+		Host:       "unix://" + socket,
+		containers: map[string]*Container{},
+		images:     map[string]bool{},
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(e.serve))
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+	return e
+}
+
+// AddImage makes the image available locally
+func (e *Engine) AddImage(image string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.images[image] = true
+}
+
+// HasImage reports whether the image was added or pulled
+func (e *Engine) HasImage(image string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.images[image]
+}
+
+// AddContainer adds a container as if it was created earlier
+func (e *Engine) AddContainer(name string, container Container) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if container.ID == "" {
+		container.ID = e.newID()
+	}
+	e.containers[name] = &container
+}
+
+// Container runs change on the container under the lock, it fails the test
+// if there is no such container
+func (e *Engine) Container(t testing.TB, name string, change func(*Container)) {
+	t.Helper()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	container, ok := e.containers[name]
+	if !ok {
+		t.Fatalf("no container %s", name)
+	}
+	change(container)
+}
+
+// Exists reports whether the container exists
+func (e *Engine) Exists(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, ok := e.containers[name]
+	return ok
+}
+
+// Requests is every request received so far
+func (e *Engine) Requests() []Request {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Request{}, e.requests...)
+}
+
+// LastRequest is the last request with the method and path, ok is false if
+// there was none
+func (e *Engine) LastRequest(method string, path string) (Request, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := len(e.requests) - 1; i >= 0; i-- {
+		if e.requests[i].Method == method && e.requests[i].Path == path {
+			return e.requests[i], true
+		}
+	}
+	return Request{}, false
+}
+
+func (e *Engine) newID() string {
+	e.nextID++
+	return fmt.Sprintf("%064x", e.nextID)
+}
+
+func (e *Engine) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	path := strings.TrimPrefix(r.URL.Path, "/"+docker.APIVersion)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests = append(e.requests, Request{Method: r.Method, Path: path, Query: r.URL.Query(), Body: body})
+
+	switch {
+	case path == "/_ping":
+		_, _ = io.WriteString(w, "OK")
+	case r.Method == http.MethodPost && path == "/containers/create":
+		e.create(w, r.URL.Query().Get("name"), body)
+	case r.Method == http.MethodGet && path == "/containers/json":
+		e.list(w, r.URL.Query().Get("filters"))
+	case r.Method == http.MethodPost && path == "/images/create":
+		image := r.URL.Query().Get("fromImage") + ":" + r.URL.Query().Get("tag")
+		e.images[image] = true
+		writeJSON(w, http.StatusOK, map[string]string{"status": "Downloaded newer image for " + image})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/images/") && strings.HasSuffix(path, "/json"):
+		image := strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/json")
+		if !e.images[image] {
+			writeError(w, http.StatusNotFound, "No such image: "+image)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"Id": "sha256:" + image})
+	case strings.HasPrefix(path, "/containers/"):
+		name, action, _ := strings.Cut(strings.TrimPrefix(path, "/containers/"), "/")
+		e.container(w, r, name, action)
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+func (e *Engine) create(w http.ResponseWriter, name string, body []byte) {
+	var config docker.ContainerConfig
+	if err := json.Unmarshal(body, &config); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, ok := e.containers[name]; ok {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Conflict. The container name \"/%s\" is already in use", name))
+		return
+	}
+	if !e.images[config.Image] {
+		writeError(w, http.StatusNotFound, "No such image: "+config.Image)
+		return
+	}
+	container := &Container{ID: e.newID(), Config: config}
+	e.containers[name] = container
+	writeJSON(w, http.StatusCreated, map[string]any{"Id": container.ID, "Warnings": []string{}})
+}
+
+func (e *Engine) list(w http.ResponseWriter, filters string) {
+	var label string
+	if filters != "" {
+		var parsed map[string][]string
+		if err := json.Unmarshal([]byte(filters), &parsed); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if labels := parsed["label"]; len(labels) > 0 {
+			label = labels[0]
+		}
+	}
+	key, value, hasValue := strings.Cut(label, "=")
+
+	summaries := []docker.ContainerSummary{}
+	for name, container := range e.containers {
+		labelValue, ok := container.Config.Labels[key]
+		if label != "" && (!ok || (hasValue && labelValue != value)) {
+			continue
+		}
+		summaries = append(summaries, docker.ContainerSummary{
+			ID:     container.ID,
+			Names:  []string{"/" + name},
+			Image:  container.Config.Image,
+			State:  container.status(),
+			Labels: container.Config.Labels,
+		})
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (e *Engine) container(w http.ResponseWriter, r *http.Request, name string, action string) {
+	container, ok := e.containers[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such container: "+name)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && action == "json":
+		info := docker.ContainerInfo{
+			ID:           container.ID,
+			Name:         "/" + name,
+			Image:        container.Config.Image,
+			RestartCount: container.RestartCount,
+			State: docker.ContainerState{
+				Status:    container.status(),
+				Running:   container.Running,
+				OOMKilled: container.OOMKilled,
+				ExitCode:  container.ExitCode,
+				Error:     container.Error,
+			},
+			Config: container.Config,
+		}
+		if container.Health != "" {
+			info.State.Health = &docker.Health{Status: container.Health}
+		}
+		writeJSON(w, http.StatusOK, info)
+	case r.Method == http.MethodPost && action == "start":
+		if container.Running {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		container.Running, container.ExitCode = true, 0
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && action == "stop":
+		if !container.Running {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		container.Running = false
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && action == "restart":
+		container.Running = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && action == "":
+		if container.Running && r.URL.Query().Get("force") != "true" {
+			writeError(w, http.StatusConflict, "You cannot remove a running container "+container.ID+". Stop the container before attempting removal or force remove")
+			return
+		}
+		delete(e.containers, name)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && action == "logs":
+		w.WriteHeader(http.StatusOK)
+		for _, frame := range container.Logs {
+			if container.Config.Tty {
+				_, _ = io.WriteString(w, frame.Data)
+				continue
+			}
+			header := make([]byte, 8)
+			header[0] = frame.Stream
+			binary.BigEndian.PutUint32(header[4:], uint32(len(frame.Data)))
+			_, _ = w.Write(append(header, frame.Data...))
+		}
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+func (c *Container) status() string {
+	if c.Running {
+		return "running"
+	}
+	return "exited"
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
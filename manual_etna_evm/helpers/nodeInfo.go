@@ -3,7 +3,6 @@ package helpers
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/ava-labs/avalanchego/api/info"
@@ -11,20 +10,15 @@ import (
 	"github.com/ava-labs/avalanchego/vms/platformvm/signer"
 )
 
-// Naively retries getting node info from the node until it succeeds
-func GetNodeInfoRetry(endpoint string) (nodeID ids.NodeID, proofOfPossession *signer.ProofOfPossession, err error) {
-	infoClient := info.NewClient(endpoint)
-	log.Printf("Getting node info from %s\n", endpoint)
+// GetNodeInfo gets the node ID and BLS proof of possession of a node that is
+// already up, it does not retry and gives up after 10 seconds
+func GetNodeInfo(ctx context.Context, endpoint string) (nodeID ids.NodeID, proofOfPossession *signer.ProofOfPossession, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-	for i := 0; i < 10; i++ {
-		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-		defer cancel()
-
-		nodeID, proofOfPossession, err = infoClient.GetNodeID(ctx)
-		if err == nil {
-			return
-		}
-		time.Sleep(time.Duration(i) * time.Second)
+	nodeID, proofOfPossession, err = info.NewClient(endpoint).GetNodeID(ctx)
+	if err != nil {
+		return ids.NodeID{}, nil, fmt.Errorf("failed to get node info from %s: %w", endpoint, err)
 	}
-	return ids.NodeID{}, nil, fmt.Errorf("failed to get node info after 10 retries")
+	return nodeID, proofOfPossession, nil
 }
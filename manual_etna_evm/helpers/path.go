@@ -8,6 +8,15 @@ var (
 	InitializeValidatorSetTxPath = "data/initialize_validator_set_tx.txt"
 	L1GenesisPath                = "data/L1-genesis.json"
 	Node0KeysFolder              = "data/node0/staking/"
+	ChainConfigSelectionPath     = "data/chain_config.json"
+	NodesComposePath             = "data/docker-compose.yml"
+	NodeRunnerPath               = "data/node_runner.txt"
+	NetworkSelectionPath         = "data/network.json"
+	TmpnetRootDir                = "data/tmpnet"
+	WarpIndexPath                = "data/warp_index.json"
+	WalletSnapshotPath           = "data/wallet_snapshot.json"
+	LogsFollowCursorPath         = "data/logs_follow_cursor.json"
+	ValidatorIndexPath           = "data/validator_index.db"
 
 	ExampleRewardCalculatorAddressPath = "data/example_reward_calculator_address.txt"
 )
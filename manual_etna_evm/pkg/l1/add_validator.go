@@ -0,0 +1,222 @@
+package l1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ava-labs/avalanche-cli/pkg/constants"
+	"github.com/ava-labs/avalanche-cli/pkg/contract"
+	"github.com/ava-labs/avalanche-cli/pkg/evm"
+	validatorManagerSDK "github.com/ava-labs/avalanche-cli/sdk/validatormanager"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+	goethereumcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// Registration is what adding a validator produced
+type Registration struct {
+	ValidationID ids.ID
+	Expiry       uint64
+	// InitializeTxHash is empty if the node was registered in the contract
+	// by an earlier attempt
+	InitializeTxHash goethereumcommon.Hash
+	RegisterTxID     ids.ID
+	CompleteTxHash   goethereumcommon.Hash
+}
+
+// AddValidator registers the validator in the contract, on the P-chain and
+// completes the registration in the contract. The signer owns the remaining
+// balance and may disable the validator.
+func (m *ValidatorManager) AddValidator(ctx context.Context, validator Validator) (*Registration, error) {
+	return m.AddValidatorWithExpiry(ctx, validator, NewRegistrationExpiry())
+}
+
+// NewRegistrationExpiry is the default deadline for the P-chain to register a
+// validator, as a unix timestamp
+func NewRegistrationExpiry() uint64 {
+	return uint64(time.Now().Add(constants.DefaultValidationIDExpiryDuration).Unix())
+}
+
+// AddValidatorWithExpiry is AddValidator with the registration expiry of an
+// earlier, interrupted attempt. The contract only signs the message of the
+// registration again if the expiry matches. A validator that is already in
+// the P-chain validator set is not registered again. Once the expiry passed
+// the registration is invalidated and starts over with a new one, the
+// returned Registration has the expiry used.
+func (m *ValidatorManager) AddValidatorWithExpiry(ctx context.Context, validator Validator, expiry uint64) (*Registration, error) {
+	if expiry <= uint64(time.Now().Unix()) {
+		var err error
+		expiry, err = m.resumeExpiry(ctx, validator.NodeID, expiry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	message, registration, err := m.InitializeValidatorRegistration(ctx, validator, expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	isValidator, err := m.L1.IsValidator(ctx, validator.NodeID)
+	if err != nil {
+		return registration, err
+	}
+	if !isValidator {
+		registration.RegisterTxID, err = m.L1.RegisterValidator(ctx, validator, message)
+		if err != nil {
+			return registration, fmt.Errorf("failed to register L1 validator on P-chain: %w", err)
+		}
+	} else {
+		m.L1.Workspace.Log.Printf("%s is already in the P-chain validator set, skipping registration\n", validator.NodeID)
+	}
+
+	registration.CompleteTxHash, err = m.CompleteValidatorRegistration(ctx, registration.ValidationID)
+	if err != nil {
+		return registration, err
+	}
+	return registration, nil
+}
+
+// InitializeValidatorRegistration is step 1 of adding a validator: the
+// contract emits a RegisterL1Validator message, which is returned signed by
+// the L1 validators. A node that is already registered is not an error, the
+// message is signed again.
+func (m *ValidatorManager) InitializeValidatorRegistration(ctx context.Context, validator Validator, expiry uint64) (*warp.Message, *Registration, error) {
+	owner := warpMessage.PChainOwner{
+		Threshold: 1,
+		Addresses: []ids.ShortID{m.L1.Workspace.Signer.Address()},
+	}
+	registration := &Registration{Expiry: expiry}
+
+	type PChainOwner struct {
+		Threshold uint32
+		Addresses []goethereumcommon.Address
+	}
+	type ValidatorRegistrationInput struct {
+		NodeID                []byte
+		BlsPublicKey          []byte
+		RegistrationExpiry    uint64
+		RemainingBalanceOwner PChainOwner
+		DisableOwner          PChainOwner
+	}
+	ownerAux := PChainOwner{
+		Threshold: owner.Threshold,
+		Addresses: []goethereumcommon.Address{goethereumcommon.BytesToAddress(owner.Addresses[0][:])},
+	}
+	validatorRegistrationInput := ValidatorRegistrationInput{
+		NodeID:                validator.NodeID[:],
+		BlsPublicKey:          validator.Signer.PublicKey[:],
+		RegistrationExpiry:    expiry,
+		RemainingBalanceOwner: ownerAux,
+		DisableOwner:          ownerAux,
+	}
+
+	// Registering adds the weight to the churn of the period, a node that an
+	// earlier attempt registered already did
+	var unlock func()
+	if _, err := m.ValidationID(ctx, validator.NodeID); errors.Is(err, ErrValidatorNotFound) {
+		unlock, err = m.lockChurnRoom(ctx, validator.Weight)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to register %s: %w", validator.NodeID, err)
+		}
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to get validation of %s: %w", validator.NodeID, err)
+	} else {
+		unlock = m.L1.Workspace.lockEVMTxs()
+	}
+	defer unlock()
+
+	// The contract helpers take no context, a tx that was sent is waited for
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	_, receipt, err := contract.TxToMethod(
+		m.L1.RPCURL(),
+		m.L1.Workspace.signerHex(),
+		m.Address,
+		big.NewInt(0),
+		"initialize validator registration",
+		validatorManagerSDK.ErrorSignatureToError,
+		"initializeValidatorRegistration((bytes,bytes,uint64,(uint32,[address]),(uint32,[address])),uint64)",
+		validatorRegistrationInput,
+		validator.Weight,
+	)
+	if err != nil {
+		if !isContractError(err, ErrNodeAlreadyRegistered) {
+			return nil, nil, fmt.Errorf("failed to initialize validator registration of %s: %w", validator.NodeID, err)
+		}
+		m.L1.Workspace.Log.Printf("reverted with an expected error: %s", err)
+		m.L1.Workspace.Log.Printf("✅ Node %s was already registered as validator previously\n", validator.NodeID)
+	} else {
+		m.L1.Workspace.Log.Printf("✅ Validator registration initialized: %s\n", receipt.TxHash)
+		registration.InitializeTxHash = receipt.TxHash
+	}
+	unlock()
+
+	m.L1.Workspace.Log.Println("Validator registration initialized in the contract, collecting signatures...")
+
+	message, validationID, err := m.registerL1ValidatorMessage(ctx, validator, expiry, owner, owner)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get subnet validator registration message: %w", err)
+	}
+	registration.ValidationID = validationID
+	return message, registration, nil
+}
+
+// RegisterValidator is step 2 of adding a validator: the P-chain takes the
+// signed RegisterL1Validator message and the validator balance. It returns
+// once the validator is in the set at the proposed height, which the
+// completion signature is checked against. The tx ID is also returned if the
+// tx was issued but waiting for it failed.
+func (l *L1) RegisterValidator(ctx context.Context, validator Validator, message *warp.Message) (ids.ID, error) {
+	txID, err := l.Workspace.issuePChainTx(ctx, "register L1 validator", func(wallet primary.Wallet, options ...common.Option) (*txs.Tx, error) {
+		return wallet.P().IssueRegisterL1ValidatorTx(
+			validator.Balance,
+			validator.Signer.ProofOfPossession,
+			message.Bytes(),
+			options...,
+		)
+	})
+	if err != nil {
+		return txID, err
+	}
+	return txID, l.Workspace.awaitL1Validator(ctx, l.SubnetID, validator.NodeID, true)
+}
+
+// CompleteValidatorRegistration is step 3 of adding a validator: the
+// contract activates the validation once the P-chain signed that it is
+// registered
+func (m *ValidatorManager) CompleteValidatorRegistration(ctx context.Context, validationID ids.ID) (goethereumcommon.Hash, error) {
+	signedMessage, err := m.l1ValidatorRegistrationMessage(ctx, validationID, true)
+	if err != nil {
+		return goethereumcommon.Hash{}, fmt.Errorf("failed to get P-chain subnet validator registration warp message: %w", err)
+	}
+
+	unlock := m.L1.Workspace.lockEVMTxs()
+	defer unlock()
+	if err := ctx.Err(); err != nil {
+		return goethereumcommon.Hash{}, err
+	}
+	tx, _, err := contract.TxToMethodWithWarpMessage(
+		m.L1.RPCURL(),
+		m.L1.Workspace.signerHex(),
+		m.Address,
+		signedMessage,
+		big.NewInt(0),
+		"complete validator registration",
+		validatorManagerSDK.ErrorSignatureToError,
+		"completeValidatorRegistration(uint32)",
+		uint32(0),
+	)
+	if err != nil {
+		return goethereumcommon.Hash{}, evm.TransactionError(tx, err, "failure completing validator registration")
+	}
+	m.L1.Workspace.Log.Printf("✅ Validator registration completed: %s\n", tx.Hash())
+	return tx.Hash(), nil
+}
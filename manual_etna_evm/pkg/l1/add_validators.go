@@ -0,0 +1,64 @@
+package l1
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchValidator is a validator to add with the expiry of its registration,
+// an interrupted batch is resumed with the same expiries
+type BatchValidator struct {
+	Validator Validator
+	Expiry    uint64
+}
+
+// BatchResult is how adding one validator of a batch ended. Registration
+// holds what was done so far, also if Err is set.
+type BatchResult struct {
+	Validator    Validator
+	Registration *Registration
+	Err          error
+}
+
+// AddValidators adds the validators concurrently, at most parallel at a
+// time, zero for all at once. The EVM txs of the signer go out one at a
+// time, each registration waits for room in the churn period of the
+// contract. P-chain txs are built one at a time from the shared UTXOs.
+// Signature aggregation and waiting for the validator set overlap.
+//
+// A validator that fails does not stop the others. onDone, if set, gets
+// every result as soon as it is known, one call at a time. The results are
+// returned in the order of validators.
+func (m *ValidatorManager) AddValidators(ctx context.Context, validators []BatchValidator, parallel int, onDone func(BatchResult)) []BatchResult {
+	if parallel <= 0 || parallel > len(validators) {
+		parallel = len(validators)
+	}
+	results := make([]BatchResult, len(validators))
+	slots := make(chan struct{}, parallel)
+	var (
+		wg     sync.WaitGroup
+		doneMu sync.Mutex
+	)
+	for i, batchValidator := range validators {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := BatchResult{Validator: batchValidator.Validator}
+			select {
+			case slots <- struct{}{}:
+				result.Registration, result.Err = m.AddValidatorWithExpiry(ctx, batchValidator.Validator, batchValidator.Expiry)
+				<-slots
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+			}
+			results[i] = result
+			if onDone != nil {
+				doneMu.Lock()
+				defer doneMu.Unlock()
+				onDone(result)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
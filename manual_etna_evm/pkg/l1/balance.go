@@ -0,0 +1,51 @@
+package l1
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/ava-labs/avalanchego/genesis"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	validatorfee "github.com/ava-labs/avalanchego/vms/platformvm/validators/fee"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+)
+
+// ValidatorFeeConfig is the continuous fee config of L1 validators on the
+// network, the one avalanchego uses by default for its ID
+func (n Network) ValidatorFeeConfig() validatorfee.Config {
+	return genesis.GetTxFeeConfig(n.ID).ValidatorFeeConfig
+}
+
+// ValidatorFeeRate is the continuous fee an active L1 validator pays in nAVAX
+// per second. It is the minimum price of the config, the price only rises
+// while more L1 validators than the target are active, and the P-chain API
+// does not expose by how much.
+func (n Network) ValidatorFeeRate() uint64 {
+	return uint64(n.ValidatorFeeConfig().MinPrice)
+}
+
+// BalanceRunOut is how long balance pays the continuous fee at
+// ValidatorFeeRate, after that the P-chain deactivates the validator
+func (n Network) BalanceRunOut(balance uint64) time.Duration {
+	rate := n.ValidatorFeeRate()
+	if rate == 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	seconds := balance / rate
+	if seconds > uint64(math.MaxInt64/int64(time.Second)) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// IncreaseValidatorBalance adds amount nAVAX of the signer to the balance
+// the validator pays the continuous fee from. A validator that was
+// deactivated for running out becomes active again.
+func (l *L1) IncreaseValidatorBalance(ctx context.Context, validationID ids.ID, amount uint64) (ids.ID, error) {
+	return l.Workspace.issuePChainTx(ctx, "increase L1 validator balance", func(wallet primary.Wallet, options ...common.Option) (*txs.Tx, error) {
+		return wallet.P().IssueIncreaseL1ValidatorBalanceTx(validationID, amount, options...)
+	})
+}
@@ -0,0 +1,217 @@
+package l1
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ava-labs/avalanche-cli/pkg/evm"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// validatorManagerStorageLocation is the ERC-7201 slot of the
+// ValidatorManagerStorage struct, VALIDATOR_MANAGER_STORAGE_LOCATION of the
+// contract. Its fields after the L1 ID are the churn settings and tracker:
+//
+//	slot+1: uint64 churnPeriodSeconds, uint8 maximumChurnPercentage
+//	slot+2: uint256 startedAt
+//	slot+3: uint64 initialWeight, uint64 totalWeight, uint64 churnAmount
+var validatorManagerStorageLocation = common.HexToHash("0xe92546d698950ddd38910d2e15ed1d923cd0a7b3dde9e2a6a3f380565559cb00")
+
+// ChurnPolicy is what an operation does when its weight change does not fit
+// the current churn period
+type ChurnPolicy int
+
+const (
+	// ChurnWait queues the operation until the period resets
+	ChurnWait ChurnPolicy = iota
+	// ChurnRefuse fails the operation with ErrChurnLimitExceeded before it
+	// sends anything
+	ChurnRefuse
+)
+
+// Churn is the churn tracker of the contract with its settings. Any weight
+// added or removed counts against MaximumPercentage of the total weight the
+// period started with.
+type Churn struct {
+	PeriodSeconds     uint64
+	MaximumPercentage uint8
+	// StartedAt is the timestamp of the first change of the period, zero
+	// before the first change after the validator set was initialized
+	StartedAt     uint64
+	InitialWeight uint64
+	TotalWeight   uint64
+	ChurnAmount   uint64
+	// Now is the timestamp of the latest block
+	Now uint64
+}
+
+// Churn reads the churn tracker from the storage of the contract
+func (m *ValidatorManager) Churn(ctx context.Context) (Churn, error) {
+	client, err := evm.GetClient(m.L1.RPCURL())
+	if err != nil {
+		return Churn{}, err
+	}
+	defer client.Close()
+
+	ctx, cancel := m.L1.Workspace.rpcContext(ctx)
+	defer cancel()
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return Churn{}, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	words := make([][]byte, 4)
+	for offset := range words {
+		slot := new(big.Int).Add(validatorManagerStorageLocation.Big(), big.NewInt(int64(offset)))
+		words[offset], err = client.StorageAt(ctx, m.Address, common.BigToHash(slot), header.Number)
+		if err != nil {
+			return Churn{}, fmt.Errorf("failed to read validator manager storage: %w", err)
+		}
+		words[offset] = common.LeftPadBytes(words[offset], common.HashLength)
+	}
+
+	// Packed fields start at the low order end of the word
+	packed := func(word []byte, byteOffset int) uint64 {
+		return binary.BigEndian.Uint64(word[common.HashLength-8-byteOffset : common.HashLength-byteOffset])
+	}
+	churn := Churn{
+		PeriodSeconds:     packed(words[1], 0),
+		MaximumPercentage: words[1][common.HashLength-9],
+		StartedAt:         new(big.Int).SetBytes(words[2]).Uint64(),
+		InitialWeight:     packed(words[3], 0),
+		TotalWeight:       packed(words[3], 8),
+		ChurnAmount:       packed(words[3], 16),
+		Now:               header.Time,
+	}
+	if churn.MaximumPercentage == 0 {
+		return Churn{}, fmt.Errorf("validator manager %s is not initialized", m.Address)
+	}
+	return churn, nil
+}
+
+// NewPeriod reports whether the next change starts a new period, which
+// resets the churn amount and the weight it is measured against
+func (c Churn) NewPeriod() bool {
+	return c.StartedAt == 0 || c.Now >= c.StartedAt+c.PeriodSeconds
+}
+
+// MaxChange is the largest change a new period accepts at the current total
+// weight. A change above it is never accepted.
+func (c Churn) MaxChange() uint64 {
+	return uint64(c.MaximumPercentage) * c.TotalWeight / 100
+}
+
+// Room is the weight that can change right now
+func (c Churn) Room() uint64 {
+	if c.NewPeriod() {
+		return c.MaxChange()
+	}
+	limit := uint64(c.MaximumPercentage) * c.InitialWeight / 100
+	if c.ChurnAmount >= limit {
+		return 0
+	}
+	return limit - c.ChurnAmount
+}
+
+// ResetsAt is when the current period ends, the zero time if the next
+// change starts a new one anyway
+func (c Churn) ResetsAt() time.Time {
+	if c.NewPeriod() {
+		return time.Time{}
+	}
+	return time.Unix(int64(c.StartedAt+c.PeriodSeconds), 0)
+}
+
+// Wait is how long a weight change has to wait for the contract to accept
+// it, zero if it fits the current period. It fails if the change is too
+// large for any period at the current total weight.
+func (c Churn) Wait(weightChange uint64) (time.Duration, error) {
+	limit := uint64(c.MaximumPercentage)
+	if !c.NewPeriod() && limit*c.InitialWeight >= (c.ChurnAmount+weightChange)*100 {
+		return 0, nil
+	}
+	if limit*c.TotalWeight < weightChange*100 {
+		return 0, fmt.Errorf("%w: a change of %d is more than %d%% of the total weight %d", ErrChurnLimitExceeded, weightChange, limit, c.TotalWeight)
+	}
+	if c.NewPeriod() {
+		return 0, nil
+	}
+	return time.Duration(c.StartedAt+c.PeriodSeconds-c.Now) * time.Second, nil
+}
+
+// PreflightChurn checks a weight change against the churn period before any
+// work is done for it. It fails if the contract would never accept the
+// change, or if it has to wait and the policy refuses to. Otherwise it
+// returns the tracker and the wait, the operation itself waits for the
+// period again.
+func (m *ValidatorManager) PreflightChurn(ctx context.Context, weightChange uint64) (Churn, time.Duration, error) {
+	churn, err := m.Churn(ctx)
+	if err != nil {
+		return Churn{}, 0, err
+	}
+	wait, err := m.churnWait(churn, weightChange)
+	return churn, wait, err
+}
+
+// churnWait applies the churn policy to the wait of a change
+func (m *ValidatorManager) churnWait(churn Churn, weightChange uint64) (time.Duration, error) {
+	wait, err := churn.Wait(weightChange)
+	if err != nil {
+		return 0, err
+	}
+	if wait > 0 && m.ChurnPolicy == ChurnRefuse {
+		return 0, fmt.Errorf("%w: a change of %d does not fit the %d left in the period, it resets at %s", ErrChurnLimitExceeded, weightChange, churn.Room(), churn.ResetsAt().Format(time.RFC3339))
+	}
+	return wait, nil
+}
+
+// lockChurnRoom waits for the contract to accept a weight change in its
+// churn period without holding the EVM txs of the signer, then locks them
+// and checks the room again, so nothing else changes the tracker before the
+// tx that uses it. It returns the unlock of lockEVMTxs.
+func (m *ValidatorManager) lockChurnRoom(ctx context.Context, weightChange uint64) (func(), error) {
+	for {
+		if err := m.awaitChurnRoom(ctx, weightChange); err != nil {
+			return nil, err
+		}
+		unlock := m.L1.Workspace.lockEVMTxs()
+		churn, err := m.Churn(ctx)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		wait, err := m.churnWait(churn, weightChange)
+		if err == nil && wait == 0 {
+			return unlock, nil
+		}
+		// A tx sent while waiting used the room
+		unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// awaitChurnRoom blocks until the contract accepts a weight change in its
+// churn period, or fails right away if the policy refuses to wait
+func (m *ValidatorManager) awaitChurnRoom(ctx context.Context, weightChange uint64) error {
+	for {
+		churn, err := m.Churn(ctx)
+		if err != nil {
+			return err
+		}
+		wait, err := m.churnWait(churn, weightChange)
+		if err != nil || wait == 0 {
+			return err
+		}
+		m.L1.Workspace.Log.Printf("⏳ Churn limit of the period reached, waiting %s for the next one\n", wait)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for the churn period to reset: %w", ctx.Err())
+		// The next block has to be past the period, not only the clock
+		case <-time.After(wait + time.Second):
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package l1
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChurnRoom(t *testing.T) {
+	tests := []struct {
+		name         string
+		churn        Churn
+		wantNew      bool
+		wantRoom     uint64
+		wantMax      uint64
+		wantResetsAt time.Time
+	}{
+		{
+			name:    "first change after initialization",
+			churn:   Churn{PeriodSeconds: 3600, MaximumPercentage: 20, TotalWeight: 1000, Now: 5000},
+			wantNew: true, wantRoom: 200, wantMax: 200,
+		},
+		{
+			name:     "inside the period",
+			churn:    Churn{PeriodSeconds: 3600, MaximumPercentage: 20, StartedAt: 4000, InitialWeight: 1000, TotalWeight: 1100, ChurnAmount: 150, Now: 5000},
+			wantRoom: 50, wantMax: 220, wantResetsAt: time.Unix(7600, 0),
+		},
+		{
+			name:     "period used up",
+			churn:    Churn{PeriodSeconds: 3600, MaximumPercentage: 20, StartedAt: 4000, InitialWeight: 1000, TotalWeight: 1200, ChurnAmount: 200, Now: 5000},
+			wantRoom: 0, wantMax: 240, wantResetsAt: time.Unix(7600, 0),
+		},
+		{
+			name:     "churn above the limit of a shrunk initial weight",
+			churn:    Churn{PeriodSeconds: 3600, MaximumPercentage: 20, StartedAt: 4000, InitialWeight: 500, TotalWeight: 1200, ChurnAmount: 150, Now: 5000},
+			wantRoom: 0, wantMax: 240, wantResetsAt: time.Unix(7600, 0),
+		},
+		{
+			name:    "period ends exactly now",
+			churn:   Churn{PeriodSeconds: 3600, MaximumPercentage: 20, StartedAt: 4000, InitialWeight: 1000, TotalWeight: 1200, ChurnAmount: 200, Now: 7600},
+			wantNew: true, wantRoom: 240, wantMax: 240,
+		},
+		{
+			name:    "rounds down",
+			churn:   Churn{PeriodSeconds: 60, MaximumPercentage: 33, TotalWeight: 10, Now: 1},
+			wantNew: true, wantRoom: 3, wantMax: 3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.churn.NewPeriod(); got != test.wantNew {
+				t.Errorf("NewPeriod() = %v, want %v", got, test.wantNew)
+			}
+			if got := test.churn.Room(); got != test.wantRoom {
+				t.Errorf("Room() = %d, want %d", got, test.wantRoom)
+			}
+			if got := test.churn.MaxChange(); got != test.wantMax {
+				t.Errorf("MaxChange() = %d, want %d", got, test.wantMax)
+			}
+			if got := test.churn.ResetsAt(); !got.Equal(test.wantResetsAt) {
+				t.Errorf("ResetsAt() = %s, want %s", got, test.wantResetsAt)
+			}
+		})
+	}
+}
+
+func TestChurnWait(t *testing.T) {
+	inPeriod := Churn{PeriodSeconds: 3600, MaximumPercentage: 20, StartedAt: 4000, InitialWeight: 1000, TotalWeight: 1100, ChurnAmount: 150, Now: 5000}
+	newPeriod := Churn{PeriodSeconds: 3600, MaximumPercentage: 20, StartedAt: 1000, InitialWeight: 1000, TotalWeight: 1100, ChurnAmount: 200, Now: 5000}
+	tests := []struct {
+		name     string
+		churn    Churn
+		change   uint64
+		wantWait time.Duration
+		wantErr  error
+	}{
+		{"fits the room", inPeriod, 50, 0, nil},
+		{"waits for the reset", inPeriod, 51, 2600 * time.Second, nil},
+		{"largest change of a new period", inPeriod, 220, 2600 * time.Second, nil},
+		{"never fits", inPeriod, 221, 0, ErrChurnLimitExceeded},
+		{"new period", newPeriod, 220, 0, nil},
+		{"too large for a new period", newPeriod, 221, 0, ErrChurnLimitExceeded},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wait, err := test.churn.Wait(test.change)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Wait(%d) error = %v, want %v", test.change, err, test.wantErr)
+			}
+			if wait != test.wantWait {
+				t.Errorf("Wait(%d) = %s, want %s", test.change, wait, test.wantWait)
+			}
+		})
+	}
+}
+
+func TestChurnWaitPolicy(t *testing.T) {
+	churn := Churn{PeriodSeconds: 3600, MaximumPercentage: 20, StartedAt: 4000, InitialWeight: 1000, TotalWeight: 1100, ChurnAmount: 150, Now: 5000}
+	tests := []struct {
+		policy   ChurnPolicy
+		change   uint64
+		wantWait time.Duration
+		wantErr  error
+	}{
+		{ChurnWait, 50, 0, nil},
+		{ChurnWait, 100, 2600 * time.Second, nil},
+		{ChurnRefuse, 50, 0, nil},
+		{ChurnRefuse, 100, 0, ErrChurnLimitExceeded},
+	}
+	for _, test := range tests {
+		m := &ValidatorManager{ChurnPolicy: test.policy}
+		wait, err := m.churnWait(churn, test.change)
+		if !errors.Is(err, test.wantErr) || wait != test.wantWait {
+			t.Errorf("churnWait(%d) with policy %d = %s, %v, want %s, %v", test.change, test.policy, wait, err, test.wantWait, test.wantErr)
+		}
+	}
+}
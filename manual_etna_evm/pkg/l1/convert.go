@@ -0,0 +1,74 @@
+package l1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+	goethereumcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// ConvertToL1 turns the subnet into an L1 whose validators are managed by the
+// contract at managerAddress on chainID, with validators as the bootstrap
+// validators. The signer gets their remaining balance. It returns once the
+// first validator is in the set at the proposed height, so it can sign warp
+// messages for the L1. The tx ID is also returned if the tx was issued but
+// waiting for it failed, resume with AwaitL1ValidatorTx.
+func (w *Workspace) ConvertToL1(ctx context.Context, subnetID ids.ID, chainID ids.ID, managerAddress goethereumcommon.Address, validators []Validator) (ids.ID, error) {
+	if len(validators) == 0 {
+		return ids.Empty, fmt.Errorf("at least one bootstrap validator is required")
+	}
+
+	bootstrapValidators := make([]*txs.ConvertSubnetToL1Validator, 0, len(validators))
+	for _, validator := range validators {
+		bootstrapValidators = append(bootstrapValidators, &txs.ConvertSubnetToL1Validator{
+			NodeID:  validator.NodeID[:],
+			Weight:  validator.Weight,
+			Balance: validator.Balance,
+			Signer:  *validator.Signer,
+			RemainingBalanceOwner: message.PChainOwner{
+				Threshold: 1,
+				Addresses: []ids.ShortID{w.Signer.Address()},
+			},
+		})
+	}
+	utils.Sort(bootstrapValidators)
+
+	txID, err := w.issuePChainTx(ctx, "convert subnet to L1", func(wallet primary.Wallet, options ...common.Option) (*txs.Tx, error) {
+		return wallet.P().IssueConvertSubnetToL1Tx(
+			subnetID,
+			chainID,
+			managerAddress.Bytes(),
+			bootstrapValidators,
+			append(options, w.subnetAuthOptions()...)...,
+		)
+	}, subnetID)
+	if err != nil {
+		return txID, err
+	}
+	if err := w.awaitL1Validator(ctx, subnetID, validators[0].NodeID, true); err != nil {
+		return txID, err
+	}
+	w.Log.Printf("✅ Converted subnet %s to L1 in tx %s\n", subnetID, txID)
+	return txID, nil
+}
+
+// subnetAuthOptions sign with the signer as subnet owner and send change
+// back to it
+func (w *Workspace) subnetAuthOptions() []common.Option {
+	signerAddr := w.Signer.Address()
+	return []common.Option{
+		common.WithCustomAddresses(set.Of(signerAddr)),
+		common.WithChangeOwner(&secp256k1fx.OutputOwners{
+			Threshold: 1,
+			Addrs:     []ids.ShortID{signerAddr},
+		}),
+	}
+}
@@ -0,0 +1,186 @@
+package l1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	platformapi "github.com/ava-labs/avalanchego/vms/platformvm/api"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	warpPayload "github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+)
+
+// DisableValidator deactivates the validation on the P-chain without the
+// validator manager, signed by the disable owner set at registration. The
+// remaining balance goes back to the remaining balance owner. The contract
+// still has the validation active afterwards.
+func (l *L1) DisableValidator(ctx context.Context, validationID ids.ID) (ids.ID, error) {
+	validator, err := l.L1Validator(ctx, validationID)
+	if err != nil {
+		return ids.Empty, err
+	}
+	owner := validator.DeactivationOwner
+	if owner == nil || owner.Threshold != 1 || !slices.Contains(owner.Addrs, l.Workspace.Signer.Address()) {
+		return ids.Empty, fmt.Errorf("the signer %s can not disable validation %s alone, it is not a single signature disable owner", l.Workspace.Signer.Address(), validationID)
+	}
+	l.Workspace.wallets().SetOwner(validationID, owner)
+	return l.Workspace.issuePChainTx(ctx, "disable L1 validator", func(wallet primary.Wallet, options ...common.Option) (*txs.Tx, error) {
+		return wallet.P().IssueDisableL1ValidatorTx(validationID, options...)
+	}, validationID)
+}
+
+// PChainValidationID finds the validation of the node without the L1: among
+// the bootstrap validations of the conversion tx and the registrations in the
+// warp index, the one the P-chain has for the node
+func (m *ValidatorManager) PChainValidationID(ctx context.Context, nodeID ids.NodeID, conversionTxID ids.ID) (ids.ID, error) {
+	candidates, err := m.candidateValidations(ctx, conversionTxID)
+	if err != nil {
+		return ids.Empty, err
+	}
+	for _, candidate := range candidates {
+		if candidate.NodeID != nodeID {
+			continue
+		}
+		validator, err := m.L1.L1Validator(ctx, candidate.ValidationID)
+		if errors.Is(err, ErrValidatorNotFound) {
+			continue
+		}
+		if err != nil {
+			return ids.Empty, err
+		}
+		if validator.NodeID == nodeID {
+			return candidate.ValidationID, nil
+		}
+	}
+	return ids.Empty, fmt.Errorf("no P-chain validation of %s in the conversion or the warp index: %w", nodeID, ErrValidatorNotFound)
+}
+
+// PChainValidation is the P-chain state of a validation
+type PChainValidation struct {
+	ValidationID ids.ID
+	Validator    *platformvm.L1Validator
+}
+
+// PChainValidations lists the validations the P-chain has for the L1 without
+// the L1, deactivated ones included: the bootstrap validations of the
+// conversion tx and the registrations in the warp index. It also returns the
+// nodes of the validator set none of them is for, e.g. registrations of
+// another signer the warp index has not seen yet.
+func (m *ValidatorManager) PChainValidations(ctx context.Context, conversionTxID ids.ID) ([]PChainValidation, []ids.NodeID, error) {
+	validatorSet, err := platformvm.NewClient(m.L1.Workspace.Network.URI).GetValidatorsAt(ctx, m.L1.SubnetID, platformapi.ProposedHeight)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get validators of subnet %s: %w", m.L1.SubnetID, err)
+	}
+	candidates, err := m.candidateValidations(ctx, conversionTxID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var validations []PChainValidation
+	found := make(map[ids.NodeID]bool)
+	for _, candidate := range candidates {
+		validator, err := m.L1.L1Validator(ctx, candidate.ValidationID)
+		if errors.Is(err, ErrValidatorNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		validations = append(validations, PChainValidation{ValidationID: candidate.ValidationID, Validator: validator})
+		found[validator.NodeID] = true
+	}
+	var missing []ids.NodeID
+	for nodeID := range validatorSet {
+		if !found[nodeID] {
+			missing = append(missing, nodeID)
+		}
+	}
+	slices.SortFunc(validations, func(a, b PChainValidation) int {
+		return a.Validator.NodeID.Compare(b.Validator.NodeID)
+	})
+	slices.SortFunc(missing, func(a, b ids.NodeID) int {
+		return a.Compare(b)
+	})
+	return validations, missing, nil
+}
+
+// candidateValidation is a validation the L1 may have for the node
+type candidateValidation struct {
+	ValidationID ids.ID
+	NodeID       ids.NodeID
+}
+
+// candidateValidations is the bootstrap validations of the conversion tx, if
+// there is one, and the registrations in the warp index
+func (m *ValidatorManager) candidateValidations(ctx context.Context, conversionTxID ids.ID) ([]candidateValidation, error) {
+	var candidates []candidateValidation
+	if conversionTxID != ids.Empty {
+		bootstrap, err := m.L1.bootstrapValidations(ctx, conversionTxID)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, bootstrap...)
+	}
+	index := m.warpIndex()
+	for _, validationID := range index.ValidationIDs() {
+		validation, _ := index.Validation(validationID)
+		if nodeID := registeredNodeID(validation.Registration); nodeID != ids.EmptyNodeID {
+			candidates = append(candidates, candidateValidation{ValidationID: validationID, NodeID: nodeID})
+		}
+	}
+	return candidates, nil
+}
+
+// bootstrapValidations is the validations the conversion tx created
+func (l *L1) bootstrapValidations(ctx context.Context, conversionTxID ids.ID) ([]candidateValidation, error) {
+	txBytes, err := platformvm.NewClient(l.Workspace.Network.URI).GetTx(ctx, conversionTxID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversion tx %s: %w", conversionTxID, err)
+	}
+	tx, err := txs.Parse(txs.Codec, txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse conversion tx %s: %w", conversionTxID, err)
+	}
+	conversion, ok := tx.Unsigned.(*txs.ConvertSubnetToL1Tx)
+	if !ok {
+		return nil, fmt.Errorf("tx %s is a %T, not a conversion", conversionTxID, tx.Unsigned)
+	}
+	var validations []candidateValidation
+	for i, validator := range conversion.Validators {
+		nodeID, err := ids.ToNodeID(validator.NodeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node ID of conversion validator %d: %w", i, err)
+		}
+		validations = append(validations, candidateValidation{ValidationID: conversion.Subnet.Append(uint32(i)), NodeID: nodeID})
+	}
+	return validations, nil
+}
+
+// registeredNodeID is the node of an unsigned RegisterL1Validator message,
+// empty if it does not parse
+func registeredNodeID(unsignedBytes []byte) ids.NodeID {
+	unsignedMessage, err := warp.ParseUnsignedMessage(unsignedBytes)
+	if err != nil {
+		return ids.EmptyNodeID
+	}
+	addressedCall, err := warpPayload.ParseAddressedCall(unsignedMessage.Payload)
+	if err != nil {
+		return ids.EmptyNodeID
+	}
+	registration, err := warpMessage.ParseRegisterL1Validator(addressedCall.Payload)
+	if err != nil {
+		return ids.EmptyNodeID
+	}
+	nodeID, err := ids.ToNodeID(registration.NodeID)
+	if err != nil {
+		return ids.EmptyNodeID
+	}
+	return nodeID
+}
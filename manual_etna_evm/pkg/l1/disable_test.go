@@ -0,0 +1,188 @@
+package l1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto/bls"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	avajson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	warpPayload "github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+)
+
+// fakePChain serves the P-chain API calls PChainValidations makes
+type fakePChain struct {
+	conversion  []byte
+	set         []ids.NodeID
+	validations map[ids.ID]platformvm.GetL1ValidatorReply
+}
+
+func (f *fakePChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var (
+		result any
+		err    error
+	)
+	switch request.Method {
+	case "platform.getTx":
+		var encoded string
+		encoded, err = formatting.Encode(formatting.Hex, f.conversion)
+		result = map[string]any{"tx": encoded, "encoding": formatting.Hex}
+	case "platform.getValidatorsAt":
+		reply := &platformvm.GetValidatorsAtReply{Validators: make(map[ids.NodeID]*validators.GetValidatorOutput)}
+		for _, nodeID := range f.set {
+			reply.Validators[nodeID] = &validators.GetValidatorOutput{NodeID: nodeID, Weight: 20}
+		}
+		result = reply
+	case "platform.getL1Validator":
+		var args platformvm.GetL1ValidatorArgs
+		if err = json.Unmarshal(request.Params, &args); err != nil {
+			break
+		}
+		validator, ok := f.validations[args.ValidationID]
+		if !ok {
+			err = fmt.Errorf("fetching L1 validator %s failed: not found", args.ValidationID)
+			break
+		}
+		result = validator
+	default:
+		err = fmt.Errorf("unexpected method %s", request.Method)
+	}
+	response := map[string]any{"jsonrpc": "2.0", "id": request.ID}
+	if err != nil {
+		response["error"] = map[string]any{"code": -32000, "message": err.Error()}
+	} else {
+		response["result"] = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func TestPChainValidations(t *testing.T) {
+	var (
+		subnetID     = ids.ID{1, 2, 3}
+		chainID      = ids.ID{9, 8, 7}
+		bootstrap    = ids.NodeID{0x01}
+		disabled     = ids.NodeID{0x02}
+		registered   = ids.NodeID{0x03}
+		deactivated  = ids.NodeID{0x04}
+		unknown      = ids.NodeID{0x05}
+		neverOnChain = ids.NodeID{0x06}
+		owner        = warpMessage.PChainOwner{}
+	)
+	secretKey, err := bls.NewSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := bls.PublicKeyToCompressedBytes(bls.PublicFromSecretKey(secretKey))
+
+	conversion, err := txs.NewSigned(&txs.ConvertSubnetToL1Tx{
+		Subnet:     subnetID,
+		ChainID:    chainID,
+		SubnetAuth: &secp256k1fx.Input{},
+		Validators: []*txs.ConvertSubnetToL1Validator{
+			{NodeID: bootstrap[:], Weight: 20},
+			{NodeID: disabled[:], Weight: 20},
+		},
+	}, txs.Codec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The warp index has the registrations of the L1, one of them never
+	// made it to the P-chain
+	index := NewWarpIndex()
+	registration := func(nodeID ids.NodeID) ids.ID {
+		message, err := warpMessage.NewRegisterL1Validator(subnetID, nodeID, [48]byte{1}, 1, owner, owner, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addressedCall, err := warpPayload.NewAddressedCall(nil, message.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		unsigned, err := warp.NewUnsignedMessage(constants.FujiID, chainID, addressedCall.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := index.add(map[ids.ID][]byte{message.ValidationID(): unsigned.Bytes()}, nil, 0); err != nil {
+			t.Fatal(err)
+		}
+		return message.ValidationID()
+	}
+	registeredID := registration(registered)
+	deactivatedID := registration(deactivated)
+	registration(neverOnChain)
+
+	onPChain := func(nodeID ids.NodeID, balance uint64) platformvm.GetL1ValidatorReply {
+		return platformvm.GetL1ValidatorReply{SubnetID: subnetID, NodeID: nodeID, PublicKey: publicKey, Weight: 20, Balance: avajson.Uint64(balance)}
+	}
+	pChain := &fakePChain{
+		conversion: conversion.Bytes(),
+		// Deactivated validators are out of the set, the unknown one was
+		// registered by a manager the warp index has not seen
+		set: []ids.NodeID{bootstrap, registered, unknown},
+		validations: map[ids.ID]platformvm.GetL1ValidatorReply{
+			subnetID.Append(0): onPChain(bootstrap, 5),
+			registeredID:       onPChain(registered, 7),
+			deactivatedID:      onPChain(deactivated, 0),
+		},
+	}
+	server := httptest.NewServer(pChain)
+	defer server.Close()
+
+	workspace := &Workspace{Network: Network{ID: constants.FujiID, URI: server.URL}}
+	m := &ValidatorManager{L1: workspace.L1(subnetID, chainID, server.URL), WarpIndex: index}
+	validations, missing, err := m.PChainValidations(context.Background(), conversion.ID())
+	if err != nil {
+		t.Fatalf("failed to list validations: %s", err)
+	}
+
+	want := []struct {
+		nodeID       ids.NodeID
+		validationID ids.ID
+		balance      uint64
+	}{
+		{bootstrap, subnetID.Append(0), 5},
+		{registered, registeredID, 7},
+		{deactivated, deactivatedID, 0},
+	}
+	if len(validations) != len(want) {
+		t.Fatalf("validations = %+v, want %d", validations, len(want))
+	}
+	for i, validation := range validations {
+		if validation.Validator.NodeID != want[i].nodeID || validation.ValidationID != want[i].validationID || validation.Validator.Balance != want[i].balance {
+			t.Errorf("validation %d = %s %s %d, want %s %s %d", i, validation.Validator.NodeID, validation.ValidationID, validation.Validator.Balance, want[i].nodeID, want[i].validationID, want[i].balance)
+		}
+	}
+	if !slices.Equal(missing, []ids.NodeID{unknown}) {
+		t.Errorf("missing = %s, want %s", missing, unknown)
+	}
+
+	validationID, err := m.PChainValidationID(context.Background(), deactivated, conversion.ID())
+	if err != nil || validationID != deactivatedID {
+		t.Errorf("validation of %s = %s, %v, want %s", deactivated, validationID, err, deactivatedID)
+	}
+}
@@ -0,0 +1,46 @@
+package l1
+
+import (
+	"errors"
+	"strings"
+
+	validatorManagerSDK "github.com/ava-labs/avalanche-cli/sdk/validatormanager"
+)
+
+// Sentinel errors, match them with errors.Is. The validator manager ones are
+// the SDK errors its reverts decode to.
+var (
+	// ErrTxDropped means the tx failed verification after it was issued, the
+	// error carries the reason the node gave
+	ErrTxDropped = errors.New("P-chain tx was dropped")
+	// ErrTxAborted means a proposal tx was decided but its effect was aborted
+	ErrTxAborted = errors.New("P-chain tx was aborted")
+
+	// ErrNodeAlreadyRegistered means the validator manager already has a
+	// registration for the node
+	ErrNodeAlreadyRegistered = validatorManagerSDK.ErrNodeAlreadyRegistered
+	// ErrInvalidValidatorStatus means the validation is not in the state the
+	// call expects, e.g. removing a validator whose removal already started
+	ErrInvalidValidatorStatus = validatorManagerSDK.ErrInvalidValidatorStatus
+	// ErrChurnLimitExceeded means a weight change is more than the contract
+	// accepts in its churn period
+	ErrChurnLimitExceeded = validatorManagerSDK.ErrMaxChurnRateExceeded
+	// ErrValidatorNotFound means the node has no validation in the validator
+	// manager
+	ErrValidatorNotFound = errors.New("validator not found")
+	// ErrRegistrationNotExpired means a pending registration can still reach
+	// the P-chain, so it can not be invalidated yet
+	ErrRegistrationNotExpired = errors.New("registration has not expired")
+	// ErrInsufficientBalance means an address holds less than the flow needs
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrInvalidCredentials means the staking certificate or BLS key of a
+	// creds folder can not be parsed
+	ErrInvalidCredentials = errors.New("invalid node credentials")
+)
+
+// isContractError reports whether a validator manager call failed with
+// target. The revert is only decoded when the node allows debug traces,
+// otherwise the message of the raw error is all there is.
+func isContractError(err error, target error) bool {
+	return errors.Is(err, target) || (err != nil && strings.Contains(err.Error(), target.Error()))
+}
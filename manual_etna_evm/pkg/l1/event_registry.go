@@ -0,0 +1,227 @@
+package l1
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/ids"
+	proxyadmin "github.com/ava-labs/icm-contracts/abi-bindings/go/ProxyAdmin"
+	transparentupgradeableproxy "github.com/ava-labs/icm-contracts/abi-bindings/go/TransparentUpgradeableProxy"
+	erc20tokenstakingmanager "github.com/ava-labs/icm-contracts/abi-bindings/go/validator-manager/ERC20TokenStakingManager"
+	nativetokenstakingmanager "github.com/ava-labs/icm-contracts/abi-bindings/go/validator-manager/NativeTokenStakingManager"
+	poavalidatormanager "github.com/ava-labs/icm-contracts/abi-bindings/go/validator-manager/PoAValidatorManager"
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/accounts/abi/bind"
+	"github.com/ava-labs/subnet-evm/core/types"
+	subnetEvmWarp "github.com/ava-labs/subnet-evm/precompile/contracts/warp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrUnknownEvent means no ABI of the registry has the event of a log
+var ErrUnknownEvent = errors.New("unknown event")
+
+// EventSource is a contract ABI the registry decodes the events of
+type EventSource struct {
+	Name string
+	ABI  *abi.ABI
+}
+
+// EventRegistry decodes contract logs by the topic of their event. Events
+// with the same signature in several ABIs are decoded once and list every
+// source.
+type EventRegistry struct {
+	events map[common.Hash]*registeredEvent
+}
+
+type registeredEvent struct {
+	event   abi.Event
+	sources []string
+}
+
+// NewEventRegistry registers the non-anonymous events of the sources
+func NewEventRegistry(sources ...EventSource) *EventRegistry {
+	registry := &EventRegistry{events: make(map[common.Hash]*registeredEvent)}
+	for _, source := range sources {
+		for _, event := range source.ABI.Events {
+			if event.Anonymous {
+				continue
+			}
+			registered, ok := registry.events[event.ID]
+			if !ok {
+				registered = &registeredEvent{event: event}
+				registry.events[event.ID] = registered
+			}
+			registered.sources = append(registered.sources, source.Name)
+		}
+	}
+	return registry
+}
+
+// DefaultEventRegistry knows the events of every validator manager flavour,
+// the proxy and its admin, and the warp precompile
+func DefaultEventRegistry() (*EventRegistry, error) {
+	metaData := []struct {
+		name     string
+		metaData *bind.MetaData
+	}{
+		{"PoAValidatorManager", poavalidatormanager.PoAValidatorManagerMetaData},
+		{"NativeTokenStakingManager", nativetokenstakingmanager.NativeTokenStakingManagerMetaData},
+		{"ERC20TokenStakingManager", erc20tokenstakingmanager.ERC20TokenStakingManagerMetaData},
+		{"TransparentUpgradeableProxy", transparentupgradeableproxy.TransparentUpgradeableProxyMetaData},
+		{"ProxyAdmin", proxyadmin.ProxyAdminMetaData},
+	}
+	sources := make([]EventSource, 0, len(metaData)+1)
+	for _, contract := range metaData {
+		contractABI, err := contract.metaData.GetAbi()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s ABI: %w", contract.name, err)
+		}
+		sources = append(sources, EventSource{Name: contract.name, ABI: contractABI})
+	}
+	sources = append(sources, EventSource{Name: "WarpPrecompile", ABI: &subnetEvmWarp.WarpABI})
+	return NewEventRegistry(sources...), nil
+}
+
+// EventNames is the names of the registered events, sorted
+func (r *EventRegistry) EventNames() []string {
+	names := make([]string, 0, len(r.events))
+	for _, registered := range r.events {
+		if !slices.Contains(names, registered.event.Name) {
+			names = append(names, registered.event.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Topics is the topics of the events with the names, to filter logs by. A
+// name of several signatures gives a topic for each.
+func (r *EventRegistry) Topics(names []string) ([]common.Hash, error) {
+	var topics []common.Hash
+	for _, name := range names {
+		found := false
+		for topic, registered := range r.events {
+			if registered.event.Name == name {
+				topics = append(topics, topic)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w %q, use one of %s", ErrUnknownEvent, name, strings.Join(r.EventNames(), ", "))
+		}
+	}
+	return topics, nil
+}
+
+// LogField is a decoded event argument
+type LogField struct {
+	Name    string
+	Type    string
+	Indexed bool
+	// Value is the decoded argument. Indexed arguments of dynamic types are
+	// only the keccak hash of the value, a common.Hash.
+	Value any
+}
+
+// DecodedLog is a log with its event arguments in ABI order
+type DecodedLog struct {
+	Log       types.Log
+	Event     string
+	Signature string
+	// Sources is the ABIs that have the event
+	Sources []string
+	Fields  []LogField
+}
+
+// Decode decodes the indexed and non-indexed arguments of the log,
+// ErrUnknownEvent if no ABI has its event
+func (r *EventRegistry) Decode(log types.Log) (DecodedLog, error) {
+	if len(log.Topics) == 0 {
+		return DecodedLog{}, fmt.Errorf("%w: anonymous log", ErrUnknownEvent)
+	}
+	registered, ok := r.events[log.Topics[0]]
+	if !ok {
+		return DecodedLog{}, fmt.Errorf("%w with topic %s", ErrUnknownEvent, log.Topics[0].Hex())
+	}
+	event := registered.event
+	decoded := DecodedLog{
+		Log:       log,
+		Event:     event.Name,
+		Signature: event.Sig,
+		Sources:   registered.sources,
+	}
+
+	values, err := event.Inputs.NonIndexed().Unpack(log.Data)
+	if err != nil {
+		return DecodedLog{}, fmt.Errorf("failed to decode data of %s: %w", event.Name, err)
+	}
+	topics := log.Topics[1:]
+	for _, input := range event.Inputs {
+		field := LogField{Name: input.Name, Type: input.Type.String(), Indexed: input.Indexed}
+		if input.Indexed {
+			if len(topics) == 0 {
+				return DecodedLog{}, fmt.Errorf("%s has fewer topics than indexed arguments", event.Name)
+			}
+			field.Value, err = decodeTopic(input.Type, topics[0])
+			if err != nil {
+				return DecodedLog{}, fmt.Errorf("failed to decode %s of %s: %w", input.Name, event.Name, err)
+			}
+			topics = topics[1:]
+		} else {
+			field.Value, values = values[0], values[1:]
+		}
+		decoded.Fields = append(decoded.Fields, field)
+	}
+	return decoded, nil
+}
+
+// decodeTopic is the value of an indexed argument. Static types are encoded
+// in the topic like in data, dynamic ones are only hashed.
+func decodeTopic(argType abi.Type, topic common.Hash) (any, error) {
+	switch argType.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return topic, nil
+	}
+	values, err := abi.Arguments{{Type: argType}}.Unpack(topic[:])
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// String formats the value for people: hex for bytes, NodeIDs for 20 byte
+// node IDs, decimal for integers
+func (f LogField) String() string {
+	switch value := f.JSONValue().(type) {
+	case string:
+		return value
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// JSONValue is the value in a form that survives JSON: big integers become
+// decimal strings, bytes hex strings without 0x and addresses checksummed
+// hex
+func (f LogField) JSONValue() any {
+	switch value := f.Value.(type) {
+	case *big.Int:
+		return value.String()
+	case common.Address:
+		return value.Hex()
+	case common.Hash:
+		return fmt.Sprintf("%x", value[:])
+	case [32]byte:
+		return fmt.Sprintf("%x", value[:])
+	case []byte:
+		if strings.EqualFold(f.Name, "nodeID") && len(value) == ids.NodeIDLen {
+			return ids.NodeID(value).String()
+		}
+		return fmt.Sprintf("%x", value)
+	}
+	return f.Value
+}
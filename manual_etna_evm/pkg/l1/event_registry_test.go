@@ -0,0 +1,207 @@
+package l1
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestEventRegistryTopics(t *testing.T) {
+	registry, err := DefaultEventRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	managers := []string{"PoAValidatorManager", "NativeTokenStakingManager", "ERC20TokenStakingManager"}
+	stakingManagers := []string{"NativeTokenStakingManager", "ERC20TokenStakingManager"}
+	tests := []struct {
+		signature   string
+		wantName    string
+		wantSources []string
+	}{
+		{"InitialValidatorCreated(bytes32,bytes,uint64)", "InitialValidatorCreated", managers},
+		{"ValidationPeriodCreated(bytes32,bytes,bytes32,uint64,uint64)", "ValidationPeriodCreated", managers},
+		{"ValidationPeriodRegistered(bytes32,uint64,uint256)", "ValidationPeriodRegistered", managers},
+		{"ValidatorRemovalInitialized(bytes32,bytes32,uint64,uint256)", "ValidatorRemovalInitialized", managers},
+		{"ValidationPeriodEnded(bytes32,uint8)", "ValidationPeriodEnded", managers},
+		{"ValidatorWeightUpdate(bytes32,uint64,uint64,bytes32)", "ValidatorWeightUpdate", managers},
+		{"DelegatorAdded(bytes32,bytes32,address,uint64,uint64,uint64,bytes32)", "DelegatorAdded", stakingManagers},
+		{"UptimeUpdated(bytes32,uint64)", "UptimeUpdated", stakingManagers},
+		{"OwnershipTransferred(address,address)", "OwnershipTransferred", []string{"PoAValidatorManager", "ProxyAdmin"}},
+		{"Upgraded(address)", "Upgraded", []string{"TransparentUpgradeableProxy"}},
+		{"AdminChanged(address,address)", "AdminChanged", []string{"TransparentUpgradeableProxy"}},
+		{"SendWarpMessage(address,bytes32,bytes)", "SendWarpMessage", []string{"WarpPrecompile"}},
+	}
+	for _, test := range tests {
+		topic := crypto.Keccak256Hash([]byte(test.signature))
+		registered, ok := registry.events[topic]
+		if !ok {
+			t.Errorf("%s is not registered under %s", test.signature, topic.Hex())
+			continue
+		}
+		if registered.event.Name != test.wantName || registered.event.Sig != test.signature {
+			t.Errorf("topic of %s resolves to %s", test.signature, registered.event.Sig)
+		}
+		if !slices.Equal(registered.sources, test.wantSources) {
+			t.Errorf("sources of %s = %v, want %v", test.signature, registered.sources, test.wantSources)
+		}
+
+		topics, err := registry.Topics([]string{test.wantName})
+		if err != nil {
+			t.Errorf("Topics(%s) failed: %s", test.wantName, err)
+		} else if !slices.Contains(topics, topic) {
+			t.Errorf("Topics(%s) = %v, want %s", test.wantName, topics, topic.Hex())
+		}
+	}
+
+	if _, err := registry.Topics([]string{"NoSuchEvent"}); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("Topics of an unknown name error = %v, want ErrUnknownEvent", err)
+	}
+	if !slices.IsSorted(registry.EventNames()) {
+		t.Errorf("EventNames() is not sorted: %v", registry.EventNames())
+	}
+}
+
+func TestEventRegistryDecode(t *testing.T) {
+	registry, err := DefaultEventRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	validationID := common.HexToHash("0x6f1c7c5d2a1d9e0f4b8c3a2e1d0c9b8a7f6e5d4c3b2a19081726354453627181")
+	messageID := common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111")
+	nodeID := []byte{0xa1, 0xb2, 0xc3, 0xd4, 0xe5, 0xf6, 0x07, 0x18, 0x29, 0x3a, 0x4b, 0x5c, 0x6d, 0x7e, 0x8f, 0x90, 0x01, 0x12, 0x23, 0x34}
+	pack := func(types []string, values ...any) []byte {
+		var arguments abi.Arguments
+		for _, typeName := range types {
+			argType, err := abi.NewType(typeName, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			arguments = append(arguments, abi.Argument{Type: argType})
+		}
+		data, err := arguments.Pack(values...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name       string
+		log        types.Log
+		wantEvent  string
+		wantFields map[string]string
+		// wantErr is set for logs that do not decode, wantUnknown if no ABI
+		// has their event
+		wantErr     bool
+		wantUnknown bool
+	}{
+		{
+			name: "weight update",
+			log: types.Log{
+				Topics: []common.Hash{crypto.Keccak256Hash([]byte("ValidatorWeightUpdate(bytes32,uint64,uint64,bytes32)")), validationID, common.BigToHash(big.NewInt(7))},
+				Data:   pack([]string{"uint64", "bytes32"}, uint64(200), [32]byte(messageID)),
+			},
+			wantEvent: "ValidatorWeightUpdate",
+			wantFields: map[string]string{
+				"validationID":       validationID.Hex()[2:],
+				"nonce":              "7",
+				"weight":             "200",
+				"setWeightMessageID": messageID.Hex()[2:],
+			},
+		},
+		{
+			name: "dynamic indexed argument stays a hash",
+			log: types.Log{
+				Topics: []common.Hash{crypto.Keccak256Hash([]byte("InitialValidatorCreated(bytes32,bytes,uint64)")), validationID, crypto.Keccak256Hash(nodeID)},
+				Data:   pack([]string{"uint64"}, uint64(100)),
+			},
+			wantEvent: "InitialValidatorCreated",
+			wantFields: map[string]string{
+				"validationID": validationID.Hex()[2:],
+				"nodeID":       crypto.Keccak256Hash(nodeID).Hex()[2:],
+				"weight":       "100",
+			},
+		},
+		{
+			name: "node ID in data",
+			log: types.Log{
+				Topics: []common.Hash{crypto.Keccak256Hash([]byte("SendWarpMessage(address,bytes32,bytes)")), common.BytesToHash(common.HexToAddress("0x0C0DEBA5E0000000000000000000000000000000").Bytes()), messageID},
+				Data:   pack([]string{"bytes"}, nodeID),
+			},
+			wantEvent: "SendWarpMessage",
+			wantFields: map[string]string{
+				"sender":    common.HexToAddress("0x0C0DEBA5E0000000000000000000000000000000").Hex(),
+				"messageID": messageID.Hex()[2:],
+				"message":   common.Bytes2Hex(nodeID),
+			},
+		},
+		{
+			name:    "unknown topic",
+			log:     types.Log{Topics: []common.Hash{messageID}},
+			wantErr: true, wantUnknown: true,
+		},
+		{
+			name:    "anonymous",
+			log:     types.Log{},
+			wantErr: true, wantUnknown: true,
+		},
+		{
+			name: "missing indexed topic",
+			log: types.Log{
+				Topics: []common.Hash{crypto.Keccak256Hash([]byte("ValidationPeriodEnded(bytes32,uint8)")), validationID},
+			},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded, err := registry.Decode(test.log)
+			if test.wantErr {
+				if err == nil || errors.Is(err, ErrUnknownEvent) != test.wantUnknown {
+					t.Fatalf("Decode() error = %v, want unknown event %v", err, test.wantUnknown)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() failed: %s", err)
+			}
+			if decoded.Event != test.wantEvent {
+				t.Errorf("event = %s, want %s", decoded.Event, test.wantEvent)
+			}
+			if len(decoded.Fields) != len(test.wantFields) {
+				t.Errorf("fields = %v, want %d", decoded.Fields, len(test.wantFields))
+			}
+			for _, field := range decoded.Fields {
+				if want := test.wantFields[field.Name]; field.String() != want {
+					t.Errorf("%s = %s, want %s", field.Name, field.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogFieldNodeID(t *testing.T) {
+	nodeID := make([]byte, 20)
+	nodeID[19] = 1
+	tests := []struct {
+		field LogField
+		want  string
+	}{
+		{LogField{Name: "nodeID", Value: nodeID}, "NodeID-1111111111111111111Ax1asG"},
+		{LogField{Name: "message", Value: nodeID}, "0000000000000000000000000000000000000001"},
+		{LogField{Name: "nodeID", Value: []byte{1, 2}}, "0102"},
+		{LogField{Name: "weight", Value: big.NewInt(12345)}, "12345"},
+		{LogField{Name: "status", Value: uint8(3)}, "3"},
+	}
+	for _, test := range tests {
+		if got := test.field.String(); got != test.want {
+			t.Errorf("%s %v = %s, want %s", test.field.Name, test.field.Value, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package l1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	goethereumcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// InvalidateRegistration ends a registration that expired before it reached
+// the P-chain. The P-chain signs that the validation never will be
+// registered, justified by the RegisterL1Validator message, and
+// completeEndValidation marks it invalidated, which frees the node ID for a
+// new registration.
+func (m *ValidatorManager) InvalidateRegistration(ctx context.Context, validationID ids.ID) (goethereumcommon.Hash, error) {
+	validation, err := m.Validation(ctx, validationID)
+	if err != nil {
+		return goethereumcommon.Hash{}, err
+	}
+	if validation.Status != ValidationPendingAdded {
+		return goethereumcommon.Hash{}, fmt.Errorf("validation %s is %s, only pending-added ones can be invalidated: %w", validationID, validation.Status, ErrInvalidValidatorStatus)
+	}
+	_, err = m.L1.L1Validator(ctx, validationID)
+	if err == nil {
+		return goethereumcommon.Hash{}, fmt.Errorf("validation %s is registered on the P-chain, complete it instead: %w", validationID, ErrInvalidValidatorStatus)
+	}
+	if !errors.Is(err, ErrValidatorNotFound) {
+		return goethereumcommon.Hash{}, err
+	}
+	registration, err := m.registration(ctx, validationID)
+	if err != nil {
+		return goethereumcommon.Hash{}, err
+	}
+	expiry := time.Unix(int64(registration.Expiry), 0)
+	if !time.Now().After(expiry) {
+		return goethereumcommon.Hash{}, fmt.Errorf("validation %s expires at %s: %w", validationID, expiry.UTC().Format(time.RFC3339), ErrRegistrationNotExpired)
+	}
+
+	txHash, err := m.CompleteValidatorRemoval(ctx, validationID)
+	if err != nil {
+		return txHash, fmt.Errorf("failed to invalidate registration %s: %w", validationID, err)
+	}
+	m.L1.Workspace.Log.Printf("✅ Invalidated the expired registration %s of %s\n", validationID, validation.NodeID)
+	return txHash, nil
+}
+
+// resumeExpiry is the expiry to register the node with when the one of an
+// earlier attempt passed. A registration the contract has is resumed with its
+// own expiry if it reached the P-chain or did not expire yet. An expired
+// one is invalidated first, then the node gets a new expiry.
+func (m *ValidatorManager) resumeExpiry(ctx context.Context, nodeID ids.NodeID, expiry uint64) (uint64, error) {
+	validationID, err := m.ValidationID(ctx, nodeID)
+	if errors.Is(err, ErrValidatorNotFound) {
+		return NewRegistrationExpiry(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get validation of %s: %w", nodeID, err)
+	}
+	validation, err := m.Validation(ctx, validationID)
+	if err != nil {
+		return 0, err
+	}
+	if validation.Status != ValidationPendingAdded {
+		return expiry, nil
+	}
+	registration, err := m.registration(ctx, validationID)
+	if err != nil {
+		return 0, err
+	}
+	_, err = m.L1.L1Validator(ctx, validationID)
+	if err == nil || registration.Expiry > uint64(time.Now().Unix()) {
+		return registration.Expiry, nil
+	}
+	if !errors.Is(err, ErrValidatorNotFound) {
+		return 0, err
+	}
+	m.L1.Workspace.Log.Printf("The registration of %s expired before it reached the P-chain, invalidating it\n", nodeID)
+	if _, err := m.InvalidateRegistration(ctx, validationID); err != nil {
+		return 0, err
+	}
+	return NewRegistrationExpiry(), nil
+}
@@ -0,0 +1,127 @@
+package l1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/avalanche-cli/cmd/blockchaincmd"
+	"github.com/ava-labs/avalanche-cli/pkg/contract"
+	"github.com/ava-labs/avalanche-cli/sdk/interchain"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// L1 is a converted subnet and its chain
+type L1 struct {
+	Workspace *Workspace
+	SubnetID  ids.ID
+	ChainID   ids.ID
+	// NodeURI is the base URL of a node validating the L1. It serves the EVM
+	// calls and is the peer the signature aggregator starts from.
+	NodeURI string
+}
+
+// L1 reaches the chain of the subnet through the node at nodeURI
+func (w *Workspace) L1(subnetID ids.ID, chainID ids.ID, nodeURI string) *L1 {
+	return &L1{Workspace: w, SubnetID: subnetID, ChainID: chainID, NodeURI: nodeURI}
+}
+
+// RPCURL is the EVM JSON-RPC endpoint of the chain
+func (l *L1) RPCURL() string {
+	return fmt.Sprintf("%s/ext/bc/%s/rpc", l.NodeURI, l.ChainID)
+}
+
+// IsValidator reports whether the node is in the validator set of the L1 at
+// the proposed height
+func (l *L1) IsValidator(ctx context.Context, nodeID ids.NodeID) (bool, error) {
+	return l.Workspace.Tracker().IsL1Validator(ctx, l.SubnetID, nodeID)
+}
+
+// signWarp collects signatures of the L1 validators for the message,
+// justification is only needed for messages the P-chain signs about
+// validations it no longer knows
+func (l *L1) signWarp(ctx context.Context, unsignedMessage *warp.UnsignedMessage, justification []byte) (*warp.Message, error) {
+	type signResult struct {
+		message *warp.Message
+		err     error
+	}
+	// The aggregator takes no context, on cancellation it is left to finish
+	// in the background. Signing has no side effects.
+	done := make(chan signResult, 1)
+	go func() {
+		message, err := l.aggregateSignatures(unsignedMessage, justification)
+		done <- signResult{message, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to aggregate signatures: %w", ctx.Err())
+	case result := <-done:
+		return result.message, result.err
+	}
+}
+
+func (l *L1) aggregateSignatures(unsignedMessage *warp.UnsignedMessage, justification []byte) (*warp.Message, error) {
+	peers, err := blockchaincmd.ConvertURIToPeers([]string{l.NodeURI})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get extra peers: %w", err)
+	}
+	signatureAggregator, err := interchain.NewSignatureAggregator(
+		l.Workspace.Network.cliNetwork(),
+		logging.Level(logging.Info),
+		l.SubnetID,
+		interchain.DefaultQuorumPercentage,
+		true,
+		peers,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signature aggregator: %w", err)
+	}
+	signedMessage, err := signatureAggregator.Sign(unsignedMessage, justification)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate signatures: %w", err)
+	}
+	return signedMessage, nil
+}
+
+// ValidatorManager is the PoA validator manager contract of an L1
+type ValidatorManager struct {
+	L1      *L1
+	Address common.Address
+	// WarpIndex keeps the warp messages the contract sent, an in-memory one
+	// is used if it is nil
+	WarpIndex *WarpIndex
+	// ChurnPolicy is what adding or removing a validator does when its
+	// weight does not fit the churn period of the contract
+	ChurnPolicy ChurnPolicy
+}
+
+// ValidatorManager is the contract at address on the chain
+func (l *L1) ValidatorManager(address common.Address) *ValidatorManager {
+	return &ValidatorManager{L1: l, Address: address}
+}
+
+// ValidationID is the validation of the node the contract knows about
+func (m *ValidatorManager) ValidationID(ctx context.Context, nodeID ids.NodeID) (ids.ID, error) {
+	if err := ctx.Err(); err != nil {
+		return ids.Empty, err
+	}
+	out, err := contract.CallToMethod(
+		m.L1.RPCURL(),
+		m.Address,
+		"registeredValidators(bytes)->(bytes32)",
+		nodeID[:],
+	)
+	if err != nil {
+		return ids.Empty, err
+	}
+	validationID, ok := out[0].([32]byte)
+	if !ok {
+		return ids.Empty, fmt.Errorf("error at registeredValidators call, expected [32]byte, got %T", out[0])
+	}
+	if ids.ID(validationID) == ids.Empty {
+		return ids.Empty, fmt.Errorf("%s: %w", nodeID, ErrValidatorNotFound)
+	}
+	return validationID, nil
+}
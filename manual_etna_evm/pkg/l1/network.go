@@ -0,0 +1,32 @@
+package l1
+
+import (
+	"github.com/ava-labs/avalanche-cli/pkg/models"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/config"
+)
+
+// Network is the primary network an L1 is created on
+type Network struct {
+	// ID is the network ID warp messages are signed for
+	ID uint32
+	// URI is the base URL of a primary network API node
+	URI string
+}
+
+// FujiNetwork uses the public Fuji API
+func FujiNetwork() Network {
+	return Network{ID: constants.FujiID, URI: config.RPC_URL}
+}
+
+// cliNetwork is the network for avalanche-cli helpers like the signature
+// aggregator, which derives the Etna activation from its kind
+func (n Network) cliNetwork() models.Network {
+	switch n.ID {
+	case constants.MainnetID:
+		return models.NewNetwork(models.Mainnet, n.ID, n.URI, "")
+	case constants.FujiID:
+		return models.NewNetwork(models.Fuji, n.ID, n.URI, "")
+	}
+	return models.NewNetwork(models.Local, n.ID, n.URI, "")
+}
@@ -0,0 +1,130 @@
+package l1
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	platformapi "github.com/ava-labs/avalanchego/vms/platformvm/api"
+	"github.com/ava-labs/avalanchego/vms/platformvm/status"
+)
+
+const (
+	// PChainTxTimeout bounds waiting for a P-chain tx and its effect on the
+	// validator set
+	PChainTxTimeout = 5 * time.Minute
+
+	pChainTxPollInterval = time.Second
+	// A tx the node still does not know after this long never made it into
+	// its mempool, or was evicted from it
+	pChainTxUnknownTimeout = time.Minute
+	pChainTxReportInterval = 15 * time.Second
+)
+
+// PChainTxTracker follows P-chain txs issued with common.WithAssumeDecided
+// until they are accepted and visible to warp signers
+type PChainTxTracker struct {
+	client platformvm.Client
+	log    *log.Logger
+}
+
+// NewPChainTxTracker tracks txs through the primary network API at uri and
+// reports progress to logger
+func NewPChainTxTracker(uri string, logger *log.Logger) *PChainTxTracker {
+	return &PChainTxTracker{client: platformvm.NewClient(uri), log: logger}
+}
+
+// AwaitTx blocks until the tx is committed, what names the tx in progress
+// messages
+func (t *PChainTxTracker) AwaitTx(ctx context.Context, txID ids.ID, what string) error {
+	start := time.Now()
+	lastReport := start
+	for {
+		res, err := t.client.GetTxStatus(ctx, txID)
+		if err != nil {
+			return fmt.Errorf("failed to get status of %s tx %s: %w", what, txID, err)
+		}
+
+		switch res.Status {
+		case status.Committed:
+			t.log.Printf("✅ %s tx %s accepted in %s\n", what, txID, time.Since(start).Round(time.Millisecond))
+			return nil
+		case status.Aborted:
+			return fmt.Errorf("%s tx %s: %w", what, txID, ErrTxAborted)
+		case status.Dropped:
+			return fmt.Errorf("%s tx %s: %w: %s", what, txID, ErrTxDropped, res.Reason)
+		case status.Unknown:
+			if time.Since(start) > pChainTxUnknownTimeout {
+				return fmt.Errorf("%s tx %s is unknown to the node %s after issuance, it was never accepted into the mempool", what, txID, pChainTxUnknownTimeout)
+			}
+		}
+
+		if time.Since(lastReport) >= pChainTxReportInterval {
+			t.log.Printf("⏳ Waiting for %s tx %s, status %s after %s\n", what, txID, res.Status, time.Since(start).Round(time.Second))
+			lastReport = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s tx %s still %s: %w", what, txID, res.Status, ctx.Err())
+		case <-time.After(pChainTxPollInterval):
+		}
+	}
+}
+
+// IsL1Validator reports whether the node is in the validator set of the
+// subnet at the proposed height
+func (t *PChainTxTracker) IsL1Validator(ctx context.Context, subnetID ids.ID, nodeID ids.NodeID) (bool, error) {
+	validators, err := t.client.GetValidatorsAt(ctx, subnetID, platformapi.ProposedHeight)
+	if err != nil {
+		return false, fmt.Errorf("failed to get validators of subnet %s: %w", subnetID, err)
+	}
+	_, ok := validators[nodeID]
+	return ok, nil
+}
+
+// AwaitL1Validator blocks until the node is in the validator set of the
+// subnet at the proposed height, or gone from it if present is false. Warp
+// signers and the P-chain checks of later txs use that height, which trails
+// the accepted tip.
+func (t *PChainTxTracker) AwaitL1Validator(ctx context.Context, subnetID ids.ID, nodeID ids.NodeID, present bool) error {
+	start := time.Now()
+	lastReport := start
+	for {
+		ok, err := t.IsL1Validator(ctx, subnetID, nodeID)
+		if err != nil {
+			return err
+		}
+		if ok == present {
+			if present {
+				t.log.Printf("✅ %s is in the validator set at the proposed height\n", nodeID)
+			} else {
+				t.log.Printf("✅ %s is out of the validator set at the proposed height\n", nodeID)
+			}
+			return nil
+		}
+
+		if time.Since(lastReport) >= pChainTxReportInterval {
+			t.log.Printf("⏳ Waiting for the proposed height to reflect %s, %s so far\n", nodeID, time.Since(start).Round(time.Second))
+			lastReport = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("validator set at the proposed height does not reflect %s yet: %w", nodeID, ctx.Err())
+		case <-time.After(pChainTxPollInterval):
+		}
+	}
+}
+
+// AwaitL1ValidatorTx waits for a tx that adds or removes the node and then
+// for the proposed height to reflect it
+func (t *PChainTxTracker) AwaitL1ValidatorTx(ctx context.Context, txID ids.ID, what string, subnetID ids.ID, nodeID ids.NodeID, present bool) error {
+	if err := t.AwaitTx(ctx, txID, what); err != nil {
+		return err
+	}
+	return t.AwaitL1Validator(ctx, subnetID, nodeID, present)
+}
@@ -0,0 +1,239 @@
+package l1
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	warpPayload "github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
+)
+
+// DiscrepancyKind is how the P-chain and the contract disagree about a
+// validation
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyRegistrationNotCompleted means the P-chain registered the
+	// validator but the contract still has it pending added
+	DiscrepancyRegistrationNotCompleted DiscrepancyKind = "registration-not-completed"
+	// DiscrepancyRegistrationNotOnPChain means the contract initialized the
+	// registration but it never reached the P-chain
+	DiscrepancyRegistrationNotOnPChain DiscrepancyKind = "registration-not-on-p-chain"
+	// DiscrepancyRegistrationExpired is DiscrepancyRegistrationNotOnPChain
+	// after the expiry of the registration, the P-chain refuses it for good
+	// and the node ID stays taken until it is invalidated
+	DiscrepancyRegistrationExpired DiscrepancyKind = "registration-expired"
+	// DiscrepancyRemovalNotOnPChain means the contract ended the validation
+	// but the P-chain still has the validator
+	DiscrepancyRemovalNotOnPChain DiscrepancyKind = "removal-not-on-p-chain"
+	// DiscrepancyRemovalNotCompleted means the P-chain removed the validator
+	// but the contract still has it pending removed
+	DiscrepancyRemovalNotCompleted DiscrepancyKind = "removal-not-completed"
+	// DiscrepancyRemovedOnPChain means the P-chain removed a validator the
+	// contract has as active, e.g. after a P-chain only removal
+	DiscrepancyRemovedOnPChain DiscrepancyKind = "removed-on-p-chain"
+	// DiscrepancyDeactivated means the P-chain deactivated a validator the
+	// contract has as active, it was disabled or its balance ran out
+	DiscrepancyDeactivated DiscrepancyKind = "deactivated-on-p-chain"
+	// DiscrepancyWeightMismatch means the P-chain has another weight than the
+	// contract for an active validation
+	DiscrepancyWeightMismatch DiscrepancyKind = "weight-mismatch"
+	// DiscrepancyNotInContract means the node is in the P-chain validator
+	// set without a validation in the contract, e.g. before the validator
+	// set was initialized
+	DiscrepancyNotInContract DiscrepancyKind = "not-in-contract"
+)
+
+// ReconcileAction is what brings the contract and the P-chain in line again
+type ReconcileAction string
+
+const (
+	// ActionCompleteRegistration is CompleteValidatorRegistration
+	ActionCompleteRegistration ReconcileAction = "complete-registration"
+	// ActionResubmitRegistration is ResubmitRegistration, it needs the BLS
+	// proof of possession of the node
+	ActionResubmitRegistration ReconcileAction = "resubmit-registration"
+	// ActionInvalidateRegistration is InvalidateRegistration
+	ActionInvalidateRegistration ReconcileAction = "invalidate-registration"
+	// ActionResubmitWeight is ResubmitWeightUpdate
+	ActionResubmitWeight ReconcileAction = "resubmit-weight"
+	// ActionCompleteRemoval is CompleteValidatorRemoval
+	ActionCompleteRemoval ReconcileAction = "complete-end-validation"
+	// ActionRemove is RemoveValidator, which skips the P-chain step for a
+	// validator the P-chain no longer has
+	ActionRemove ReconcileAction = "remove"
+	// ActionTopUpOrRemove is IncreaseValidatorBalance to reactivate the
+	// validator, or RemoveValidator if it was disabled on purpose
+	ActionTopUpOrRemove ReconcileAction = "top-up-or-remove"
+	// ActionInitializeValidatorSet is InitializeValidatorSet with the
+	// validators of the conversion
+	ActionInitializeValidatorSet ReconcileAction = "initialize-validator-set"
+	// ActionNone means there is nothing this package can do about it
+	ActionNone ReconcileAction = "none"
+)
+
+// Discrepancy is a validation the P-chain and the contract disagree about
+type Discrepancy struct {
+	NodeID       ids.NodeID
+	ValidationID ids.ID
+	Kind         DiscrepancyKind
+	Action       ReconcileAction
+	// Detail explains the discrepancy in a sentence
+	Detail string
+}
+
+// Reconcile compares the P-chain validators of the L1 with the validations
+// of the contract, read from its storage and found through its warp events
+func (m *ValidatorManager) Reconcile(ctx context.Context) ([]Discrepancy, error) {
+	states, err := m.ValidatorStates(ctx, ProposedHeight)
+	if err != nil {
+		return nil, err
+	}
+	var discrepancies []Discrepancy
+	for _, state := range states {
+		discrepancy, ok, err := m.discrepancy(ctx, state)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			discrepancies = append(discrepancies, discrepancy)
+		}
+	}
+	return discrepancies, nil
+}
+
+// discrepancy classifies the state of a validation, ok is false if the
+// P-chain and the contract agree
+func (m *ValidatorManager) discrepancy(ctx context.Context, state ValidatorState) (Discrepancy, bool, error) {
+	discrepancy := Discrepancy{NodeID: state.NodeID, ValidationID: state.ValidationID}
+	onPChain := state.L1Validator != nil
+	if state.Validation == nil {
+		if !state.InSet {
+			return discrepancy, false, nil
+		}
+		discrepancy.Kind = DiscrepancyNotInContract
+		discrepancy.Action = ActionInitializeValidatorSet
+		discrepancy.Detail = "the node validates on the P-chain but the contract has no validation for it"
+		return discrepancy, true, nil
+	}
+
+	switch status := state.Validation.Status; {
+	case status == ValidationPendingAdded && onPChain:
+		discrepancy.Kind = DiscrepancyRegistrationNotCompleted
+		discrepancy.Action = ActionCompleteRegistration
+		discrepancy.Detail = "the P-chain registered the validator, the contract still has it pending"
+	case status == ValidationPendingAdded:
+		registration, err := m.registration(ctx, state.ValidationID)
+		if err != nil {
+			return discrepancy, false, err
+		}
+		expiry := time.Unix(int64(registration.Expiry), 0)
+		if time.Now().After(expiry) {
+			discrepancy.Kind = DiscrepancyRegistrationExpired
+			discrepancy.Action = ActionInvalidateRegistration
+			discrepancy.Detail = fmt.Sprintf("the registration expired at %s before it reached the P-chain", expiry.UTC().Format(time.RFC3339))
+		} else {
+			discrepancy.Kind = DiscrepancyRegistrationNotOnPChain
+			discrepancy.Action = ActionResubmitRegistration
+			discrepancy.Detail = fmt.Sprintf("the registration never reached the P-chain, it expires at %s", expiry.UTC().Format(time.RFC3339))
+		}
+	case status == ValidationActive && !onPChain:
+		discrepancy.Kind = DiscrepancyRemovedOnPChain
+		discrepancy.Action = ActionRemove
+		discrepancy.Detail = "the P-chain no longer has the validator, the contract has it active"
+	case status == ValidationActive && state.L1Validator.Balance == 0:
+		discrepancy.Kind = DiscrepancyDeactivated
+		discrepancy.Action = ActionTopUpOrRemove
+		discrepancy.Detail = "the P-chain deactivated the validator, the contract has it active"
+	case status == ValidationActive && state.L1Validator.Weight != state.Validation.Weight:
+		discrepancy.Kind = DiscrepancyWeightMismatch
+		discrepancy.Action = ActionResubmitWeight
+		discrepancy.Detail = fmt.Sprintf("the P-chain has a weight of %d, the contract %d", state.L1Validator.Weight, state.Validation.Weight)
+	case (status == ValidationPendingRemoved || status == ValidationCompleted || status == ValidationInvalidated) && onPChain:
+		discrepancy.Kind = DiscrepancyRemovalNotOnPChain
+		discrepancy.Action = ActionResubmitWeight
+		discrepancy.Detail = fmt.Sprintf("the contract ended the validation, the P-chain still has it with a weight of %d", state.L1Validator.Weight)
+	case status == ValidationPendingRemoved:
+		discrepancy.Kind = DiscrepancyRemovalNotCompleted
+		discrepancy.Action = ActionCompleteRemoval
+		discrepancy.Detail = "the P-chain removed the validator, the contract still has its removal pending"
+	default:
+		return discrepancy, false, nil
+	}
+	return discrepancy, true, nil
+}
+
+// registration is the RegisterL1Validator message the contract sent for the
+// validation
+func (m *ValidatorManager) registration(ctx context.Context, validationID ids.ID) (*warpMessage.RegisterL1Validator, error) {
+	unsignedBytes, err := m.RegistrationMessage(ctx, validationID)
+	if err != nil {
+		return nil, err
+	}
+	unsignedMessage, err := warp.ParseUnsignedMessage(unsignedBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse registration message: %w", err)
+	}
+	addressedCall, err := warpPayload.ParseAddressedCall(unsignedMessage.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse registration message: %w", err)
+	}
+	return warpMessage.ParseRegisterL1Validator(addressedCall.Payload)
+}
+
+// ResubmitRegistration signs the RegisterL1Validator message the contract
+// sent for the validation again and registers it on the P-chain. validator
+// needs the proof of possession of the node and the balance to fund it with.
+func (m *ValidatorManager) ResubmitRegistration(ctx context.Context, validationID ids.ID, validator Validator) (ids.ID, error) {
+	registration, err := m.registration(ctx, validationID)
+	if err != nil {
+		return ids.Empty, err
+	}
+	if !bytes.Equal(registration.NodeID, validator.NodeID[:]) {
+		return ids.Empty, fmt.Errorf("validation %s is not of %s", validationID, validator.NodeID)
+	}
+	unsignedBytes, err := m.RegistrationMessage(ctx, validationID)
+	if err != nil {
+		return ids.Empty, err
+	}
+	message, err := m.resign(ctx, unsignedBytes)
+	if err != nil {
+		return ids.Empty, err
+	}
+	return m.L1.RegisterValidator(ctx, validator, message)
+}
+
+// ResubmitWeightUpdate signs the last L1ValidatorWeight message the contract
+// sent for the validation again and applies it on the P-chain
+func (m *ValidatorManager) ResubmitWeightUpdate(ctx context.Context, validationID ids.ID, nodeID ids.NodeID) (ids.ID, error) {
+	index := m.warpIndex()
+	validation, ok := index.Validation(validationID)
+	if !ok || len(validation.WeightUpdates) == 0 {
+		if err := m.SyncWarpIndex(ctx); err != nil {
+			return ids.Empty, err
+		}
+		validation, _ = index.Validation(validationID)
+	}
+	if len(validation.WeightUpdates) == 0 {
+		return ids.Empty, fmt.Errorf("weight update of validation %s not found on warp events: %w", validationID, ErrValidatorNotFound)
+	}
+	message, err := m.resign(ctx, validation.WeightUpdates[len(validation.WeightUpdates)-1])
+	if err != nil {
+		return ids.Empty, err
+	}
+	return m.L1.SetValidatorWeight(ctx, nodeID, message)
+}
+
+// resign collects signatures of the L1 validators for a message the
+// contract sent
+func (m *ValidatorManager) resign(ctx context.Context, unsignedBytes []byte) (*warp.Message, error) {
+	unsignedMessage, err := warp.ParseUnsignedMessage(unsignedBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse warp message: %w", err)
+	}
+	return m.L1.signWarp(ctx, unsignedMessage, nil)
+}
@@ -0,0 +1,165 @@
+package l1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	warpPayload "github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
+)
+
+func TestDiscrepancy(t *testing.T) {
+	var (
+		subnetID = ids.ID{1, 2, 3}
+		chainID  = ids.ID{9, 8, 7}
+		nodeID   = ids.NodeID{0x10}
+		owner    = warpMessage.PChainOwner{}
+	)
+	// registration adds the RegisterL1Validator message of a validation
+	// expiring at expiry to the warp index, so classifying it needs no RPC
+	index := NewWarpIndex()
+	registration := func(expiry time.Time) ids.ID {
+		message, err := warpMessage.NewRegisterL1Validator(subnetID, nodeID, [48]byte{1}, uint64(expiry.Unix()), owner, owner, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addressedCall, err := warpPayload.NewAddressedCall(nil, message.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		unsigned, err := warp.NewUnsignedMessage(constants.FujiID, chainID, addressedCall.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := index.add(map[ids.ID][]byte{message.ValidationID(): unsigned.Bytes()}, nil, 0); err != nil {
+			t.Fatal(err)
+		}
+		return message.ValidationID()
+	}
+	expired := registration(time.Now().Add(-time.Hour))
+	pending := registration(time.Now().Add(time.Hour))
+	validation := func(status ValidationStatus, weight uint64) *Validation {
+		return &Validation{Status: status, NodeID: nodeID, Weight: weight}
+	}
+	onPChain := func(weight uint64, balance uint64) *platformvm.L1Validator {
+		return &platformvm.L1Validator{NodeID: nodeID, Weight: weight, Balance: balance}
+	}
+
+	tests := []struct {
+		name       string
+		state      ValidatorState
+		wantOK     bool
+		wantKind   DiscrepancyKind
+		wantAction ReconcileAction
+	}{
+		{
+			name:  "neither has the node",
+			state: ValidatorState{},
+		},
+		{
+			name:     "in the set without a validation",
+			state:    ValidatorState{InSet: true, SetWeight: 100},
+			wantOK:   true,
+			wantKind: DiscrepancyNotInContract, wantAction: ActionInitializeValidatorSet,
+		},
+		{
+			name:     "registered on the P-chain, pending in the contract",
+			state:    ValidatorState{L1Validator: onPChain(20, 1), Validation: validation(ValidationPendingAdded, 20)},
+			wantOK:   true,
+			wantKind: DiscrepancyRegistrationNotCompleted, wantAction: ActionCompleteRegistration,
+		},
+		{
+			name:     "registration not on the P-chain",
+			state:    ValidatorState{ValidationID: pending, Validation: validation(ValidationPendingAdded, 20)},
+			wantOK:   true,
+			wantKind: DiscrepancyRegistrationNotOnPChain, wantAction: ActionResubmitRegistration,
+		},
+		{
+			name:     "registration expired",
+			state:    ValidatorState{ValidationID: expired, Validation: validation(ValidationPendingAdded, 20)},
+			wantOK:   true,
+			wantKind: DiscrepancyRegistrationExpired, wantAction: ActionInvalidateRegistration,
+		},
+		{
+			name:  "active on both",
+			state: ValidatorState{InSet: true, L1Validator: onPChain(20, 1), Validation: validation(ValidationActive, 20)},
+		},
+		{
+			name:     "active in the contract only",
+			state:    ValidatorState{Validation: validation(ValidationActive, 20)},
+			wantOK:   true,
+			wantKind: DiscrepancyRemovedOnPChain, wantAction: ActionRemove,
+		},
+		{
+			name:     "deactivated on the P-chain",
+			state:    ValidatorState{L1Validator: onPChain(20, 0), Validation: validation(ValidationActive, 20)},
+			wantOK:   true,
+			wantKind: DiscrepancyDeactivated, wantAction: ActionTopUpOrRemove,
+		},
+		{
+			name:     "weights differ",
+			state:    ValidatorState{InSet: true, L1Validator: onPChain(30, 1), Validation: validation(ValidationActive, 20)},
+			wantOK:   true,
+			wantKind: DiscrepancyWeightMismatch, wantAction: ActionResubmitWeight,
+		},
+		{
+			name:     "removal pending, still on the P-chain",
+			state:    ValidatorState{InSet: true, L1Validator: onPChain(20, 1), Validation: validation(ValidationPendingRemoved, 0)},
+			wantOK:   true,
+			wantKind: DiscrepancyRemovalNotOnPChain, wantAction: ActionResubmitWeight,
+		},
+		{
+			name:     "completed, still on the P-chain",
+			state:    ValidatorState{InSet: true, L1Validator: onPChain(20, 1), Validation: validation(ValidationCompleted, 0)},
+			wantOK:   true,
+			wantKind: DiscrepancyRemovalNotOnPChain, wantAction: ActionResubmitWeight,
+		},
+		{
+			name:     "invalidated, still on the P-chain",
+			state:    ValidatorState{InSet: true, L1Validator: onPChain(20, 1), Validation: validation(ValidationInvalidated, 0)},
+			wantOK:   true,
+			wantKind: DiscrepancyRemovalNotOnPChain, wantAction: ActionResubmitWeight,
+		},
+		{
+			name:     "removed on the P-chain, pending in the contract",
+			state:    ValidatorState{Validation: validation(ValidationPendingRemoved, 0)},
+			wantOK:   true,
+			wantKind: DiscrepancyRemovalNotCompleted, wantAction: ActionCompleteRemoval,
+		},
+		{
+			name:  "completed on both",
+			state: ValidatorState{Validation: validation(ValidationCompleted, 0)},
+		},
+		{
+			name:  "invalidated on both",
+			state: ValidatorState{Validation: validation(ValidationInvalidated, 0)},
+		},
+	}
+	m := &ValidatorManager{WarpIndex: index}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.state.NodeID = nodeID
+			got, ok, err := m.discrepancy(context.Background(), test.state)
+			if err != nil {
+				t.Fatalf("failed to classify: %s", err)
+			}
+			if ok != test.wantOK {
+				t.Fatalf("ok = %v, want %v, discrepancy %+v", ok, test.wantOK, got)
+			}
+			if !ok {
+				return
+			}
+			if got.Kind != test.wantKind || got.Action != test.wantAction {
+				t.Errorf("discrepancy = %s, %s, want %s, %s", got.Kind, got.Action, test.wantKind, test.wantAction)
+			}
+			if got.NodeID != nodeID || got.ValidationID != test.state.ValidationID || got.Detail == "" {
+				t.Errorf("discrepancy = %+v, want the node, the validation and a detail", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package l1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/avalanche-cli/pkg/contract"
+	"github.com/ava-labs/avalanche-cli/pkg/evm"
+	validatorManagerSDK "github.com/ava-labs/avalanche-cli/sdk/validatormanager"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	warpPayload "github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+	goethereumcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// Removal is what removing a validator produced
+type Removal struct {
+	ValidationID ids.ID
+	// InitializeTxHash is empty if an earlier attempt started the removal
+	InitializeTxHash goethereumcommon.Hash
+	// SetWeightTxID is empty if the validator was already out of the P-chain
+	// validator set
+	SetWeightTxID  ids.ID
+	CompleteTxHash goethereumcommon.Hash
+}
+
+// RemoveValidator ends the validation of the node in the contract, sets its
+// weight to zero on the P-chain and completes the removal in the contract.
+// It picks up removals a previous attempt left halfway.
+func (m *ValidatorManager) RemoveValidator(ctx context.Context, nodeID ids.NodeID) (*Removal, error) {
+	message, removal, err := m.InitializeValidatorRemoval(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A disabled validator is out of the validator set but still registered
+	// on the P-chain, its weight is set to zero all the same
+	_, err = m.L1.L1Validator(ctx, removal.ValidationID)
+	switch {
+	case err == nil:
+		removal.SetWeightTxID, err = m.L1.SetValidatorWeight(ctx, nodeID, message)
+		if err != nil {
+			return removal, fmt.Errorf("failed to set L1 validator weight: %w", err)
+		}
+	case errors.Is(err, ErrValidatorNotFound):
+		m.L1.Workspace.Log.Printf("%s is not registered on the P-chain, skipping weight update\n", nodeID)
+	default:
+		return removal, err
+	}
+
+	removal.CompleteTxHash, err = m.CompleteValidatorRemoval(ctx, removal.ValidationID)
+	if err != nil {
+		return removal, err
+	}
+	return removal, nil
+}
+
+// InitializeValidatorRemoval is step 1 of removing a validator: the contract
+// emits an L1ValidatorWeight message with weight zero, which is returned
+// signed by the L1 validators
+func (m *ValidatorManager) InitializeValidatorRemoval(ctx context.Context, nodeID ids.NodeID) (*warp.Message, *Removal, error) {
+	validationID, err := m.ValidationID(ctx, nodeID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get registered validator: %w", err)
+	}
+	removal := &Removal{ValidationID: validationID}
+
+	// Removing takes the whole weight out in the churn period, a removal an
+	// earlier attempt started already did
+	validation, err := m.Validation(ctx, validationID)
+	if err != nil {
+		return nil, removal, err
+	}
+	// Only an active validation can be removed
+	var unlock func()
+	if validation.Status == ValidationActive {
+		unlock, err = m.lockChurnRoom(ctx, validation.Weight)
+		if err != nil {
+			return nil, removal, fmt.Errorf("failed to remove %s: %w", nodeID, err)
+		}
+	} else {
+		unlock = m.L1.Workspace.lockEVMTxs()
+	}
+	defer unlock()
+
+	// The contract helpers take no context, a tx that was sent is waited for
+	if err := ctx.Err(); err != nil {
+		return nil, removal, err
+	}
+	tx, _, err := contract.TxToMethod(
+		m.L1.RPCURL(),
+		m.L1.Workspace.signerHex(),
+		m.Address,
+		big.NewInt(0),
+		"POA validator removal initialization",
+		validatorManagerSDK.ErrorSignatureToError,
+		"initializeEndValidation(bytes32)",
+		validationID,
+	)
+	if err != nil {
+		if !isContractError(err, ErrInvalidValidatorStatus) {
+			return nil, removal, evm.TransactionError(tx, err, "failure initializing validator removal")
+		}
+		m.L1.Workspace.Log.Println("the validator removal process was already initialized. Proceeding to the next step")
+	} else {
+		removal.InitializeTxHash = tx.Hash()
+	}
+	unlock()
+
+	message, err := m.l1ValidatorWeightMessage(ctx, validationID, 1, 0)
+	if err != nil {
+		return nil, removal, fmt.Errorf("failed to get subnet validator weight message: %w", err)
+	}
+	return message, removal, nil
+}
+
+// SetValidatorWeight is step 2 of removing a validator: the P-chain applies
+// the signed L1ValidatorWeight message. With weight zero it returns once the
+// validator is out of the set at the proposed height, the P-chain signs the
+// end of the validation only after that. Other weights return once the
+// validator is in the set. The tx ID is also returned if the tx was issued
+// but waiting for it failed.
+func (l *L1) SetValidatorWeight(ctx context.Context, nodeID ids.NodeID, message *warp.Message) (ids.ID, error) {
+	addressedCall, err := warpPayload.ParseAddressedCall(message.Payload)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("failed to parse weight message: %w", err)
+	}
+	weightUpdate, err := warpMessage.ParseL1ValidatorWeight(addressedCall.Payload)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("failed to parse weight message: %w", err)
+	}
+
+	txID, err := l.Workspace.issuePChainTx(ctx, "set L1 validator weight", func(wallet primary.Wallet, options ...common.Option) (*txs.Tx, error) {
+		return wallet.P().IssueSetL1ValidatorWeightTx(message.Bytes(), options...)
+	})
+	if err != nil {
+		return txID, err
+	}
+	return txID, l.Workspace.awaitL1Validator(ctx, l.SubnetID, nodeID, weightUpdate.Weight > 0)
+}
+
+// CompleteValidatorRemoval is step 3 of removing a validator: the contract
+// deletes the validation once the P-chain signed that it ended
+func (m *ValidatorManager) CompleteValidatorRemoval(ctx context.Context, validationID ids.ID) (goethereumcommon.Hash, error) {
+	signedMessage, err := m.l1ValidatorRegistrationMessage(ctx, validationID, false)
+	if err != nil {
+		return goethereumcommon.Hash{}, fmt.Errorf("failed to get P-chain subnet validator registration warp message: %w", err)
+	}
+
+	unlock := m.L1.Workspace.lockEVMTxs()
+	defer unlock()
+	if err := ctx.Err(); err != nil {
+		return goethereumcommon.Hash{}, err
+	}
+	privateKey := m.L1.Workspace.signerHex()
+	// Warp messages are verified against the P-chain height of the last
+	// block, a fresh block makes sure it includes the removal
+	if err := evm.SetupProposerVM(m.L1.RPCURL(), privateKey); err != nil {
+		return goethereumcommon.Hash{}, fmt.Errorf("failed to set up proposer VM: %w", err)
+	}
+
+	tx, _, err := contract.TxToMethodWithWarpMessage(
+		m.L1.RPCURL(),
+		privateKey,
+		m.Address,
+		signedMessage,
+		big.NewInt(0),
+		"complete poa validator removal",
+		validatorManagerSDK.ErrorSignatureToError,
+		"completeEndValidation(uint32)",
+		uint32(0),
+	)
+	if err != nil {
+		return goethereumcommon.Hash{}, evm.TransactionError(tx, err, "failure completing validator removal")
+	}
+	m.L1.Workspace.Log.Printf("✅ Validator removal completed: %s\n", tx.Hash())
+	return tx.Hash(), nil
+}
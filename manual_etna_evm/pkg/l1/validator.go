@@ -0,0 +1,75 @@
+package l1
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/staking"
+	"github.com/ava-labs/avalanchego/vms/platformvm/signer"
+	"github.com/ava-labs/etna-devnet-resources/manual_etna_evm/helpers"
+)
+
+// Validator is a node with its BLS key and what it stakes on the L1
+type Validator struct {
+	NodeID ids.NodeID
+	// Signer holds the BLS public key and its proof of possession
+	Signer *signer.ProofOfPossession
+	Weight uint64
+	// Balance pays the continuous P-chain fee of the validator, in nAVAX
+	Balance uint64
+}
+
+// ValidatorFromCreds reads the node of a creds folder, weight and balance are
+// left to the caller
+func ValidatorFromCreds(folder string) (Validator, error) {
+	nodeID, pop, err := NodeInfoFromCreds(folder)
+	if err != nil {
+		return Validator{}, err
+	}
+	return Validator{NodeID: nodeID, Signer: pop}, nil
+}
+
+func sortedByNodeID(validators []Validator) []Validator {
+	sorted := slices.Clone(validators)
+	slices.SortFunc(sorted, func(a, b Validator) int {
+		return bytes.Compare(a.NodeID[:], b.NodeID[:])
+	})
+	return sorted
+}
+
+// NodeInfoFromCreds derives the node ID from staker.crt and the proof of
+// possession from signer.key in folder
+func NodeInfoFromCreds(folder string) (ids.NodeID, *signer.ProofOfPossession, error) {
+	if !strings.HasSuffix(folder, "/") {
+		folder += "/"
+	}
+
+	blsKey, err := helpers.LoadBLSKey(folder + "signer.key")
+	if err != nil {
+		return ids.NodeID{}, nil, fmt.Errorf("failed to load BLS key: %w", err)
+	}
+
+	pop := signer.NewProofOfPossession(blsKey)
+	certString, err := helpers.LoadText(folder + "staker.crt")
+	if err != nil {
+		return ids.NodeID{}, nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	block, _ := pem.Decode([]byte(certString))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return ids.NodeID{}, nil, fmt.Errorf("%sstaker.crt has no PEM certificate block: %w", folder, ErrInvalidCredentials)
+	}
+
+	cert, err := staking.ParseCertificate(block.Bytes)
+	if err != nil {
+		return ids.NodeID{}, nil, fmt.Errorf("failed to parse %sstaker.crt: %w: %s", folder, ErrInvalidCredentials, err)
+	}
+
+	nodeID := ids.NodeIDFromCert(cert)
+
+	return nodeID, pop, nil
+}
@@ -0,0 +1,318 @@
+package l1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Chains of index entries
+const (
+	IndexChainL1     = "L1"
+	IndexChainPChain = "P-chain"
+)
+
+var (
+	indexMetaBucket    = []byte("meta")
+	indexEntriesBucket = []byte("entries")
+	indexNodesBucket   = []byte("nodes")
+
+	indexChainIDKey    = []byte("chainID")
+	indexManagerKey    = []byte("manager")
+	indexNextBlockKey  = []byte("nextBlock")
+	indexNextHeightKey = []byte("nextHeight")
+)
+
+// ValidatorIndex keeps the history of the validations of a validator manager
+// in a bbolt database: the events of the manager, the warp messages it sent
+// and the P-chain txs about its validations. It is filled incrementally by
+// SyncValidatorIndex and can be queried while the L1 is down.
+type ValidatorIndex struct {
+	// StartHeight is the P-chain height a new index starts from, zero starts
+	// at the first block after the genesis of the L1
+	StartHeight uint64
+
+	db *bolt.DB
+}
+
+// IndexEntry is one step in the history of a validation: an event of the
+// manager, a warp message it sent or a P-chain tx
+type IndexEntry struct {
+	ValidationID ids.ID `json:"validationID"`
+	// NodeID is only set on the entries that name the node
+	NodeID string `json:"nodeID,omitempty"`
+	Chain  string `json:"chain"`
+	// Kind is the event name, the warp message type or the P-chain tx type
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+	// Height is the L1 block or the P-chain height
+	Height uint64 `json:"height"`
+	// TxID is the hash of the L1 tx or the ID of the P-chain tx
+	TxID string `json:"txID"`
+	// Index is the position of the log in its L1 block or of the tx in its
+	// P-chain block
+	Index  uint           `json:"index"`
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// key orders the entries of a validation by time. L1 entries come before
+// P-chain ones of the same second, the messages they carry were sent first.
+func (e IndexEntry) key() []byte {
+	chain := byte(0)
+	if e.Chain == IndexChainPChain {
+		chain = 1
+	}
+	key := make([]byte, 0, ids.IDLen+8+1+8+4)
+	key = append(key, e.ValidationID[:]...)
+	key = binary.BigEndian.AppendUint64(key, uint64(e.Time.Unix()))
+	key = append(key, chain)
+	key = binary.BigEndian.AppendUint64(key, e.Height)
+	return binary.BigEndian.AppendUint32(key, uint32(e.Index))
+}
+
+// IndexStatus is how far the index got and what it holds
+type IndexStatus struct {
+	// NextBlock and NextHeight are the first L1 block and P-chain height not
+	// indexed yet
+	NextBlock   uint64 `json:"nextBlock"`
+	NextHeight  uint64 `json:"nextHeight"`
+	Validations int    `json:"validations"`
+	Entries     int    `json:"entries"`
+}
+
+// OpenValidatorIndex opens the database at path, creating it if needed. Only
+// one process can have it open.
+func OpenValidatorIndex(path string) (*ValidatorIndex, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("failed to open validator index %s, another command has it open: %w", path, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open validator index %s: %w", path, err)
+	}
+	if err := db.Update(createIndexBuckets); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize validator index %s: %w", path, err)
+	}
+	return &ValidatorIndex{db: db}, nil
+}
+
+func createIndexBuckets(tx *bolt.Tx) error {
+	for _, name := range [][]byte{indexMetaBucket, indexEntriesBucket, indexNodesBucket} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases the database
+func (i *ValidatorIndex) Close() error {
+	return i.db.Close()
+}
+
+// reset drops the content if it was built for another manager, e.g. when the
+// data folder is reused for a new L1
+func (i *ValidatorIndex) reset(chainID ids.ID, manager common.Address) error {
+	return i.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(indexMetaBucket)
+		if bytes.Equal(meta.Get(indexChainIDKey), chainID[:]) && bytes.Equal(meta.Get(indexManagerKey), manager[:]) {
+			return nil
+		}
+		for _, name := range [][]byte{indexMetaBucket, indexEntriesBucket, indexNodesBucket} {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		if err := createIndexBuckets(tx); err != nil {
+			return err
+		}
+		meta = tx.Bucket(indexMetaBucket)
+		if err := meta.Put(indexChainIDKey, chainID[:]); err != nil {
+			return err
+		}
+		return meta.Put(indexManagerKey, manager[:])
+	})
+}
+
+// cursor is the value of a cursor of the meta bucket, found is false before
+// the first sync of its chain
+func (i *ValidatorIndex) cursor(key []byte) (uint64, bool, error) {
+	var (
+		next  uint64
+		found bool
+	)
+	err := i.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(indexMetaBucket).Get(key)
+		if value == nil {
+			return nil
+		}
+		if len(value) != 8 {
+			return fmt.Errorf("corrupt %s cursor in validator index", key)
+		}
+		next, found = binary.BigEndian.Uint64(value), true
+		return nil
+	})
+	return next, found, err
+}
+
+// add records the entries and moves the cursor in one transaction, so an
+// interrupted sync leaves the index as it was before the range
+func (i *ValidatorIndex) add(entries []IndexEntry, cursorKey []byte, next uint64) error {
+	return i.db.Update(func(tx *bolt.Tx) error {
+		entriesBucket := tx.Bucket(indexEntriesBucket)
+		nodes := tx.Bucket(indexNodesBucket)
+		for _, entry := range entries {
+			value, err := json.Marshal(entry)
+			if err != nil {
+				return fmt.Errorf("failed to marshal index entry: %w", err)
+			}
+			if err := entriesBucket.Put(entry.key(), value); err != nil {
+				return err
+			}
+			if entry.NodeID == "" {
+				continue
+			}
+			nodeID, err := ids.NodeIDFromString(entry.NodeID)
+			if err != nil {
+				return err
+			}
+			if err := nodes.Put(append(nodeID.Bytes(), entry.ValidationID[:]...), []byte{}); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(indexMetaBucket).Put(cursorKey, binary.BigEndian.AppendUint64(nil, next))
+	})
+}
+
+// parseIndexEntry keeps numbers of the fields as json.Number, weights and
+// balances do not fit a float64
+func parseIndexEntry(value []byte) (IndexEntry, error) {
+	var entry IndexEntry
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&entry); err != nil {
+		return IndexEntry{}, fmt.Errorf("failed to parse index entry: %w", err)
+	}
+	return entry, nil
+}
+
+// HasValidation reports whether the index has entries of the validation
+func (i *ValidatorIndex) HasValidation(validationID ids.ID) (bool, error) {
+	found := false
+	err := i.db.View(func(tx *bolt.Tx) error {
+		key, _ := tx.Bucket(indexEntriesBucket).Cursor().Seek(validationID[:])
+		found = bytes.HasPrefix(key, validationID[:])
+		return nil
+	})
+	return found, err
+}
+
+// History is the entries of the validation in time order
+func (i *ValidatorIndex) History(validationID ids.ID) ([]IndexEntry, error) {
+	var entries []IndexEntry
+	err := i.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(indexEntriesBucket).Cursor()
+		for key, value := cursor.Seek(validationID[:]); bytes.HasPrefix(key, validationID[:]); key, value = cursor.Next() {
+			entry, err := parseIndexEntry(value)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// NodeValidations is the validations the index has of the node
+func (i *ValidatorIndex) NodeValidations(nodeID ids.NodeID) ([]ids.ID, error) {
+	var validationIDs []ids.ID
+	err := i.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(indexNodesBucket).Cursor()
+		for key, _ := cursor.Seek(nodeID[:]); bytes.HasPrefix(key, nodeID[:]); key, _ = cursor.Next() {
+			validationID, err := ids.ToID(key[ids.NodeIDLen:])
+			if err != nil {
+				return err
+			}
+			validationIDs = append(validationIDs, validationID)
+		}
+		return nil
+	})
+	return validationIDs, err
+}
+
+// ValidationNodes is the node of every validation the index knows the node of
+func (i *ValidatorIndex) ValidationNodes() (map[ids.ID]ids.NodeID, error) {
+	nodes := make(map[ids.ID]ids.NodeID)
+	err := i.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(indexNodesBucket).ForEach(func(key, _ []byte) error {
+			nodeID, err := ids.ToNodeID(key[:ids.NodeIDLen])
+			if err != nil {
+				return err
+			}
+			validationID, err := ids.ToID(key[ids.NodeIDLen:])
+			if err != nil {
+				return err
+			}
+			nodes[validationID] = nodeID
+			return nil
+		})
+	})
+	return nodes, err
+}
+
+// Timeline is the entries of every validation from since until until in
+// time order, a zero until has no end
+func (i *ValidatorIndex) Timeline(since time.Time, until time.Time) ([]IndexEntry, error) {
+	var entries []IndexEntry
+	err := i.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(indexEntriesBucket).ForEach(func(key, value []byte) error {
+			entry, err := parseIndexEntry(value)
+			if err != nil {
+				return err
+			}
+			if entry.Time.Before(since) || (!until.IsZero() && entry.Time.After(until)) {
+				return nil
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	sort.SliceStable(entries, func(a, b int) bool {
+		return bytes.Compare(entries[a].key()[ids.IDLen:], entries[b].key()[ids.IDLen:]) < 0
+	})
+	return entries, err
+}
+
+// Status is the cursors of the index and how much it holds
+func (i *ValidatorIndex) Status() (IndexStatus, error) {
+	var status IndexStatus
+	var err error
+	if status.NextBlock, _, err = i.cursor(indexNextBlockKey); err != nil {
+		return IndexStatus{}, err
+	}
+	if status.NextHeight, _, err = i.cursor(indexNextHeightKey); err != nil {
+		return IndexStatus{}, err
+	}
+	err = i.db.View(func(tx *bolt.Tx) error {
+		var previous []byte
+		return tx.Bucket(indexEntriesBucket).ForEach(func(key, _ []byte) error {
+			status.Entries++
+			if previous == nil || !bytes.HasPrefix(key, previous) {
+				status.Validations++
+				previous = append([]byte{}, key[:ids.IDLen]...)
+			}
+			return nil
+		})
+	})
+	return status, err
+}
@@ -0,0 +1,391 @@
+package l1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ava-labs/avalanche-cli/pkg/evm"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/block"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	warpPayload "github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/ethclient"
+	"github.com/ava-labs/subnet-evm/interfaces"
+	subnetEvmWarp "github.com/ava-labs/subnet-evm/precompile/contracts/warp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// pChainIndexBatch is the P-chain blocks indexed between two saves
+const pChainIndexBatch = 256
+
+// SyncValidatorIndex adds what happened to the validations of the manager
+// since the last sync to the index: the L1 blocks after its L1 cursor and the
+// P-chain blocks after its P-chain cursor. Progress is saved after every
+// range, so an interrupted sync picks up where it stopped.
+func (m *ValidatorManager) SyncValidatorIndex(ctx context.Context, index *ValidatorIndex) error {
+	if err := index.reset(m.L1.ChainID, m.Address); err != nil {
+		return fmt.Errorf("failed to reset validator index: %w", err)
+	}
+	client, err := evm.GetClient(m.L1.RPCURL())
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	registry, err := DefaultEventRegistry()
+	if err != nil {
+		return err
+	}
+	if err := m.syncIndexL1(ctx, index, client, registry); err != nil {
+		return err
+	}
+	rpcCtx, cancel := m.L1.Workspace.rpcContext(ctx)
+	genesis, err := client.HeaderByNumber(rpcCtx, big.NewInt(0))
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get genesis block: %w", err)
+	}
+	return m.syncIndexPChain(ctx, index, time.Unix(int64(genesis.Time), 0))
+}
+
+// syncIndexL1 indexes the events of the manager and the warp messages it sent
+func (m *ValidatorManager) syncIndexL1(ctx context.Context, index *ValidatorIndex, client ethclient.Client, registry *EventRegistry) error {
+	next, _, err := index.cursor(indexNextBlockKey)
+	if err != nil {
+		return err
+	}
+	rpcCtx, cancel := m.L1.Workspace.rpcContext(ctx)
+	height, err := client.BlockNumber(rpcCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+	for from := next; from <= height; from += warpLogsBlockRange {
+		to := min(from+warpLogsBlockRange-1, height)
+		logs, err := m.managerLogs(ctx, client, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to get manager events of blocks %d to %d: %w", from, to, err)
+		}
+		warpLogs, err := m.warpLogs(ctx, client, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to get warp events of blocks %d to %d: %w", from, to, err)
+		}
+		logs = append(logs, warpLogs...)
+		sort.Slice(logs, func(a, b int) bool {
+			if logs[a].BlockNumber != logs[b].BlockNumber {
+				return logs[a].BlockNumber < logs[b].BlockNumber
+			}
+			return logs[a].Index < logs[b].Index
+		})
+
+		var entries []IndexEntry
+		blockTimes := make(map[uint64]time.Time)
+		for _, txLog := range logs {
+			entry, ok, err := m.l1IndexEntry(registry, txLog)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			if _, ok := blockTimes[txLog.BlockNumber]; !ok {
+				rpcCtx, cancel := m.L1.Workspace.rpcContext(ctx)
+				header, err := client.HeaderByNumber(rpcCtx, new(big.Int).SetUint64(txLog.BlockNumber))
+				cancel()
+				if err != nil {
+					return fmt.Errorf("failed to get block %d: %w", txLog.BlockNumber, err)
+				}
+				blockTimes[txLog.BlockNumber] = time.Unix(int64(header.Time), 0)
+			}
+			entry.Time = blockTimes[txLog.BlockNumber]
+			entries = append(entries, entry)
+		}
+		if err := index.add(entries, indexNextBlockKey, to+1); err != nil {
+			return fmt.Errorf("failed to save validator index: %w", err)
+		}
+		if len(entries) > 0 {
+			m.L1.Workspace.Log.Printf("Indexed %d L1 entries of blocks %d to %d\n", len(entries), from, to)
+		}
+	}
+	return nil
+}
+
+// managerLogs is the events the manager emitted in the blocks from to to,
+// both included
+func (m *ValidatorManager) managerLogs(ctx context.Context, client ethclient.Client, from uint64, to uint64) ([]types.Log, error) {
+	ctx, cancel := m.L1.Workspace.rpcContext(ctx)
+	defer cancel()
+	return client.FilterLogs(ctx, interfaces.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{m.Address},
+	})
+}
+
+// l1IndexEntry is the entry of a manager event about a validation or a warp
+// message the manager sent, ok is false for other logs
+func (m *ValidatorManager) l1IndexEntry(registry *EventRegistry, txLog types.Log) (IndexEntry, bool, error) {
+	entry := IndexEntry{
+		Chain:  IndexChainL1,
+		Height: txLog.BlockNumber,
+		TxID:   txLog.TxHash.Hex(),
+		Index:  txLog.Index,
+		Fields: make(map[string]any),
+	}
+	if txLog.Address == subnetEvmWarp.ContractAddress {
+		message, err := subnetEvmWarp.UnpackSendWarpEventDataToMessage(txLog.Data)
+		if err != nil {
+			return IndexEntry{}, false, nil
+		}
+		addressedCall, err := warpPayload.ParseAddressedCall(message.Payload)
+		if err != nil {
+			return IndexEntry{}, false, nil
+		}
+		entry.Fields["messageID"] = message.ID().String()
+		if registration, err := warpMessage.ParseRegisterL1Validator(addressedCall.Payload); err == nil {
+			nodeID, err := ids.ToNodeID(registration.NodeID)
+			if err != nil {
+				return IndexEntry{}, false, nil
+			}
+			entry.ValidationID = registration.ValidationID()
+			entry.NodeID = nodeID.String()
+			entry.Kind = "RegisterL1ValidatorMessage"
+			entry.Fields["weight"] = registration.Weight
+			entry.Fields["expiry"] = registration.Expiry
+			return entry, true, nil
+		}
+		if update, err := warpMessage.ParseL1ValidatorWeight(addressedCall.Payload); err == nil {
+			entry.ValidationID = update.ValidationID
+			entry.Kind = "L1ValidatorWeightMessage"
+			entry.Fields["nonce"] = update.Nonce
+			entry.Fields["weight"] = update.Weight
+			return entry, true, nil
+		}
+		return IndexEntry{}, false, nil
+	}
+
+	decoded, err := registry.Decode(txLog)
+	if errors.Is(err, ErrUnknownEvent) {
+		return IndexEntry{}, false, nil
+	}
+	if err != nil {
+		return IndexEntry{}, false, err
+	}
+	entry.Kind = decoded.Event
+	found := false
+	for _, field := range decoded.Fields {
+		if validationID, ok := field.Value.([32]byte); ok && field.Name == "validationID" {
+			entry.ValidationID, found = validationID, true
+			continue
+		}
+		entry.Fields[field.Name] = field.JSONValue()
+	}
+	return entry, found, nil
+}
+
+// syncIndexPChain indexes the P-chain txs about the validations of the L1. A
+// new index starts at StartHeight, or at the first block not older than the
+// genesis of the L1, which the conversion can not precede.
+func (m *ValidatorManager) syncIndexPChain(ctx context.Context, index *ValidatorIndex, genesisTime time.Time) error {
+	client := platformvm.NewClient(m.L1.Workspace.Network.URI)
+	tip, err := client.GetHeight(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get P-chain height: %w", err)
+	}
+	next, found, err := index.cursor(indexNextHeightKey)
+	if err != nil {
+		return err
+	}
+	if !found {
+		next = index.StartHeight
+		if next == 0 {
+			next, err = firstPChainHeightAt(ctx, client, genesisTime, tip)
+			if err != nil {
+				return err
+			}
+		}
+		m.L1.Workspace.Log.Printf("Indexing the P-chain from height %d\n", next)
+	}
+
+	// known has the validations of this sync not saved yet, balance and
+	// disable txs only name the validation
+	known := make(map[ids.ID]bool)
+	var entries []IndexEntry
+	for height := next; height <= tip; height++ {
+		blockBytes, err := client.GetBlockByHeight(ctx, height)
+		if err != nil {
+			return fmt.Errorf("failed to get P-chain block %d: %w", height, err)
+		}
+		blk, err := block.Parse(block.Codec, blockBytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse P-chain block %d: %w", height, err)
+		}
+		blockEntries, err := m.pChainIndexEntries(index, blk, known)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, blockEntries...)
+		if height != tip && (height+1-next)%pChainIndexBatch != 0 {
+			continue
+		}
+		if err := index.add(entries, indexNextHeightKey, height+1); err != nil {
+			return fmt.Errorf("failed to save validator index: %w", err)
+		}
+		if len(entries) > 0 {
+			m.L1.Workspace.Log.Printf("Indexed %d P-chain entries up to height %d\n", len(entries), height)
+		}
+		entries = nil
+	}
+	return nil
+}
+
+// pChainIndexEntries is the entries of the txs of the block about
+// validations of the L1
+func (m *ValidatorManager) pChainIndexEntries(index *ValidatorIndex, blk block.Block, known map[ids.ID]bool) ([]IndexEntry, error) {
+	var timestamp time.Time
+	if banff, ok := blk.(interface{ Timestamp() time.Time }); ok {
+		timestamp = banff.Timestamp()
+	}
+	isKnown := func(validationID ids.ID) (bool, error) {
+		if known[validationID] {
+			return true, nil
+		}
+		return index.HasValidation(validationID)
+	}
+
+	var entries []IndexEntry
+	for position, tx := range blk.Txs() {
+		entry := IndexEntry{
+			Chain:  IndexChainPChain,
+			Time:   timestamp,
+			Height: blk.Height(),
+			TxID:   tx.ID().String(),
+			Index:  uint(position),
+			Fields: make(map[string]any),
+		}
+		switch unsigned := tx.Unsigned.(type) {
+		case *txs.ConvertSubnetToL1Tx:
+			if unsigned.Subnet != m.L1.SubnetID {
+				continue
+			}
+			for i, validator := range unsigned.Validators {
+				nodeID, err := ids.ToNodeID(validator.NodeID)
+				if err != nil {
+					return nil, fmt.Errorf("invalid node ID in conversion tx %s: %w", entry.TxID, err)
+				}
+				converted := entry
+				converted.ValidationID = unsigned.Subnet.Append(uint32(i))
+				converted.NodeID = nodeID.String()
+				converted.Kind = "ConvertSubnetToL1Tx"
+				converted.Fields = map[string]any{"weight": validator.Weight, "balance": validator.Balance}
+				known[converted.ValidationID] = true
+				entries = append(entries, converted)
+			}
+			continue
+		case *txs.RegisterL1ValidatorTx:
+			payload, ok := m.pChainWarpPayload(unsigned.Message)
+			if !ok {
+				continue
+			}
+			registration, err := warpMessage.ParseRegisterL1Validator(payload)
+			if err != nil || registration.SubnetID != m.L1.SubnetID {
+				continue
+			}
+			nodeID, err := ids.ToNodeID(registration.NodeID)
+			if err != nil {
+				continue
+			}
+			entry.ValidationID = registration.ValidationID()
+			entry.NodeID = nodeID.String()
+			entry.Kind = "RegisterL1ValidatorTx"
+			entry.Fields["weight"] = registration.Weight
+			entry.Fields["balance"] = unsigned.Balance
+		case *txs.SetL1ValidatorWeightTx:
+			payload, ok := m.pChainWarpPayload(unsigned.Message)
+			if !ok {
+				continue
+			}
+			update, err := warpMessage.ParseL1ValidatorWeight(payload)
+			if err != nil {
+				continue
+			}
+			entry.ValidationID = update.ValidationID
+			entry.Kind = "SetL1ValidatorWeightTx"
+			entry.Fields["nonce"] = update.Nonce
+			entry.Fields["weight"] = update.Weight
+		case *txs.IncreaseL1ValidatorBalanceTx:
+			ok, err := isKnown(unsigned.ValidationID)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			entry.ValidationID = unsigned.ValidationID
+			entry.Kind = "IncreaseL1ValidatorBalanceTx"
+			entry.Fields["balance"] = unsigned.Balance
+		case *txs.DisableL1ValidatorTx:
+			ok, err := isKnown(unsigned.ValidationID)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			entry.ValidationID = unsigned.ValidationID
+			entry.Kind = "DisableL1ValidatorTx"
+		default:
+			continue
+		}
+		known[entry.ValidationID] = true
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// pChainWarpPayload is the payload of a signed warp message the L1 sent, ok
+// is false for messages of other chains
+func (m *ValidatorManager) pChainWarpPayload(messageBytes []byte) ([]byte, bool) {
+	message, err := warp.ParseMessage(messageBytes)
+	if err != nil || message.SourceChainID != m.L1.ChainID {
+		return nil, false
+	}
+	addressedCall, err := warpPayload.ParseAddressedCall(message.Payload)
+	if err != nil {
+		return nil, false
+	}
+	return addressedCall.Payload, true
+}
+
+// firstPChainHeightAt is the first P-chain height up to tip whose block is
+// not older than t. Blocks from before Banff have no timestamp and count as
+// older.
+func firstPChainHeightAt(ctx context.Context, client platformvm.Client, t time.Time, tip uint64) (uint64, error) {
+	low, high := uint64(0), tip
+	for low < high {
+		middle := low + (high-low)/2
+		blockBytes, err := client.GetBlockByHeight(ctx, middle)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get P-chain block %d: %w", middle, err)
+		}
+		blk, err := block.Parse(block.Codec, blockBytes)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse P-chain block %d: %w", middle, err)
+		}
+		banff, ok := blk.(interface{ Timestamp() time.Time })
+		if ok && !banff.Timestamp().Before(t) {
+			high = middle
+		} else {
+			low = middle + 1
+		}
+	}
+	return low, nil
+}
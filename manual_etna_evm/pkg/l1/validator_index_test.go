@@ -0,0 +1,418 @@
+package l1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	avajson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/platformvm/block"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	warpPayload "github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	poavalidatormanager "github.com/ava-labs/icm-contracts/abi-bindings/go/validator-manager/PoAValidatorManager"
+	"github.com/ava-labs/subnet-evm/core/types"
+	subnetEvmWarp "github.com/ava-labs/subnet-evm/precompile/contracts/warp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// fakeIndexChains serves the EVM RPC of an L1 and the P-chain API, enough of
+// them for SyncValidatorIndex
+type fakeIndexChains struct {
+	chainID ids.ID
+	manager common.Address
+
+	mu        sync.Mutex
+	headers   []*types.Header
+	logs      []types.Log
+	pBlocks   [][]byte
+	pRequests int
+}
+
+func (f *fakeIndexChains) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	body, _ := io.ReadAll(r.Body)
+	if err := json.Unmarshal(body, &request); err != nil {
+		// The P-chain client sends the params as an object
+		var pRequest struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(body, &pRequest); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		request.ID, request.Method, request.Params = pRequest.ID, pRequest.Method, []json.RawMessage{pRequest.Params}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var (
+		result any
+		err    error
+	)
+	switch {
+	case r.URL.Path == "/ext/P":
+		result, err = f.pChain(request.Method, request.Params[0])
+	case r.URL.Path == fmt.Sprintf("/ext/bc/%s/rpc", f.chainID):
+		result, err = f.evm(request.Method, request.Params)
+	default:
+		err = fmt.Errorf("unexpected path %s", r.URL.Path)
+	}
+	response := map[string]any{"jsonrpc": "2.0", "id": request.ID}
+	if err != nil {
+		response["error"] = map[string]any{"code": -32000, "message": err.Error()}
+	} else {
+		response["result"] = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func (f *fakeIndexChains) evm(method string, params []json.RawMessage) (any, error) {
+	switch method {
+	case "eth_blockNumber":
+		return hexutil.Uint64(len(f.headers) - 1), nil
+	case "eth_getBlockByNumber":
+		var number hexutil.Uint64
+		if err := json.Unmarshal(params[0], &number); err != nil {
+			return nil, err
+		}
+		if int(number) >= len(f.headers) {
+			return nil, nil
+		}
+		return f.headers[number], nil
+	case "eth_getLogs":
+		var query struct {
+			FromBlock hexutil.Uint64   `json:"fromBlock"`
+			ToBlock   hexutil.Uint64   `json:"toBlock"`
+			Address   []common.Address `json:"address"`
+			Topics    [][]common.Hash  `json:"topics"`
+		}
+		if err := json.Unmarshal(params[0], &query); err != nil {
+			return nil, err
+		}
+		logs := []types.Log{}
+		for _, txLog := range f.logs {
+			if txLog.BlockNumber < uint64(query.FromBlock) || txLog.BlockNumber > uint64(query.ToBlock) || !slices.Contains(query.Address, txLog.Address) {
+				continue
+			}
+			matches := true
+			for i, topics := range query.Topics {
+				if len(topics) > 0 && (i >= len(txLog.Topics) || !slices.Contains(topics, txLog.Topics[i])) {
+					matches = false
+				}
+			}
+			if matches {
+				logs = append(logs, txLog)
+			}
+		}
+		return logs, nil
+	}
+	return nil, fmt.Errorf("unexpected method %s", method)
+}
+
+func (f *fakeIndexChains) pChain(method string, params json.RawMessage) (any, error) {
+	f.pRequests++
+	switch method {
+	case "platform.getHeight":
+		return map[string]any{"height": avajson.Uint64(len(f.pBlocks) - 1)}, nil
+	case "platform.getBlockByHeight":
+		var args struct {
+			Height avajson.Uint64 `json:"height"`
+		}
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, err
+		}
+		if int(args.Height) >= len(f.pBlocks) {
+			return nil, fmt.Errorf("no block at height %d", args.Height)
+		}
+		encoded, err := formatting.Encode(formatting.HexNC, f.pBlocks[args.Height])
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"block": encoded, "encoding": formatting.HexNC}, nil
+	}
+	return nil, fmt.Errorf("unexpected method %s", method)
+}
+
+// addBlock adds an L1 block with the logs
+func (f *fakeIndexChains) addBlock(t *testing.T, timestamp time.Time, logs ...types.Log) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	number := uint64(len(f.headers))
+	f.headers = append(f.headers, &types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		Time:       uint64(timestamp.Unix()),
+		Difficulty: big.NewInt(1),
+		GasLimit:   8_000_000,
+	})
+	for i, txLog := range logs {
+		txLog.BlockNumber = number
+		txLog.Index = uint(i)
+		txLog.TxHash = common.BigToHash(big.NewInt(int64(number*100 + uint64(i))))
+		f.logs = append(f.logs, txLog)
+	}
+}
+
+// addPBlock adds a P-chain block with the txs
+func (f *fakeIndexChains) addPBlock(t *testing.T, timestamp time.Time, unsignedTxs ...txs.UnsignedTx) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	signed := make([]*txs.Tx, 0, len(unsignedTxs))
+	for _, unsigned := range unsignedTxs {
+		tx, err := txs.NewSigned(unsigned, txs.Codec, nil)
+		if err != nil {
+			t.Fatalf("failed to sign %T: %s", unsigned, err)
+		}
+		signed = append(signed, tx)
+	}
+	blk, err := block.NewBanffStandardBlock(timestamp, ids.Empty, uint64(len(f.pBlocks)), signed)
+	if err != nil {
+		t.Fatalf("failed to build P-chain block: %s", err)
+	}
+	f.pBlocks = append(f.pBlocks, blk.Bytes())
+}
+
+func TestSyncValidatorIndex(t *testing.T) {
+	var (
+		subnetID = ids.ID{1, 2, 3}
+		chainID  = ids.ID{9, 8, 7}
+		manager  = common.HexToAddress("0x0Feedc0de0000000000000000000000000000000")
+		genesis  = time.Unix(1_730_000_000, 0).UTC()
+		at       = func(seconds int) time.Time { return genesis.Add(time.Duration(seconds) * time.Second) }
+		baseTx   = txs.BaseTx{BaseTx: avax.BaseTx{NetworkID: constants.FujiID, BlockchainID: constants.PlatformChainID}}
+		owner    = warpMessage.PChainOwner{Threshold: 1, Addresses: []ids.ShortID{{0x11}}}
+		node0    = ids.NodeID{0x10}
+		node1    = ids.NodeID{0x20}
+	)
+	managerABI, err := poavalidatormanager.PoAValidatorManagerMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	managerLog := func(event string, args ...any) types.Log {
+		topics, data, err := managerABI.PackEvent(event, args...)
+		if err != nil {
+			t.Fatalf("failed to pack %s: %s", event, err)
+		}
+		return types.Log{Address: manager, Topics: topics, Data: data}
+	}
+	// warpLog is the SendWarpMessage log of the manager and the signed
+	// message the P-chain tx carries
+	warpLog := func(payload []byte) (types.Log, []byte) {
+		addressedCall, err := warpPayload.NewAddressedCall(manager.Bytes(), payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unsigned, err := warp.NewUnsignedMessage(constants.FujiID, chainID, addressedCall.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		topics, data, err := subnetEvmWarp.PackSendWarpMessageEvent(manager, common.Hash(unsigned.ID()), unsigned.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		signed, err := warp.NewMessage(unsigned, &warp.BitSetSignature{Signers: []byte{1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return types.Log{Address: subnetEvmWarp.ContractAddress, Topics: topics, Data: data}, signed.Bytes()
+	}
+
+	registration, err := warpMessage.NewRegisterL1Validator(subnetID, node1, [48]byte{1}, uint64(at(3600).Unix()), owner, owner, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validation0 := subnetID.Append(0)
+	validation1 := registration.ValidationID()
+	registrationLog, registrationMessage := warpLog(registration.Bytes())
+	removal, err := warpMessage.NewL1ValidatorWeight(validation1, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	removalLog, removalMessage := warpLog(removal.Bytes())
+
+	chains := &fakeIndexChains{chainID: chainID, manager: manager}
+	chains.addBlock(t, genesis)
+	chains.addBlock(t, at(10),
+		managerLog("ValidationPeriodCreated", [32]byte(validation1), node1.Bytes(), [32]byte(registration.ValidationID()), uint64(20), uint64(at(3600).Unix())),
+		registrationLog,
+		// Events of other contracts in the range are left out
+		types.Log{Address: common.HexToAddress("0x01"), Topics: []common.Hash{{1}}},
+	)
+	chains.addBlock(t, at(30), managerLog("ValidationPeriodRegistered", [32]byte(validation1), uint64(20), big.NewInt(at(30).Unix())))
+	chains.addBlock(t, at(100),
+		managerLog("ValidatorRemovalInitialized", [32]byte(validation1), [32]byte(ids.ID{0xee}), uint64(20), big.NewInt(at(100).Unix())),
+		removalLog,
+	)
+
+	// Before the genesis of the L1, skipped when a new index looks for its
+	// first height
+	chains.addPBlock(t, at(-500), &txs.IncreaseL1ValidatorBalanceTx{BaseTx: baseTx, ValidationID: validation0, Balance: 1})
+	chains.addPBlock(t, at(5),
+		&txs.ConvertSubnetToL1Tx{
+			BaseTx:     baseTx,
+			Subnet:     ids.ID{0xff},
+			Validators: []*txs.ConvertSubnetToL1Validator{{NodeID: node1.Bytes(), Weight: 1, Balance: 1, RemainingBalanceOwner: owner, DeactivationOwner: owner}},
+			SubnetAuth: &secp256k1fx.Input{},
+		},
+		&txs.ConvertSubnetToL1Tx{
+			BaseTx:     baseTx,
+			Subnet:     subnetID,
+			ChainID:    chainID,
+			Address:    manager.Bytes(),
+			Validators: []*txs.ConvertSubnetToL1Validator{{NodeID: node0.Bytes(), Weight: 100, Balance: 1000, RemainingBalanceOwner: owner, DeactivationOwner: owner}},
+			SubnetAuth: &secp256k1fx.Input{},
+		},
+	)
+	chains.addPBlock(t, at(20),
+		&txs.RegisterL1ValidatorTx{BaseTx: baseTx, Balance: 500, Message: registrationMessage},
+		// A validation of another L1
+		&txs.IncreaseL1ValidatorBalanceTx{BaseTx: baseTx, ValidationID: ids.ID{0xab}, Balance: 7},
+	)
+	chains.addPBlock(t, at(50), &txs.IncreaseL1ValidatorBalanceTx{BaseTx: baseTx, ValidationID: validation1, Balance: 300})
+	chains.addPBlock(t, at(200),
+		&txs.SetL1ValidatorWeightTx{BaseTx: baseTx, Message: removalMessage},
+		&txs.DisableL1ValidatorTx{BaseTx: baseTx, ValidationID: validation0, DisableAuth: &secp256k1fx.Input{}},
+	)
+
+	server := httptest.NewServer(chains)
+	defer server.Close()
+	workspace := &Workspace{Network: Network{ID: constants.FujiID, URI: server.URL}, Log: log.New(io.Discard, "", 0)}
+	validatorManager := workspace.L1(subnetID, chainID, server.URL).ValidatorManager(manager)
+
+	index, err := OpenValidatorIndex(filepath.Join(t.TempDir(), "validator_index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	if err := validatorManager.SyncValidatorIndex(context.Background(), index); err != nil {
+		t.Fatalf("failed to sync: %s", err)
+	}
+
+	type step struct {
+		chain string
+		kind  string
+		at    time.Time
+	}
+	steps := func(entries []IndexEntry) []step {
+		result := make([]step, 0, len(entries))
+		for _, entry := range entries {
+			result = append(result, step{entry.Chain, entry.Kind, entry.Time})
+		}
+		return result
+	}
+	wantHistory1 := []step{
+		{IndexChainL1, "ValidationPeriodCreated", at(10)},
+		{IndexChainL1, "RegisterL1ValidatorMessage", at(10)},
+		{IndexChainPChain, "RegisterL1ValidatorTx", at(20)},
+		{IndexChainL1, "ValidationPeriodRegistered", at(30)},
+		{IndexChainPChain, "IncreaseL1ValidatorBalanceTx", at(50)},
+		{IndexChainL1, "ValidatorRemovalInitialized", at(100)},
+		{IndexChainL1, "L1ValidatorWeightMessage", at(100)},
+		{IndexChainPChain, "SetL1ValidatorWeightTx", at(200)},
+	}
+	wantHistory0 := []step{
+		{IndexChainPChain, "ConvertSubnetToL1Tx", at(5)},
+		{IndexChainPChain, "DisableL1ValidatorTx", at(200)},
+	}
+
+	history1, err := index.History(validation1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := steps(history1); !slices.Equal(got, wantHistory1) {
+		t.Errorf("history of the registered validation =\n%v\nwant\n%v", got, wantHistory1)
+	}
+	history0, err := index.History(validation0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := steps(history0); !slices.Equal(got, wantHistory0) {
+		t.Errorf("history of the converted validation =\n%v\nwant\n%v", got, wantHistory0)
+	}
+	if balance := history1[4].Fields["balance"]; fmt.Sprint(balance) != "300" {
+		t.Errorf("balance increase = %v, want 300", balance)
+	}
+	if nodeID := history0[0].NodeID; nodeID != node0.String() {
+		t.Errorf("node of the conversion = %s, want %s", nodeID, node0)
+	}
+
+	timeline, err := index.Timeline(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTimeline := []step{wantHistory0[0], wantHistory1[0], wantHistory1[1], wantHistory1[2], wantHistory1[3], wantHistory1[4], wantHistory1[5], wantHistory1[6], wantHistory1[7], wantHistory0[1]}
+	if got := steps(timeline); !slices.Equal(got, wantTimeline) {
+		t.Errorf("timeline =\n%v\nwant\n%v", got, wantTimeline)
+	}
+	window, err := index.Timeline(at(20), at(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := steps(window); !slices.Equal(got, wantTimeline[3:8]) {
+		t.Errorf("timeline from 20s to 100s =\n%v\nwant\n%v", got, wantTimeline[3:8])
+	}
+
+	nodeValidations, err := index.NodeValidations(node1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(nodeValidations, []ids.ID{validation1}) {
+		t.Errorf("validations of %s = %v, want %s", node1, nodeValidations, validation1)
+	}
+	status, err := index.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (IndexStatus{NextBlock: 4, NextHeight: 5, Validations: 2, Entries: 10}); status != want {
+		t.Errorf("status = %+v, want %+v", status, want)
+	}
+
+	// The next sync only reads the new blocks
+	chains.addPBlock(t, at(300), &txs.IncreaseL1ValidatorBalanceTx{BaseTx: baseTx, ValidationID: validation0, Balance: 5})
+	chains.mu.Lock()
+	chains.pRequests = 0
+	chains.mu.Unlock()
+	if err := validatorManager.SyncValidatorIndex(context.Background(), index); err != nil {
+		t.Fatalf("failed to sync again: %s", err)
+	}
+	if chains.pRequests != 2 {
+		t.Errorf("second sync made %d P-chain requests, want the height and the new block", chains.pRequests)
+	}
+	history0, err = index.History(validation0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHistory0 = append(wantHistory0, step{IndexChainPChain, "IncreaseL1ValidatorBalanceTx", at(300)})
+	if got := steps(history0); !slices.Equal(got, wantHistory0) {
+		t.Errorf("history after the second sync =\n%v\nwant\n%v", got, wantHistory0)
+	}
+	if history1, err = index.History(validation1); err != nil || len(history1) != len(wantHistory1) {
+		t.Errorf("second sync changed the history of the registered validation: %d entries, %v", len(history1), err)
+	}
+}
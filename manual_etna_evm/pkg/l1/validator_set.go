@@ -0,0 +1,72 @@
+package l1
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/avalanche-cli/pkg/contract"
+	"github.com/ava-labs/avalanche-cli/sdk/validatormanager"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// InitializeValidatorSet hands the bootstrap validators of the conversion to
+// the contract. validators needs the node IDs and weights of the ConvertToL1
+// call, the P-chain only signs that exact set.
+func (m *ValidatorManager) InitializeValidatorSet(ctx context.Context, validators []Validator) (common.Hash, error) {
+	// The conversion tx has them sorted by node ID
+	validators = sortedByNodeID(validators)
+	signedMessage, err := m.subnetToL1ConversionMessage(ctx, validators)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to sign subnet conversion message: %w", err)
+	}
+
+	type InitialValidatorPayload struct {
+		NodeID       []byte
+		BlsPublicKey []byte
+		Weight       uint64
+	}
+	type SubnetConversionDataPayload struct {
+		SubnetID                     [32]byte
+		ValidatorManagerBlockchainID [32]byte
+		ValidatorManagerAddress      common.Address
+		InitialValidators            []InitialValidatorPayload
+	}
+
+	subnetConversionDataPayload := SubnetConversionDataPayload{
+		SubnetID:                     m.L1.SubnetID,
+		ValidatorManagerBlockchainID: m.L1.ChainID,
+		ValidatorManagerAddress:      m.Address,
+	}
+	for _, validator := range validators {
+		subnetConversionDataPayload.InitialValidators = append(subnetConversionDataPayload.InitialValidators, InitialValidatorPayload{
+			NodeID:       validator.NodeID[:],
+			BlsPublicKey: validator.Signer.PublicKey[:],
+			Weight:       validator.Weight,
+		})
+	}
+
+	unlock := m.L1.Workspace.lockEVMTxs()
+	defer unlock()
+	// The contract helpers take no context, a tx that was sent is waited for
+	if err := ctx.Err(); err != nil {
+		return common.Hash{}, err
+	}
+	tx, _, err := contract.TxToMethodWithWarpMessage(
+		m.L1.RPCURL(),
+		m.L1.Workspace.signerHex(),
+		m.Address,
+		signedMessage,
+		big.NewInt(0),
+		"initialize validator set",
+		validatormanager.ErrorSignatureToError,
+		"initializeValidatorSet((bytes32,bytes32,address,[(bytes,bytes,uint64)]),uint32)",
+		subnetConversionDataPayload,
+		uint32(0),
+	)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to initialize validator set: %w", err)
+	}
+	m.L1.Workspace.Log.Printf("✅ Initialized validator set in tx %s\n", tx.Hash())
+	return tx.Hash(), nil
+}
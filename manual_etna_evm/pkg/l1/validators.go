@@ -0,0 +1,263 @@
+package l1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ava-labs/avalanche-cli/pkg/evm"
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/crypto/bls"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	platformapi "github.com/ava-labs/avalanchego/vms/platformvm/api"
+	poavalidatormanager "github.com/ava-labs/icm-contracts/abi-bindings/go/validator-manager/PoAValidatorManager"
+	"github.com/ava-labs/subnet-evm/accounts/abi/bind"
+)
+
+// ProposedHeight selects the validator set at the proposed height, the one
+// warp signers and P-chain txs are checked against
+const ProposedHeight uint64 = platformapi.ProposedHeight
+
+// ValidationStatus is the ValidatorStatus of a validation in the contract
+type ValidationStatus uint8
+
+const (
+	ValidationUnknown ValidationStatus = iota
+	ValidationPendingAdded
+	ValidationActive
+	ValidationPendingRemoved
+	ValidationCompleted
+	ValidationInvalidated
+)
+
+var validationStatusNames = []string{"unknown", "pending-added", "active", "pending-removed", "completed", "invalidated"}
+
+func (s ValidationStatus) String() string {
+	if int(s) < len(validationStatusNames) {
+		return validationStatusNames[s]
+	}
+	return fmt.Sprintf("status-%d", s)
+}
+
+// ParseValidationStatus is the status with the name String gives it
+func ParseValidationStatus(name string) (ValidationStatus, error) {
+	index := slices.Index(validationStatusNames, name)
+	if index < 0 {
+		return 0, fmt.Errorf("unknown validation status %q, use one of %s", name, strings.Join(validationStatusNames, ", "))
+	}
+	return ValidationStatus(index), nil
+}
+
+// Validation is a validation as the contract keeps it
+type Validation struct {
+	Status         ValidationStatus
+	NodeID         ids.NodeID
+	StartingWeight uint64
+	// MessageNonce is the nonce of the last L1ValidatorWeight message
+	MessageNonce uint64
+	Weight       uint64
+	// StartedAt and EndedAt are unix timestamps, zero until they happen
+	StartedAt uint64
+	EndedAt   uint64
+}
+
+// Validation reads the validation from the contract, its status is
+// ValidationUnknown if the contract never saw it
+func (m *ValidatorManager) Validation(ctx context.Context, validationID ids.ID) (Validation, error) {
+	client, err := evm.GetClient(m.L1.RPCURL())
+	if err != nil {
+		return Validation{}, err
+	}
+	defer client.Close()
+	caller, err := poavalidatormanager.NewPoAValidatorManagerCaller(m.Address, client)
+	if err != nil {
+		return Validation{}, fmt.Errorf("failed to bind validator manager: %w", err)
+	}
+
+	ctx, cancel := m.L1.Workspace.rpcContext(ctx)
+	defer cancel()
+	validator, err := caller.GetValidator(&bind.CallOpts{Context: ctx}, validationID)
+	if err != nil {
+		return Validation{}, fmt.Errorf("failed to get validation %s: %w", validationID, err)
+	}
+	validation := Validation{
+		Status:         ValidationStatus(validator.Status),
+		StartingWeight: validator.StartingWeight,
+		MessageNonce:   validator.MessageNonce,
+		Weight:         validator.Weight,
+		StartedAt:      validator.StartedAt,
+		EndedAt:        validator.EndedAt,
+	}
+	if len(validator.NodeID) > 0 {
+		validation.NodeID, err = ids.ToNodeID(validator.NodeID)
+		if err != nil {
+			return Validation{}, fmt.Errorf("failed to parse node ID of validation %s: %w", validationID, err)
+		}
+	}
+	return validation, nil
+}
+
+// L1Validator is the P-chain state of the validation, ErrValidatorNotFound if
+// the P-chain has none, e.g. after it was removed
+func (l *L1) L1Validator(ctx context.Context, validationID ids.ID) (*platformvm.L1Validator, error) {
+	validator, _, err := platformvm.NewClient(l.Workspace.Network.URI).GetL1Validator(ctx, validationID)
+	if err != nil {
+		if strings.Contains(err.Error(), database.ErrNotFound.Error()) {
+			return nil, fmt.Errorf("L1 validator %s: %w", validationID, ErrValidatorNotFound)
+		}
+		return nil, fmt.Errorf("failed to get L1 validator %s: %w", validationID, err)
+	}
+	return &validator, nil
+}
+
+// ConnectedNodes is the node the L1 is reached through and the peers it is
+// connected to
+func (l *L1) ConnectedNodes(ctx context.Context) (set.Set[ids.NodeID], error) {
+	client := info.NewClient(l.NodeURI)
+	nodeID, _, err := client.GetNodeID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node ID of %s: %w", l.NodeURI, err)
+	}
+	peers, err := client.Peers(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get peers of %s: %w", l.NodeURI, err)
+	}
+	connected := set.Of(nodeID)
+	for _, peer := range peers {
+		connected.Add(peer.ID)
+	}
+	return connected, nil
+}
+
+// ValidatorState is what the P-chain and the contract know about a node
+type ValidatorState struct {
+	NodeID ids.NodeID
+	// ValidationID is empty if neither the contract nor the P-chain has a
+	// validation for the node
+	ValidationID ids.ID
+	// InSet reports whether the node is in the P-chain validator set at the
+	// height, SetWeight and PublicKey are its entry there
+	InSet     bool
+	SetWeight uint64
+	PublicKey *bls.PublicKey
+	// L1Validator is the current P-chain state of the validation, nil if the
+	// P-chain has none
+	L1Validator *platformvm.L1Validator
+	// Validation is the current contract state, nil if the contract has no
+	// validation for the node
+	Validation *Validation
+	// Connected reports whether the node the L1 is reached through is
+	// connected to it
+	Connected bool
+}
+
+// ValidatorStates lists the P-chain validator set of the L1 at the height
+// with what the P-chain and the contract know now about every validation.
+// At ProposedHeight it also lists the validations of the contract's warp
+// index that are not in the set, e.g. registrations the P-chain never saw.
+func (m *ValidatorManager) ValidatorStates(ctx context.Context, height uint64) ([]ValidatorState, error) {
+	pClient := platformvm.NewClient(m.L1.Workspace.Network.URI)
+	validatorSet, err := pClient.GetValidatorsAt(ctx, m.L1.SubnetID, platformapi.Height(height))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get validators of subnet %s: %w", m.L1.SubnetID, err)
+	}
+	connected, err := m.L1.ConnectedNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]ValidatorState, 0, len(validatorSet))
+	known := make(map[ids.ID]bool)
+	for nodeID, output := range validatorSet {
+		state := ValidatorState{
+			NodeID:    nodeID,
+			InSet:     true,
+			SetWeight: output.Weight,
+			PublicKey: output.PublicKey,
+			Connected: connected.Contains(nodeID),
+		}
+		validationID, err := m.ValidationID(ctx, nodeID)
+		if err != nil && !errors.Is(err, ErrValidatorNotFound) {
+			return nil, fmt.Errorf("failed to get validation of %s: %w", nodeID, err)
+		}
+		if err == nil {
+			if err := m.addValidationState(ctx, &state, validationID); err != nil {
+				return nil, err
+			}
+			known[validationID] = true
+		}
+		states = append(states, state)
+	}
+
+	if height == ProposedHeight {
+		if err := m.SyncWarpIndex(ctx); err != nil {
+			return nil, err
+		}
+		for _, validationID := range m.warpIndex().ValidationIDs() {
+			if known[validationID] {
+				continue
+			}
+			var state ValidatorState
+			if err := m.addValidationState(ctx, &state, validationID); err != nil {
+				return nil, err
+			}
+			// Registrations the contract never accepted, e.g. reverted
+			// ones, are not validations of the node
+			if state.Validation == nil && state.L1Validator == nil {
+				continue
+			}
+			state.Connected = connected.Contains(state.NodeID)
+			states = append(states, state)
+		}
+		// Bootstrap validations are not in the warp index, the contract has
+		// them from initializeValidatorSet. Disabled ones are out of the set.
+		for index := uint32(0); ; index++ {
+			validationID := m.L1.SubnetID.Append(index)
+			if known[validationID] {
+				continue
+			}
+			var state ValidatorState
+			if err := m.addValidationState(ctx, &state, validationID); err != nil {
+				return nil, err
+			}
+			if state.Validation == nil {
+				break
+			}
+			state.Connected = connected.Contains(state.NodeID)
+			states = append(states, state)
+		}
+	}
+
+	slices.SortFunc(states, func(a, b ValidatorState) int {
+		return a.NodeID.Compare(b.NodeID)
+	})
+	return states, nil
+}
+
+// addValidationState fills in the contract and P-chain state of the
+// validation
+func (m *ValidatorManager) addValidationState(ctx context.Context, state *ValidatorState, validationID ids.ID) error {
+	state.ValidationID = validationID
+	validation, err := m.Validation(ctx, validationID)
+	if err != nil {
+		return err
+	}
+	if validation.Status != ValidationUnknown {
+		state.Validation = &validation
+		state.NodeID = validation.NodeID
+	}
+	l1Validator, err := m.L1.L1Validator(ctx, validationID)
+	switch {
+	case err == nil:
+		state.L1Validator = l1Validator
+		state.NodeID = l1Validator.NodeID
+	case !errors.Is(err, ErrValidatorNotFound):
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+package l1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/block"
+	"github.com/ava-labs/avalanchego/vms/platformvm/fx"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/ava-labs/avalanchego/wallet/chain/c"
+	"github.com/ava-labs/avalanchego/wallet/chain/p"
+	pbuilder "github.com/ava-labs/avalanchego/wallet/chain/p/builder"
+	psigner "github.com/ava-labs/avalanchego/wallet/chain/p/signer"
+	pwallet "github.com/ava-labs/avalanchego/wallet/chain/p/wallet"
+	"github.com/ava-labs/avalanchego/wallet/chain/x"
+	xbuilder "github.com/ava-labs/avalanchego/wallet/chain/x/builder"
+	xsigner "github.com/ava-labs/avalanchego/wallet/chain/x/signer"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	walletcommon "github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+	"github.com/ava-labs/coreth/plugin/evm"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// DefaultWalletSnapshotMaxAge bounds how old a saved snapshot may be to be
+// used instead of a sync
+const DefaultWalletSnapshotMaxAge = 10 * time.Minute
+
+// maxWalletSnapshotBlocks bounds the P-chain blocks accepted since a snapshot
+// was saved that are checked for spent UTXOs, past it a sync is cheaper
+const maxWalletSnapshotBlocks = 200
+
+// WalletService syncs the UTXOs of a key once and shares them between the
+// wallets it hands out. Txs issued through those wallets update the shared
+// UTXOs, so a flow of several txs pays for a single sync.
+type WalletService struct {
+	URI string
+	Key *secp256k1.PrivateKey
+	Log *log.Logger
+	// SnapshotPath is where the UTXOs spendable on the P-chain are saved
+	// after every accepted tx, empty does not save them
+	SnapshotPath string
+	// SnapshotMaxAge bounds how old a snapshot may be to be loaded. It is
+	// only loaded if no P-chain block accepted since it was saved spent one
+	// of its UTXOs. Zero always syncs.
+	SnapshotMaxAge time.Duration
+
+	mu     sync.Mutex
+	state  *primary.AVAXState
+	owners map[ids.ID]fx.Owner
+}
+
+// walletSnapshot is the UTXOs spendable on the P-chain by source chain, at
+// a P-chain height
+type walletSnapshot struct {
+	URI     string                     `json:"uri"`
+	Address ids.ShortID                `json:"address"`
+	Height  uint64                     `json:"height"`
+	SavedAt time.Time                  `json:"savedAt"`
+	UTXOs   map[ids.ID][]hexutil.Bytes `json:"utxos"`
+}
+
+// NewWalletService syncs the UTXOs of key from the node at uri on first use
+func NewWalletService(uri string, key *secp256k1.PrivateKey, logger *log.Logger) *WalletService {
+	return &WalletService{
+		URI:            uri,
+		Key:            key,
+		Log:            logger,
+		SnapshotMaxAge: DefaultWalletSnapshotMaxAge,
+		owners:         make(map[ids.ID]fx.Owner),
+	}
+}
+
+// Wallet is a wallet over the shared UTXOs, txs that need the subnet owner
+// signature need the subnet in subnetIDs. Owners recorded with SetOwner are
+// passed the same way.
+func (s *WalletService) Wallet(ctx context.Context, subnetIDs ...ids.ID) (primary.Wallet, error) {
+	state, err := s.sync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize wallet: %w", err)
+	}
+	owners, err := s.subnetOwners(ctx, state.PClient, subnetIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subnet owners: %w", err)
+	}
+
+	kc := secp256k1fx.NewKeychain(s.Key)
+	avaxAddrs := kc.Addresses()
+	ethAddrs := kc.EthAddresses()
+	ethState, err := primary.FetchEthState(ctx, s.URI, ethAddrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch C-chain state: %w", err)
+	}
+
+	// Same as primary.MakeWallet, with the UTXOs of the service
+	pBackend := pwallet.NewBackend(state.PCTX, walletcommon.NewChainUTXOs(constants.PlatformChainID, state.UTXOs), owners)
+	xBackend := x.NewBackend(state.XCTX, walletcommon.NewChainUTXOs(state.XCTX.BlockchainID, state.UTXOs))
+	cBackend := c.NewBackend(walletcommon.NewChainUTXOs(state.CCTX.BlockchainID, state.UTXOs), ethState.Accounts)
+	return primary.NewWallet(
+		pwallet.New(p.NewClient(state.PClient, pBackend), pbuilder.New(avaxAddrs, state.PCTX, pBackend), psigner.New(kc, pBackend)),
+		x.NewWallet(xbuilder.New(avaxAddrs, state.XCTX, xBackend), xsigner.New(kc, xBackend), state.XClient, xBackend),
+		c.NewWallet(c.NewBuilder(avaxAddrs, ethAddrs, state.CCTX, cBackend), c.NewSigner(kc, kc, cBackend), state.CClient, ethState.Client, cBackend),
+	), nil
+}
+
+// Invalidate drops the UTXOs and the snapshot, the next wallet syncs again.
+// Call it when a tx failed, the UTXOs it consumed may still be unspent.
+func (s *WalletService) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = nil
+	if s.SnapshotPath != "" {
+		if err := os.Remove(s.SnapshotPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.Log.Printf("⚠️ Failed to remove wallet snapshot: %s\n", err)
+		}
+	}
+}
+
+// Save writes the snapshot, call it once the txs issued so far are accepted
+func (s *WalletService) Save(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.SnapshotPath == "" || s.state == nil {
+		return nil
+	}
+	height, err := s.state.PClient.GetHeight(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get P-chain height: %w", err)
+	}
+	snapshot := walletSnapshot{
+		URI:     s.URI,
+		Address: s.Key.Address(),
+		Height:  height,
+		SavedAt: time.Now(),
+		UTXOs:   make(map[ids.ID][]hexutil.Bytes),
+	}
+	for _, sourceChainID := range s.sourceChains(s.state) {
+		utxos, err := s.state.UTXOs.UTXOs(ctx, sourceChainID, constants.PlatformChainID)
+		if err != nil {
+			return err
+		}
+		for _, utxo := range utxos {
+			utxoBytes, err := txs.Codec.Marshal(txs.CodecVersion, utxo)
+			if err != nil {
+				return fmt.Errorf("failed to marshal UTXO %s: %w", utxo.InputID(), err)
+			}
+			snapshot.UTXOs[sourceChainID] = append(snapshot.UTXOs[sourceChainID], utxoBytes)
+		}
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal wallet snapshot: %w", err)
+	}
+	return writeFileAtomic(s.SnapshotPath, data)
+}
+
+// sync fetches the UTXOs unless they are synced already or a fresh snapshot
+// has them
+func (s *WalletService) sync(ctx context.Context) (*primary.AVAXState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state != nil {
+		return s.state, nil
+	}
+
+	start := time.Now()
+	state, err := s.loadSnapshot(ctx)
+	if err != nil {
+		s.Log.Printf("⚠️ Ignoring wallet snapshot: %s\n", err)
+	}
+	if state != nil {
+		s.Log.Printf("Loaded wallet snapshot in %s\n", time.Since(start))
+		s.state = state
+		return state, nil
+	}
+
+	kc := secp256k1fx.NewKeychain(s.Key)
+	state, err = primary.FetchState(ctx, s.URI, kc.Addresses())
+	if err != nil {
+		return nil, err
+	}
+	s.Log.Printf("Synced wallet in %s\n", time.Since(start))
+	s.state = state
+	return state, nil
+}
+
+// loadSnapshot is the state with the UTXOs of the snapshot, nil if there is
+// none or it is stale. It only has the UTXOs spendable on the P-chain, the
+// flows of the workspace spend nothing else.
+func (s *WalletService) loadSnapshot(ctx context.Context) (*primary.AVAXState, error) {
+	if s.SnapshotPath == "" || s.SnapshotMaxAge <= 0 {
+		return nil, nil
+	}
+	data, err := os.ReadFile(s.SnapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshot walletSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.SnapshotPath, err)
+	}
+	if snapshot.URI != s.URI || snapshot.Address != s.Key.Address() {
+		return nil, nil
+	}
+	if time.Since(snapshot.SavedAt) > s.SnapshotMaxAge {
+		return nil, nil
+	}
+
+	infoClient := info.NewClient(s.URI)
+	pClient := platformvm.NewClient(s.URI)
+	xClient := avm.NewClient(s.URI, "X")
+	utxos := make(map[ids.ID][]*avax.UTXO)
+	utxoIDs := set.NewSet[ids.ID](0)
+	for sourceChainID, utxosBytes := range snapshot.UTXOs {
+		for _, utxoBytes := range utxosBytes {
+			utxo := &avax.UTXO{}
+			if _, err := txs.Codec.Unmarshal(utxoBytes, utxo); err != nil {
+				return nil, fmt.Errorf("failed to parse UTXO: %w", err)
+			}
+			utxos[sourceChainID] = append(utxos[sourceChainID], utxo)
+			utxoIDs.Add(utxo.InputID())
+		}
+	}
+	spent, err := spentSince(ctx, pClient, snapshot.Height, utxoIDs)
+	if err != nil || spent {
+		return nil, err
+	}
+
+	pCTX, err := p.NewContextFromClients(ctx, infoClient, xClient, pClient)
+	if err != nil {
+		return nil, err
+	}
+	xCTX, err := x.NewContextFromClients(ctx, infoClient, xClient)
+	if err != nil {
+		return nil, err
+	}
+	cCTX, err := c.NewContextFromClients(ctx, infoClient, xClient)
+	if err != nil {
+		return nil, err
+	}
+	state := &primary.AVAXState{
+		PClient: pClient,
+		PCTX:    pCTX,
+		XClient: xClient,
+		XCTX:    xCTX,
+		CClient: evm.NewCChainClient(s.URI),
+		CCTX:    cCTX,
+		UTXOs:   walletcommon.NewUTXOs(),
+	}
+	for _, sourceChainID := range s.sourceChains(state) {
+		for _, utxo := range utxos[sourceChainID] {
+			if err := state.UTXOs.AddUTXO(ctx, sourceChainID, constants.PlatformChainID, utxo); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return state, nil
+}
+
+// spentSince reports whether a P-chain block accepted after height consumed
+// one of the UTXOs, or there are too many of them to check
+func spentSince(ctx context.Context, pClient platformvm.Client, height uint64, utxoIDs set.Set[ids.ID]) (bool, error) {
+	current, err := pClient.GetHeight(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get P-chain height: %w", err)
+	}
+	// A lower height is another chain, e.g. a restarted local network
+	if current < height || current-height > maxWalletSnapshotBlocks {
+		return true, nil
+	}
+	for next := height + 1; next <= current; next++ {
+		blockBytes, err := pClient.GetBlockByHeight(ctx, next)
+		if err != nil {
+			return false, fmt.Errorf("failed to get P-chain block %d: %w", next, err)
+		}
+		blk, err := block.Parse(block.Codec, blockBytes)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse P-chain block %d: %w", next, err)
+		}
+		for _, tx := range blk.Txs() {
+			if utxoIDs.Overlaps(tx.Unsigned.InputIDs()) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// sourceChains is the chains UTXOs spendable on the P-chain come from
+func (s *WalletService) sourceChains(state *primary.AVAXState) []ids.ID {
+	return []ids.ID{constants.PlatformChainID, state.XCTX.BlockchainID, state.CCTX.BlockchainID}
+}
+
+// SetOwner records the owner of an ID the P-chain API has no owner lookup
+// for, e.g. the deactivation owner of an L1 validator
+func (s *WalletService) SetOwner(ownerID ids.ID, owner fx.Owner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.owners[ownerID] = owner
+}
+
+// subnetOwners is the owners of the subnets, fetched once per subnet
+func (s *WalletService) subnetOwners(ctx context.Context, pClient platformvm.Client, subnetIDs []ids.ID) (map[ids.ID]fx.Owner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var missing []ids.ID
+	for _, subnetID := range subnetIDs {
+		if _, ok := s.owners[subnetID]; !ok {
+			missing = append(missing, subnetID)
+		}
+	}
+	fetched, err := platformvm.GetSubnetOwners(pClient, ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+	for subnetID, owner := range fetched {
+		s.owners[subnetID] = owner
+	}
+	// The backend records owners of subnets created through it, each wallet
+	// gets its own copy
+	owners := make(map[ids.ID]fx.Owner, len(subnetIDs))
+	for _, subnetID := range subnetIDs {
+		owners[subnetID] = s.owners[subnetID]
+	}
+	return owners, nil
+}
+
+// writeFileAtomic writes through a temporary file, so an interrupted run
+// leaves the previous content in place
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package l1
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+)
+
+func TestSpentSince(t *testing.T) {
+	spentUTXO := avax.UTXOID{TxID: ids.ID{1}, OutputIndex: 2}
+	unspentUTXO := avax.UTXOID{TxID: ids.ID{3}, OutputIndex: 0}
+	baseTx := func(inputs ...avax.UTXOID) txs.BaseTx {
+		tx := txs.BaseTx{BaseTx: avax.BaseTx{NetworkID: constants.FujiID, BlockchainID: constants.PlatformChainID}}
+		for _, input := range inputs {
+			tx.Ins = append(tx.Ins, &avax.TransferableInput{
+				UTXOID: input,
+				Asset:  avax.Asset{ID: ids.ID{0xaa}},
+				In:     &secp256k1fx.TransferInput{Amt: 1},
+			})
+		}
+		return tx
+	}
+
+	// The P-chain is at height 3, the block at height 2 spent spentUTXO
+	chains := &fakeIndexChains{}
+	now := time.Now()
+	chains.addPBlock(t, now)
+	chains.addPBlock(t, now, &txs.IncreaseL1ValidatorBalanceTx{BaseTx: baseTx(), ValidationID: ids.ID{0xab}, Balance: 1})
+	chains.addPBlock(t, now, &txs.IncreaseL1ValidatorBalanceTx{BaseTx: baseTx(spentUTXO), ValidationID: ids.ID{0xab}, Balance: 1})
+	chains.addPBlock(t, now)
+	server := httptest.NewServer(chains)
+	defer server.Close()
+	pClient := platformvm.NewClient(server.URL)
+
+	tests := []struct {
+		name   string
+		height uint64
+		utxos  []avax.UTXOID
+		want   bool
+	}{
+		{name: "saved at the current height", height: 3, utxos: []avax.UTXOID{spentUTXO}},
+		{name: "spent after the save", height: 1, utxos: []avax.UTXOID{unspentUTXO, spentUTXO}, want: true},
+		{name: "spent before the save", height: 2, utxos: []avax.UTXOID{spentUTXO}},
+		{name: "blocks without its UTXOs", height: 0, utxos: []avax.UTXOID{unspentUTXO}},
+		{name: "saved on another chain", height: 5, utxos: []avax.UTXOID{unspentUTXO}, want: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			utxoIDs := set.NewSet[ids.ID](len(test.utxos))
+			for _, utxo := range test.utxos {
+				utxoIDs.Add(utxo.InputID())
+			}
+			got, err := spentSince(context.Background(), pClient, test.height, utxoIDs)
+			if err != nil {
+				t.Fatalf("failed to check the blocks: %s", err)
+			}
+			if got != test.want {
+				t.Errorf("spent = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
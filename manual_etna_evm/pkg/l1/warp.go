@@ -0,0 +1,280 @@
+package l1
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/avalanche-cli/pkg/evm"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/proto/pb/platformvm"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	warpPayload "github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/ethclient"
+	"github.com/ava-labs/subnet-evm/interfaces"
+	subnetEvmWarp "github.com/ava-labs/subnet-evm/precompile/contracts/warp"
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/protobuf/proto"
+)
+
+// Messages from the validator manager are sent by the contract on the L1
+// chain, messages about the validator set come from the P-chain.
+
+// subnetToL1ConversionMessage is the P-chain attestation of the conversion,
+// the validator manager checks it against its initial validators
+func (m *ValidatorManager) subnetToL1ConversionMessage(ctx context.Context, validators []Validator) (*warp.Message, error) {
+	conversionValidators := make([]warpMessage.SubnetToL1ConverstionValidatorData, 0, len(validators))
+	for _, validator := range validators {
+		conversionValidators = append(conversionValidators, warpMessage.SubnetToL1ConverstionValidatorData{
+			NodeID:       validator.NodeID[:],
+			BLSPublicKey: validator.Signer.PublicKey,
+			Weight:       validator.Weight,
+		})
+	}
+	conversionID, err := warpMessage.SubnetToL1ConversionID(warpMessage.SubnetToL1ConversionData{
+		SubnetID:       m.L1.SubnetID,
+		ManagerChainID: m.L1.ChainID,
+		ManagerAddress: m.Address.Bytes(),
+		Validators:     conversionValidators,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subnet conversion ID: %w", err)
+	}
+	addressedCallPayload, err := warpMessage.NewSubnetToL1Conversion(conversionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create addressed call payload: %w", err)
+	}
+	addressedCall, err := warpPayload.NewAddressedCall(nil, addressedCallPayload.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create addressed call: %w", err)
+	}
+	unsignedMessage, err := warp.NewUnsignedMessage(
+		m.L1.Workspace.Network.ID,
+		constants.PlatformChainID,
+		addressedCall.Bytes(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create unsigned message: %w", err)
+	}
+	return m.L1.signWarp(ctx, unsignedMessage, m.L1.SubnetID[:])
+}
+
+// registerL1ValidatorMessage is the message the contract emitted when it
+// initialized the registration, signed by the L1 validators
+func (m *ValidatorManager) registerL1ValidatorMessage(
+	ctx context.Context,
+	validator Validator,
+	expiry uint64,
+	balanceOwners warpMessage.PChainOwner,
+	disableOwners warpMessage.PChainOwner,
+) (*warp.Message, ids.ID, error) {
+	addressedCallPayload, err := warpMessage.NewRegisterL1Validator(
+		m.L1.SubnetID,
+		validator.NodeID,
+		validator.Signer.PublicKey,
+		expiry,
+		balanceOwners,
+		disableOwners,
+		validator.Weight,
+	)
+	if err != nil {
+		return nil, ids.Empty, err
+	}
+	validationID := addressedCallPayload.ValidationID()
+	signedMessage, err := m.contractMessage(ctx, addressedCallPayload.Bytes())
+	return signedMessage, validationID, err
+}
+
+// l1ValidatorWeightMessage is the message the contract emitted to change the
+// weight of a validation, signed by the L1 validators
+func (m *ValidatorManager) l1ValidatorWeightMessage(ctx context.Context, validationID ids.ID, nonce uint64, weight uint64) (*warp.Message, error) {
+	addressedCallPayload, err := warpMessage.NewL1ValidatorWeight(validationID, nonce, weight)
+	if err != nil {
+		return nil, err
+	}
+	return m.contractMessage(ctx, addressedCallPayload.Bytes())
+}
+
+// contractMessage signs a payload sent by the validator manager contract
+func (m *ValidatorManager) contractMessage(ctx context.Context, payload []byte) (*warp.Message, error) {
+	addressedCall, err := warpPayload.NewAddressedCall(m.Address.Bytes(), payload)
+	if err != nil {
+		return nil, err
+	}
+	unsignedMessage, err := warp.NewUnsignedMessage(
+		m.L1.Workspace.Network.ID,
+		m.L1.ChainID,
+		addressedCall.Bytes(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m.L1.signWarp(ctx, unsignedMessage, nil)
+}
+
+// l1ValidatorRegistrationMessage is the P-chain attestation that the
+// validation is registered, or that it ended or never will be
+func (m *ValidatorManager) l1ValidatorRegistrationMessage(ctx context.Context, validationID ids.ID, registered bool) (*warp.Message, error) {
+	addressedCallPayload, err := warpMessage.NewL1ValidatorRegistration(validationID, registered)
+	if err != nil {
+		return nil, err
+	}
+	addressedCall, err := warpPayload.NewAddressedCall(nil, addressedCallPayload.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	unsignedMessage, err := warp.NewUnsignedMessage(
+		m.L1.Workspace.Network.ID,
+		constants.PlatformChainID,
+		addressedCall.Bytes(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var justification []byte
+	if !registered {
+		justification, err = m.registrationJustification(ctx, validationID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m.L1.signWarp(ctx, unsignedMessage, justification)
+}
+
+// registrationJustification proves to the P-chain signers how a validation
+// they no longer track was created, either by the conversion or by a
+// RegisterL1Validator message
+func (m *ValidatorManager) registrationJustification(ctx context.Context, validationID ids.ID) ([]byte, error) {
+	const numBootstrapValidatorsToSearch = 100
+	subnetID := m.L1.SubnetID
+	for validationIndex := uint32(0); validationIndex < numBootstrapValidatorsToSearch; validationIndex++ {
+		bootstrapValidationID := subnetID.Append(validationIndex)
+		if bootstrapValidationID == validationID {
+			justification := platformvm.L1ValidatorRegistrationJustification{
+				Preimage: &platformvm.L1ValidatorRegistrationJustification_ConvertSubnetToL1TxData{
+					ConvertSubnetToL1TxData: &platformvm.SubnetIDIndex{
+						SubnetId: subnetID[:],
+						Index:    validationIndex,
+					},
+				},
+			}
+			return proto.Marshal(&justification)
+		}
+	}
+	msg, err := m.RegistrationMessage(ctx, validationID)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := warp.ParseUnsignedMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+	addressedCall, err := warpPayload.ParseAddressedCall(parsed.Payload)
+	if err != nil {
+		return nil, err
+	}
+	justification := platformvm.L1ValidatorRegistrationJustification{
+		Preimage: &platformvm.L1ValidatorRegistrationJustification_RegisterL1ValidatorMessage{
+			RegisterL1ValidatorMessage: addressedCall.Payload,
+		},
+	}
+	return proto.Marshal(&justification)
+}
+
+// warpLogsBlockRange bounds the blocks of one eth_getLogs call, public RPC
+// endpoints reject larger ranges
+const warpLogsBlockRange = 2048
+
+// RegistrationMessage is the unsigned RegisterL1Validator warp message the
+// manager sent for the validation
+func (m *ValidatorManager) RegistrationMessage(ctx context.Context, validationID ids.ID) ([]byte, error) {
+	index := m.warpIndex()
+	if validation, ok := index.Validation(validationID); ok && len(validation.Registration) > 0 {
+		return validation.Registration, nil
+	}
+	if err := m.SyncWarpIndex(ctx); err != nil {
+		return nil, err
+	}
+	if validation, ok := index.Validation(validationID); ok && len(validation.Registration) > 0 {
+		return validation.Registration, nil
+	}
+	return nil, fmt.Errorf("registration of validation %s not found on warp events: %w", validationID, ErrValidatorNotFound)
+}
+
+// SyncWarpIndex adds the warp messages the manager sent since the last sync
+// to its index. Progress is saved after every range of blocks, so an
+// interrupted sync picks up where it stopped.
+func (m *ValidatorManager) SyncWarpIndex(ctx context.Context) error {
+	index := m.warpIndex()
+	index.reset(m.L1.ChainID, m.Address)
+
+	client, err := evm.GetClient(m.L1.RPCURL())
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	rpcCtx, cancel := m.L1.Workspace.rpcContext(ctx)
+	height, err := client.BlockNumber(rpcCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+	for from := index.NextBlock(); from <= height; from += warpLogsBlockRange {
+		to := min(from+warpLogsBlockRange-1, height)
+		logs, err := m.warpLogs(ctx, client, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to get warp events of blocks %d to %d: %w", from, to, err)
+		}
+		registrations := make(map[ids.ID][]byte)
+		weightUpdates := make(map[ids.ID][][]byte)
+		for _, txLog := range logs {
+			msg, err := subnetEvmWarp.UnpackSendWarpEventDataToMessage(txLog.Data)
+			if err != nil {
+				continue
+			}
+			addressedCall, err := warpPayload.ParseAddressedCall(msg.Payload)
+			if err != nil {
+				continue
+			}
+			if reg, err := warpMessage.ParseRegisterL1Validator(addressedCall.Payload); err == nil {
+				registrations[reg.ValidationID()] = msg.Bytes()
+				continue
+			}
+			if update, err := warpMessage.ParseL1ValidatorWeight(addressedCall.Payload); err == nil {
+				weightUpdates[update.ValidationID] = append(weightUpdates[update.ValidationID], msg.Bytes())
+			}
+		}
+		if err := index.add(registrations, weightUpdates, to+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// warpIndex is the index of the manager, kept in memory if none was set
+func (m *ValidatorManager) warpIndex() *WarpIndex {
+	if m.WarpIndex == nil {
+		m.WarpIndex = NewWarpIndex()
+	}
+	return m.WarpIndex
+}
+
+// warpLogs is the warp precompile events the manager emitted in the blocks
+// from to to, both included
+func (m *ValidatorManager) warpLogs(ctx context.Context, client ethclient.Client, from uint64, to uint64) ([]types.Log, error) {
+	ctx, cancel := m.L1.Workspace.rpcContext(ctx)
+	defer cancel()
+	return client.FilterLogs(ctx, interfaces.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{subnetEvmWarp.Module.Address},
+		Topics: [][]common.Hash{
+			{subnetEvmWarp.WarpABI.Events["SendWarpMessage"].ID},
+			{common.BytesToHash(m.Address.Bytes())},
+		},
+	})
+}
@@ -0,0 +1,258 @@
+package l1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanche-cli/sdk/interchain"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto/bls"
+	"github.com/ava-labs/avalanchego/utils/formatting/address"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	platformapi "github.com/ava-labs/avalanchego/vms/platformvm/api"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	warpMessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	warpPayload "github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
+	evmMessages "github.com/ava-labs/subnet-evm/warp/messages"
+)
+
+// Payload types of a decoded warp message
+const (
+	WarpPayloadAddressedCall = "AddressedCall"
+	WarpPayloadHash          = "Hash"
+)
+
+// WarpField is a field of the payload, in the order the payload has them
+type WarpField struct {
+	Name string
+	// Value is a string, a number, a bool or a PChainOwner map
+	Value any
+}
+
+// DecodedWarpMessage is a warp message taken apart down to its ACP-77
+// payload
+type DecodedWarpMessage struct {
+	Unsigned *warp.UnsignedMessage
+	// Signature is nil for unsigned messages
+	Signature *warp.BitSetSignature
+	// Signers is the indices of the signers in the canonical validator set,
+	// SignersErr is set if the bitset does not parse
+	Signers    []int
+	SignersErr error
+
+	PayloadType string
+	// SourceAddress is the sender of an AddressedCall, empty for the P-chain
+	SourceAddress []byte
+	// Hash is the content of a Hash payload
+	Hash ids.ID
+	// MessageType is the ACP-77 type of the AddressedCall payload, empty if
+	// it is none of them
+	MessageType string
+	Fields      []WarpField
+	// ValidationID is the validation the message is about, derived from the
+	// payload of RegisterL1Validator messages. It is empty for conversions.
+	ValidationID ids.ID
+}
+
+// DecodeWarpMessage parses signed and unsigned warp messages. Payloads of an
+// AddressedCall are tried as every ACP-77 message, and as the ValidationUptime
+// message of subnet-evm.
+func DecodeWarpMessage(messageBytes []byte) (*DecodedWarpMessage, error) {
+	decoded := &DecodedWarpMessage{}
+	if signed, err := warp.ParseMessage(messageBytes); err == nil {
+		decoded.Unsigned = &signed.UnsignedMessage
+		signature, ok := signed.Signature.(*warp.BitSetSignature)
+		if !ok {
+			return nil, fmt.Errorf("unsupported signature type %T", signed.Signature)
+		}
+		decoded.Signature = signature
+		bits := set.BitsFromBytes(signature.Signers)
+		if len(bits.Bytes()) != len(signature.Signers) {
+			decoded.SignersErr = warp.ErrInvalidBitSet
+		}
+		for i := 0; i < bits.BitLen(); i++ {
+			if bits.Contains(i) {
+				decoded.Signers = append(decoded.Signers, i)
+			}
+		}
+	} else {
+		unsigned, unsignedErr := warp.ParseUnsignedMessage(messageBytes)
+		if unsignedErr != nil {
+			return nil, fmt.Errorf("neither a signed warp message (%w) nor an unsigned one (%w)", err, unsignedErr)
+		}
+		decoded.Unsigned = unsigned
+	}
+
+	payload, err := warpPayload.Parse(decoded.Unsigned.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse payload: %w", err)
+	}
+	switch payload := payload.(type) {
+	case *warpPayload.Hash:
+		decoded.PayloadType, decoded.Hash = WarpPayloadHash, payload.Hash
+		return decoded, nil
+	case *warpPayload.AddressedCall:
+		decoded.PayloadType, decoded.SourceAddress = WarpPayloadAddressedCall, payload.SourceAddress
+		decoded.decodeAddressedCall(payload.Payload)
+		return decoded, nil
+	}
+	return nil, fmt.Errorf("unknown payload type %T", payload)
+}
+
+// decodeAddressedCall fills the message fields. Uptime messages share the
+// type ID of conversions in another codec, they are told apart by length.
+func (d *DecodedWarpMessage) decodeAddressedCall(payload []byte) {
+	hrp := constants.GetHRP(d.Unsigned.NetworkID)
+	parsed, err := warpMessage.Parse(payload)
+	if err != nil {
+		uptime, err := evmMessages.ParseValidatorUptime(payload)
+		if err != nil {
+			return
+		}
+		d.MessageType, d.ValidationID = "ValidationUptime", uptime.ValidationID
+		d.Fields = []WarpField{
+			{"validationID", uptime.ValidationID.String()},
+			{"totalUptime", (time.Duration(uptime.TotalUptime) * time.Second).String()},
+		}
+		return
+	}
+	switch message := parsed.(type) {
+	case *warpMessage.RegisterL1Validator:
+		d.MessageType, d.ValidationID = "RegisterL1Validator", message.ValidationID()
+		nodeID, err := ids.ToNodeID(message.NodeID)
+		nodeIDString := fmt.Sprintf("%x", []byte(message.NodeID))
+		if err == nil {
+			nodeIDString = nodeID.String()
+		}
+		d.Fields = []WarpField{
+			{"subnetID", message.SubnetID.String()},
+			{"nodeID", nodeIDString},
+			{"blsPublicKey", fmt.Sprintf("%x", message.BLSPublicKey[:])},
+			{"expiry", time.Unix(int64(message.Expiry), 0).UTC().Format(time.RFC3339)},
+			{"remainingBalanceOwner", warpOwner(hrp, message.RemainingBalanceOwner)},
+			{"disableOwner", warpOwner(hrp, message.DisableOwner)},
+			{"weight", message.Weight},
+		}
+	case *warpMessage.L1ValidatorRegistration:
+		d.MessageType, d.ValidationID = "L1ValidatorRegistration", message.ValidationID
+		d.Fields = []WarpField{
+			{"validationID", message.ValidationID.String()},
+			{"registered", message.Registered},
+		}
+	case *warpMessage.L1ValidatorWeight:
+		d.MessageType, d.ValidationID = "L1ValidatorWeight", message.ValidationID
+		d.Fields = []WarpField{
+			{"validationID", message.ValidationID.String()},
+			{"nonce", message.Nonce},
+			{"weight", message.Weight},
+		}
+	case *warpMessage.SubnetToL1Conversion:
+		d.MessageType = "SubnetToL1Conversion"
+		d.Fields = []WarpField{{"conversionID", message.ID.String()}}
+	}
+}
+
+// warpOwner formats an owner of a RegisterL1Validator message with P-chain
+// addresses
+func warpOwner(hrp string, owner warpMessage.PChainOwner) map[string]any {
+	addresses := make([]string, 0, len(owner.Addresses))
+	for _, addr := range owner.Addresses {
+		formatted, err := address.Format("P", hrp, addr[:])
+		if err != nil {
+			formatted = addr.String()
+		}
+		addresses = append(addresses, formatted)
+	}
+	return map[string]any{"threshold": owner.Threshold, "addresses": addresses}
+}
+
+// WarpSignatureStatus is how the signature of a message holds up against a
+// validator set
+type WarpSignatureStatus struct {
+	SubnetID ids.ID `json:"subnetID"`
+	// Height is the P-chain height of the validator set
+	Height       uint64 `json:"height"`
+	SignedWeight uint64 `json:"signedWeight"`
+	TotalWeight  uint64 `json:"totalWeight"`
+	// Valid is set if the signers hold a quorum and the aggregate signature
+	// verifies
+	Valid bool `json:"valid"`
+	// Reason says why the signature is not valid
+	Reason string `json:"reason,omitempty"`
+}
+
+// SigningSubnetID is the subnet whose validators sign the messages of the
+// chain. The P-chain has none, its messages are verified by the validators of
+// the receiving L1.
+func (n Network) SigningSubnetID(ctx context.Context, sourceChainID ids.ID) (ids.ID, error) {
+	if sourceChainID == constants.PlatformChainID {
+		return ids.Empty, errors.New("messages of the P-chain are signed by the validators of the L1 that receives them, pass its subnet")
+	}
+	subnetID, err := platformvm.NewClient(n.URI).ValidatedBy(ctx, sourceChainID)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("failed to get subnet of chain %s: %w", sourceChainID, err)
+	}
+	return subnetID, nil
+}
+
+// VerifyWarpSignature checks the signature like a receiving chain would, with
+// the validator set of the subnet at the current P-chain height and the
+// quorum of the signature aggregator. A message signed before the validator
+// set changed may not verify anymore.
+func (n Network) VerifyWarpSignature(ctx context.Context, decoded *DecodedWarpMessage, subnetID ids.ID) (WarpSignatureStatus, error) {
+	status := WarpSignatureStatus{SubnetID: subnetID}
+	if decoded.Signature == nil {
+		return WarpSignatureStatus{}, errors.New("the message is not signed")
+	}
+	client := platformvm.NewClient(n.URI)
+	height, err := client.GetHeight(ctx)
+	if err != nil {
+		return WarpSignatureStatus{}, fmt.Errorf("failed to get P-chain height: %w", err)
+	}
+	status.Height = height
+	validatorSet, err := client.GetValidatorsAt(ctx, subnetID, platformapi.Height(height))
+	if err != nil {
+		return WarpSignatureStatus{}, fmt.Errorf("failed to get validators of %s: %w", subnetID, err)
+	}
+	validators, totalWeight, err := warp.FlattenValidatorSet(validatorSet)
+	if err != nil {
+		return WarpSignatureStatus{}, err
+	}
+	status.TotalWeight = totalWeight
+
+	invalid := func(reason error) (WarpSignatureStatus, error) {
+		status.Reason = reason.Error()
+		return status, nil
+	}
+	if decoded.Unsigned.NetworkID != n.ID {
+		return invalid(fmt.Errorf("%w: signed for network %d", warp.ErrWrongNetworkID, decoded.Unsigned.NetworkID))
+	}
+	if decoded.SignersErr != nil {
+		return invalid(decoded.SignersErr)
+	}
+	signers, err := warp.FilterValidators(set.BitsFromBytes(decoded.Signature.Signers), validators)
+	if err != nil {
+		return invalid(err)
+	}
+	status.SignedWeight, _ = warp.SumWeight(signers)
+	if err := warp.VerifyWeight(status.SignedWeight, totalWeight, interchain.DefaultQuorumPercentage, 100); err != nil {
+		return invalid(err)
+	}
+	signature, err := bls.SignatureFromBytes(decoded.Signature.Signature[:])
+	if err != nil {
+		return invalid(fmt.Errorf("%w: %w", warp.ErrParseSignature, err))
+	}
+	publicKey, err := warp.AggregatePublicKeys(signers)
+	if err != nil {
+		return invalid(err)
+	}
+	if !bls.Verify(publicKey, signature, decoded.Unsigned.Bytes()) {
+		return invalid(warp.ErrInvalidSignature)
+	}
+	status.Valid = true
+	return status, nil
+}
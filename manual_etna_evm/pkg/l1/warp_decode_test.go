@@ -0,0 +1,209 @@
+package l1
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+)
+
+// Fixtures on Fuji from chain 4yhFExAPK3EASoHibBiAT6szfvNyThhwY6aKz6XFqtf4SwHES
+// or the P-chain, about validation 2mgCdGXe9t1cMAtuh7X23UoLbCLAgK9M1NXVKwYwJVmqKhcfn
+const (
+	// RegisterL1Validator of NodeID-GZjtbjt4KWDNe3yCv9o281LaRjfy4AiDD sent by
+	// 0xdead, signed by validators 0 and 2
+	signedRegisterFixture = "0000000000050908070000000000000000000000000000000000000000000000000000000000000000c600000000000100000002dead000000b6000000000001010203000000000000000000000000000000000000000000000000000000000000000014aabb000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000006774858000000001000000011100000000000000000000000000000000000000000000010000000111000000000000000000000000000000000000000000000000000014000000000000000105c0ffee000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"
+	// L1ValidatorWeight with nonce 3 and weight 40, unsigned
+	weightFixture = "0000000000050000000000000000000000000000000000000000000000000000000000000000000000440000000000010000000000000036000000000003040506000000000000000000000000000000000000000000000000000000000000000000000000030000000000000028"
+	// ValidationUptime of an hour, type ID 0 of the subnet-evm codec
+	uptimeFixture = "00000000000509080700000000000000000000000000000000000000000000000000000000000000003c000000000001000000000000002e00000000000004050600000000000000000000000000000000000000000000000000000000000000000000000e10"
+	// SubnetToL1Conversion, type ID 0 of the ACP-77 codec
+	conversionFixture = "00000000000500000000000000000000000000000000000000000000000000000000000000000000003400000000000100000000000000260000000000000405060000000000000000000000000000000000000000000000000000000000"
+	// L1ValidatorRegistration that registered the validation
+	registrationFixture = "0000000000050000000000000000000000000000000000000000000000000000000000000000000000350000000000010000000000000027000000000002040506000000000000000000000000000000000000000000000000000000000001"
+	// Hash payload on the local network
+	hashFixture = "0000000000010908070000000000000000000000000000000000000000000000000000000000000000260000000000000405060000000000000000000000000000000000000000000000000000000000"
+)
+
+const (
+	fixtureChainID      = "4yhFExAPK3EASoHibBiAT6szfvNyThhwY6aKz6XFqtf4SwHES"
+	fixtureValidationID = "2mgCdGXe9t1cMAtuh7X23UoLbCLAgK9M1NXVKwYwJVmqKhcfn"
+)
+
+func TestDecodeWarpMessage(t *testing.T) {
+	tests := []struct {
+		name          string
+		fixture       string
+		wantSource    string
+		wantNetwork   uint32
+		wantPayload   string
+		wantType      string
+		wantFields    []string
+		wantValidator string
+		wantSigners   []int
+		wantSigned    bool
+	}{
+		{
+			name:        "signed RegisterL1Validator",
+			fixture:     signedRegisterFixture,
+			wantSource:  fixtureChainID,
+			wantNetwork: constants.FujiID,
+			wantPayload: WarpPayloadAddressedCall,
+			wantType:    "RegisterL1Validator",
+			wantFields: []string{
+				"subnetID=SkB7qHwfMsyF2PgrjhMvtFxJKhuR5ZfVoW9VATWRV4P9jV7J",
+				"nodeID=NodeID-GZjtbjt4KWDNe3yCv9o281LaRjfy4AiDD",
+				"blsPublicKey=01" + fmt.Sprintf("%094x", 0),
+				"expiry=2025-01-01T00:00:00Z",
+				"remainingBalanceOwner=map[addresses:[P-fuji1zyqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqyqrn5j] threshold:1]",
+				"disableOwner=map[addresses:[P-fuji1zyqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqyqrn5j] threshold:1]",
+				"weight=20",
+			},
+			wantValidator: "2gbYKMvdZkuXM36Rznxm4PzGbrQt9ecNKn9yeUGnh9iGeh7kwa",
+			wantSigners:   []int{0, 2},
+			wantSigned:    true,
+		},
+		{
+			name:          "L1ValidatorWeight",
+			fixture:       weightFixture,
+			wantSource:    constants.PlatformChainID.String(),
+			wantNetwork:   constants.FujiID,
+			wantPayload:   WarpPayloadAddressedCall,
+			wantType:      "L1ValidatorWeight",
+			wantFields:    []string{"validationID=" + fixtureValidationID, "nonce=3", "weight=40"},
+			wantValidator: fixtureValidationID,
+		},
+		{
+			name:          "ValidationUptime is told apart from a conversion by length",
+			fixture:       uptimeFixture,
+			wantSource:    fixtureChainID,
+			wantNetwork:   constants.FujiID,
+			wantPayload:   WarpPayloadAddressedCall,
+			wantType:      "ValidationUptime",
+			wantFields:    []string{"validationID=" + fixtureValidationID, "totalUptime=1h0m0s"},
+			wantValidator: fixtureValidationID,
+		},
+		{
+			name:        "SubnetToL1Conversion",
+			fixture:     conversionFixture,
+			wantSource:  constants.PlatformChainID.String(),
+			wantNetwork: constants.FujiID,
+			wantPayload: WarpPayloadAddressedCall,
+			wantType:    "SubnetToL1Conversion",
+			wantFields:  []string{"conversionID=" + fixtureValidationID},
+		},
+		{
+			name:          "L1ValidatorRegistration",
+			fixture:       registrationFixture,
+			wantSource:    constants.PlatformChainID.String(),
+			wantNetwork:   constants.FujiID,
+			wantPayload:   WarpPayloadAddressedCall,
+			wantType:      "L1ValidatorRegistration",
+			wantFields:    []string{"validationID=" + fixtureValidationID, "registered=true"},
+			wantValidator: fixtureValidationID,
+		},
+		{
+			name:        "Hash",
+			fixture:     hashFixture,
+			wantSource:  fixtureChainID,
+			wantNetwork: constants.MainnetID,
+			wantPayload: WarpPayloadHash,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded, err := DecodeWarpMessage(mustDecodeHex(t, test.fixture))
+			if err != nil {
+				t.Fatalf("DecodeWarpMessage() failed: %s", err)
+			}
+			if source := decoded.Unsigned.SourceChainID.String(); source != test.wantSource {
+				t.Errorf("source chain = %s, want %s", source, test.wantSource)
+			}
+			if decoded.Unsigned.NetworkID != test.wantNetwork {
+				t.Errorf("network = %d, want %d", decoded.Unsigned.NetworkID, test.wantNetwork)
+			}
+			if decoded.PayloadType != test.wantPayload || decoded.MessageType != test.wantType {
+				t.Errorf("payload %s message %s, want %s %s", decoded.PayloadType, decoded.MessageType, test.wantPayload, test.wantType)
+			}
+			fields := make([]string, 0, len(decoded.Fields))
+			for _, field := range decoded.Fields {
+				fields = append(fields, fmt.Sprintf("%s=%v", field.Name, field.Value))
+			}
+			if !slices.Equal(fields, test.wantFields) {
+				t.Errorf("fields = %q, want %q", fields, test.wantFields)
+			}
+			wantValidator := ids.Empty
+			if test.wantValidator != "" {
+				wantValidator = ids.FromStringOrPanic(test.wantValidator)
+			}
+			if decoded.ValidationID != wantValidator {
+				t.Errorf("validation ID = %s, want %s", decoded.ValidationID, wantValidator)
+			}
+			if (decoded.Signature != nil) != test.wantSigned {
+				t.Errorf("signed = %v, want %v", decoded.Signature != nil, test.wantSigned)
+			}
+			if !slices.Equal(decoded.Signers, test.wantSigners) || decoded.SignersErr != nil {
+				t.Errorf("signers = %v, %v, want %v", decoded.Signers, decoded.SignersErr, test.wantSigners)
+			}
+		})
+	}
+}
+
+func TestDecodeWarpMessageEdgeCases(t *testing.T) {
+	// The bitset of validators 0 and 2 with a leading zero byte
+	paddedSigners := strings.Replace(signedRegisterFixture, "0000000105c0ffee", "000000020005c0ffee", 1)
+	// The conversion payload with codec version 1, which neither codec has
+	unknownCodec := strings.Replace(conversionFixture, "00000026000000000000040506", "00000026000100000000040506", 1)
+
+	t.Run("signers bitset that does not parse", func(t *testing.T) {
+		decoded, err := DecodeWarpMessage(mustDecodeHex(t, paddedSigners))
+		if err != nil {
+			t.Fatalf("DecodeWarpMessage() failed: %s", err)
+		}
+		if !errors.Is(decoded.SignersErr, warp.ErrInvalidBitSet) {
+			t.Errorf("signers error = %v, want ErrInvalidBitSet", decoded.SignersErr)
+		}
+		if decoded.MessageType != "RegisterL1Validator" {
+			t.Errorf("message = %s, want the payload decoded anyway", decoded.MessageType)
+		}
+	})
+
+	t.Run("AddressedCall that is no ACP-77 message", func(t *testing.T) {
+		decoded, err := DecodeWarpMessage(mustDecodeHex(t, unknownCodec))
+		if err != nil {
+			t.Fatalf("DecodeWarpMessage() failed: %s", err)
+		}
+		if decoded.PayloadType != WarpPayloadAddressedCall || decoded.MessageType != "" || len(decoded.Fields) != 0 {
+			t.Errorf("decoded %s %q %v, want an AddressedCall without a message", decoded.PayloadType, decoded.MessageType, decoded.Fields)
+		}
+	})
+
+	for name, fixture := range map[string]string{
+		"empty":           "",
+		"garbage":         "deadbeef",
+		"truncated":       weightFixture[:len(weightFixture)-2],
+		"trailing bytes":  weightFixture + "00",
+		"unknown payload": strings.Replace(hashFixture, "00000000000004050600", "00000000000904050600", 1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if decoded, err := DecodeWarpMessage(mustDecodeHex(t, fixture)); err == nil {
+				t.Errorf("DecodeWarpMessage() = %+v, want an error", decoded)
+			}
+		})
+	}
+}
+
+func mustDecodeHex(t *testing.T, fixture string) []byte {
+	t.Helper()
+	decoded, err := hex.DecodeString(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
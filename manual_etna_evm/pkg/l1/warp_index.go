@@ -0,0 +1,142 @@
+package l1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// WarpIndex keeps the warp messages a validator manager sent, keyed by
+// validation ID, so finding one does not mean scanning the chain again. It is
+// filled incrementally by SyncWarpIndex.
+type WarpIndex struct {
+	// Path is the file the index is kept in between runs, empty keeps it in
+	// memory only
+	Path string
+
+	mu    sync.Mutex
+	state warpIndexState
+}
+
+type warpIndexState struct {
+	ChainID ids.ID         `json:"chainID"`
+	Manager common.Address `json:"manager"`
+	// NextBlock is the first block not scanned yet
+	NextBlock   uint64                        `json:"nextBlock"`
+	Validations map[ids.ID]*IndexedValidation `json:"validations"`
+}
+
+// IndexedValidation is the unsigned warp messages the manager sent about one
+// validation, in the order they were emitted
+type IndexedValidation struct {
+	Registration  hexutil.Bytes   `json:"registration,omitempty"`
+	WeightUpdates []hexutil.Bytes `json:"weightUpdates,omitempty"`
+}
+
+// NewWarpIndex is an empty index kept in memory
+func NewWarpIndex() *WarpIndex {
+	return &WarpIndex{}
+}
+
+// OpenWarpIndex loads the index kept at path, or starts an empty one there
+func OpenWarpIndex(path string) (*WarpIndex, error) {
+	index := &WarpIndex{Path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read warp index: %w", err)
+	}
+	if err := json.Unmarshal(data, &index.state); err != nil {
+		return nil, fmt.Errorf("failed to parse warp index %s: %w", path, err)
+	}
+	return index, nil
+}
+
+// Validation is what the index holds about the validation
+func (i *WarpIndex) Validation(validationID ids.ID) (IndexedValidation, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	validation, ok := i.state.Validations[validationID]
+	if !ok {
+		return IndexedValidation{}, false
+	}
+	return *validation, true
+}
+
+// ValidationIDs is the validations the index holds messages about
+func (i *WarpIndex) ValidationIDs() []ids.ID {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	validationIDs := make([]ids.ID, 0, len(i.state.Validations))
+	for validationID := range i.state.Validations {
+		validationIDs = append(validationIDs, validationID)
+	}
+	return validationIDs
+}
+
+// NextBlock is the first block of the chain the index has not scanned
+func (i *WarpIndex) NextBlock() uint64 {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.state.NextBlock
+}
+
+// reset drops the content if it was built for another manager, e.g. when the
+// data folder is reused for a new L1
+func (i *WarpIndex) reset(chainID ids.ID, manager common.Address) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.state.ChainID == chainID && i.state.Manager == manager {
+		return
+	}
+	i.state = warpIndexState{ChainID: chainID, Manager: manager}
+}
+
+// add records the messages of the blocks up to nextBlock and saves the index
+func (i *WarpIndex) add(registrations map[ids.ID][]byte, weightUpdates map[ids.ID][][]byte, nextBlock uint64) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.state.Validations == nil {
+		i.state.Validations = make(map[ids.ID]*IndexedValidation)
+	}
+	validation := func(validationID ids.ID) *IndexedValidation {
+		if _, ok := i.state.Validations[validationID]; !ok {
+			i.state.Validations[validationID] = &IndexedValidation{}
+		}
+		return i.state.Validations[validationID]
+	}
+	for validationID, message := range registrations {
+		validation(validationID).Registration = message
+	}
+	for validationID, messages := range weightUpdates {
+		entry := validation(validationID)
+		for _, message := range messages {
+			entry.WeightUpdates = append(entry.WeightUpdates, message)
+		}
+	}
+	i.state.NextBlock = nextBlock
+	return i.save()
+}
+
+// save writes the index, an interrupted run leaves the previous one in place
+func (i *WarpIndex) save() error {
+	if i.Path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(i.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal warp index: %w", err)
+	}
+	if err := writeFileAtomic(i.Path, data); err != nil {
+		return fmt.Errorf("failed to save warp index: %w", err)
+	}
+	return nil
+}
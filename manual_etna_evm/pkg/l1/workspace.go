@@ -0,0 +1,254 @@
+// Package l1 creates Avalanche L1s and manages their PoA validators. Every
+// operation takes its network, signer and IDs explicitly, nothing is read
+// from or written to the data folder of the CLI.
+package l1
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+)
+
+// Workspace issues P-chain txs on a network with one signer
+type Workspace struct {
+	Network Network
+	// Signer pays all fees, owns the subnet and the validator manager, and
+	// receives the remaining balance of validators it adds
+	Signer *secp256k1.PrivateKey
+	// Log receives progress messages
+	Log *log.Logger
+	// TxTimeout bounds waiting for a tx and its effect on the validator set,
+	// zero leaves it to the context
+	TxTimeout time.Duration
+	// RPCTimeout bounds single EVM RPC calls, zero leaves it to the context
+	RPCTimeout time.Duration
+	// Wallets hands out the wallets of the signer. Workspaces of the same
+	// network and signer can share it to sync the UTXOs only once.
+	Wallets *WalletService
+
+	// pChainTxMu serializes building and issuing P-chain txs of the signer
+	pChainTxMu sync.Mutex
+	// pChainTxSettled is closed once the last issued P-chain tx is accepted
+	// or failed. The mempool verifies a tx against accepted state, so the
+	// next one is built once the UTXOs the previous one produced are in it.
+	pChainTxSettled chan struct{}
+	// evmTxMu keeps one EVM tx of the signer in flight, so each picks the
+	// next nonce
+	evmTxMu sync.Mutex
+}
+
+// DefaultRPCTimeout bounds single EVM RPC calls of a new workspace
+const DefaultRPCTimeout = 30 * time.Second
+
+// NewWorkspace logs progress to the standard logger
+func NewWorkspace(network Network, signer *secp256k1.PrivateKey) *Workspace {
+	return &Workspace{
+		Network:    network,
+		Signer:     signer,
+		Log:        log.Default(),
+		TxTimeout:  PChainTxTimeout,
+		RPCTimeout: DefaultRPCTimeout,
+		Wallets:    NewWalletService(network.URI, signer, log.Default()),
+	}
+}
+
+// Tracker follows txs on the workspace network
+func (w *Workspace) Tracker() *PChainTxTracker {
+	return NewPChainTxTracker(w.Network.URI, w.Log)
+}
+
+// withTimeout bounds ctx by timeout unless it is zero
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// rpcContext bounds a single EVM RPC call
+func (w *Workspace) rpcContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, w.RPCTimeout)
+}
+
+// awaitTx waits up to TxTimeout for the tx to be committed
+func (w *Workspace) awaitTx(ctx context.Context, txID ids.ID, what string) error {
+	awaitCtx, cancel := withTimeout(ctx, w.TxTimeout)
+	defer cancel()
+	return w.walletSettled(ctx, w.Tracker().AwaitTx(awaitCtx, txID, what))
+}
+
+// awaitL1ValidatorTx waits up to TxTimeout for the tx and for the proposed
+// height to have the node, or not have it if present is false
+func (w *Workspace) awaitL1ValidatorTx(ctx context.Context, txID ids.ID, what string, subnetID ids.ID, nodeID ids.NodeID, present bool) error {
+	awaitCtx, cancel := withTimeout(ctx, w.TxTimeout)
+	defer cancel()
+	return w.walletSettled(ctx, w.Tracker().AwaitL1ValidatorTx(awaitCtx, txID, what, subnetID, nodeID, present))
+}
+
+// walletSettled saves the wallet snapshot once a tx is accepted, and drops
+// the UTXOs if it failed or its outcome is unknown
+func (w *Workspace) walletSettled(ctx context.Context, err error) error {
+	if err != nil {
+		w.wallets().Invalidate()
+		return err
+	}
+	if err := w.wallets().Save(ctx); err != nil {
+		w.Log.Printf("⚠️ Failed to save wallet snapshot: %s\n", err)
+	}
+	return nil
+}
+
+// awaitL1Validator waits up to TxTimeout for the proposed height to have the
+// node, or not have it if present is false
+func (w *Workspace) awaitL1Validator(ctx context.Context, subnetID ids.ID, nodeID ids.NodeID, present bool) error {
+	ctx, cancel := withTimeout(ctx, w.TxTimeout)
+	defer cancel()
+	return w.Tracker().AwaitL1Validator(ctx, subnetID, nodeID, present)
+}
+
+// issuePChainTx builds and issues a tx with a wallet of the signer and waits
+// for it to be committed. Txs that need the subnet owner signature need the
+// subnet in subnetIDs. The tx ID is also returned if waiting failed.
+func (w *Workspace) issuePChainTx(ctx context.Context, what string, issue func(wallet primary.Wallet, options ...common.Option) (*txs.Tx, error), subnetIDs ...ids.ID) (ids.ID, error) {
+	txID, settled, err := w.buildPChainTx(ctx, what, issue, subnetIDs)
+	if err != nil {
+		return ids.Empty, err
+	}
+	defer close(settled)
+	return txID, w.awaitTx(ctx, txID, what)
+}
+
+// buildPChainTx builds and issues a tx once the previous one settled, the
+// returned channel is to be closed once this one did. The lock is released
+// as soon as the tx is issued, so waiting for it holds back no other caller.
+func (w *Workspace) buildPChainTx(ctx context.Context, what string, issue func(wallet primary.Wallet, options ...common.Option) (*txs.Tx, error), subnetIDs []ids.ID) (ids.ID, chan struct{}, error) {
+	w.pChainTxMu.Lock()
+	defer w.pChainTxMu.Unlock()
+
+	if w.pChainTxSettled != nil {
+		select {
+		case <-w.pChainTxSettled:
+		case <-ctx.Done():
+			return ids.Empty, nil, fmt.Errorf("waiting for the previous P-chain tx: %w", ctx.Err())
+		}
+	}
+	wallet, err := w.wallet(ctx, subnetIDs...)
+	if err != nil {
+		return ids.Empty, nil, err
+	}
+	tx, err := issue(wallet, common.WithContext(ctx), common.WithAssumeDecided())
+	if err != nil {
+		w.InvalidateWallet()
+		return ids.Empty, nil, fmt.Errorf("failed to issue %s tx: %w", what, err)
+	}
+	w.pChainTxSettled = make(chan struct{})
+	return tx.ID(), w.pChainTxSettled, nil
+}
+
+// lockEVMTxs holds back other EVM txs of the signer until the returned
+// function is first called
+func (w *Workspace) lockEVMTxs() func() {
+	w.evmTxMu.Lock()
+	var once sync.Once
+	return func() { once.Do(w.evmTxMu.Unlock) }
+}
+
+// AwaitTx waits up to TxTimeout for a tx issued earlier, e.g. by a run that
+// was interrupted while waiting
+func (w *Workspace) AwaitTx(ctx context.Context, txID ids.ID, what string) error {
+	return w.awaitTx(ctx, txID, what)
+}
+
+// AwaitL1ValidatorTx waits up to TxTimeout for a tx issued earlier that adds
+// or removes the node, and for the proposed height to reflect it
+func (w *Workspace) AwaitL1ValidatorTx(ctx context.Context, txID ids.ID, what string, subnetID ids.ID, nodeID ids.NodeID, present bool) error {
+	return w.awaitL1ValidatorTx(ctx, txID, what, subnetID, nodeID, present)
+}
+
+// Wallet is a wallet of the signer, synced once per workspace. Txs that need
+// the subnet owner signature need the subnet in subnetIDs. Txs issued
+// through it should be awaited with AwaitTx, which keeps the UTXOs in line
+// with the outcome.
+func (w *Workspace) Wallet(ctx context.Context, subnetIDs ...ids.ID) (primary.Wallet, error) {
+	return w.wallets().Wallet(ctx, subnetIDs...)
+}
+
+// InvalidateWallet makes the next wallet sync again, e.g. after a tx failed
+// to issue because of UTXOs spent elsewhere
+func (w *Workspace) InvalidateWallet() {
+	w.wallets().Invalidate()
+}
+
+func (w *Workspace) wallet(ctx context.Context, subnetIDs ...ids.ID) (primary.Wallet, error) {
+	return w.Wallet(ctx, subnetIDs...)
+}
+
+// wallets is the wallet service, created on first use for workspaces not
+// made by NewWorkspace
+func (w *Workspace) wallets() *WalletService {
+	if w.Wallets == nil {
+		w.Wallets = NewWalletService(w.Network.URI, w.Signer, w.Log)
+	}
+	return w.Wallets
+}
+
+// signerHex is the signer key in the form the EVM contract helpers take
+func (w *Workspace) signerHex() string {
+	return hex.EncodeToString(w.Signer.Bytes())
+}
+
+// owner is the single signature owner of the signer address
+func (w *Workspace) owner() *secp256k1fx.OutputOwners {
+	return &secp256k1fx.OutputOwners{
+		Locktime:  0,
+		Threshold: 1,
+		Addrs:     []ids.ShortID{w.Signer.Address()},
+	}
+}
+
+// CreateSubnet creates a subnet owned by the signer and returns its ID. The
+// ID is also returned if the tx was issued but waiting for it failed, resume
+// with AwaitTx.
+func (w *Workspace) CreateSubnet(ctx context.Context) (ids.ID, error) {
+	start := time.Now()
+	txID, err := w.issuePChainTx(ctx, "create subnet", func(wallet primary.Wallet, options ...common.Option) (*txs.Tx, error) {
+		return wallet.P().IssueCreateSubnetTx(w.owner(), options...)
+	})
+	if err != nil {
+		return txID, err
+	}
+	w.Log.Printf("✅ Created new subnet %s in %s\n", txID, time.Since(start))
+	return txID, nil
+}
+
+// CreateChain creates a subnet-evm chain with the genesis on the subnet and
+// returns its ID, also if the tx was issued but waiting for it failed
+func (w *Workspace) CreateChain(ctx context.Context, subnetID ids.ID, genesis []byte, name string) (ids.ID, error) {
+	start := time.Now()
+	txID, err := w.issuePChainTx(ctx, "create chain", func(wallet primary.Wallet, options ...common.Option) (*txs.Tx, error) {
+		return wallet.P().IssueCreateChainTx(
+			subnetID,
+			genesis,
+			constants.SubnetEVMID,
+			nil,
+			name,
+			options...,
+		)
+	}, subnetID)
+	if err != nil {
+		return txID, err
+	}
+	w.Log.Printf("✅ Created new chain %s in %s\n", txID, time.Since(start))
+	return txID, nil
+}